@@ -2,21 +2,34 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"gopkg.in/yaml.v3"
 
 	grpc_adapter "github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/in/grpc"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/in/healthz"
+	cache_adapter "github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/cache"
 	memory_adapter "github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/memory"
 	mysql_adapter "github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/mysql"
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+	"github.com/JoeShih716/go-mem-ledger/pkg/auth"
+	"github.com/JoeShih716/go-mem-ledger/pkg/buildinfo"
+	grpcpkg "github.com/JoeShih716/go-mem-ledger/pkg/grpc"
+	"github.com/JoeShih716/go-mem-ledger/pkg/metrics"
 	"github.com/JoeShih716/go-mem-ledger/pkg/mysql"
 	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
 	pb "github.com/JoeShih716/go-mem-ledger/proto"
@@ -30,19 +43,118 @@ const (
 	LedgerType_Level2_Memory_LMAX
 )
 
+// String 回傳 Ledger 類型名稱 (用於 Log)
+func (t LedgerType) String() string {
+	switch t {
+	case LedgerType_Level0_MySQL:
+		return "mysql"
+	case LedgerType_Level1_Memory_Mutex:
+		return "memory_mutex"
+	case LedgerType_Level2_Memory_LMAX:
+		return "memory_lmax"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(t))
+	}
+}
+
 // UsedLedgerType 設定使用哪種 Ledger
 const UsedLedgerType LedgerType = LedgerType_Level2_Memory_LMAX
 
+// walCloseTimeout 收到關機信號後，等待 WAL 刷入硬碟的最長時間 (包含停止 gRPC Server 與 Ledger 的時間)
+const walCloseTimeout = 5 * time.Second
+
+// maxRPCDuration 每個 RPC 在伺服器內部的最長執行時間
+const maxRPCDuration = 5 * time.Second
+
+// grpcPort gRPC Server 監聽的 Port
+const grpcPort = 50051
+
+// healthzAddr 健康檢查 HTTP Server 監聽地址 (/healthz, /readyz, /metrics)
+const healthzAddr = ":8080"
+
 type Config struct {
-	MySQL mysql.Config `yaml:"mysql"`
+	MySQL      mysql.Config         `yaml:"mysql"`
+	GRPCServer grpcpkg.ServerConfig `yaml:"grpc_server"`
+	Ledger     LedgerConfig         `yaml:"ledger"`
+	WAL        WALConfig            `yaml:"wal"`
+	Auth       AuthConfig           `yaml:"auth"`
+}
+
+// jwtSecretEnv 設定 JWT 簽章金鑰的環境變數 (優先於 auth.jwt_secret，避免把金鑰寫進設定檔)
+const jwtSecretEnv = "LEDGER_JWT_SECRET"
+
+// AuthConfig gRPC 驗證設定
+type AuthConfig struct {
+	// JWTSecret HS256 簽章金鑰，設定後所有 RPC (Unary 與 Stream) 都需要帶 Bearer Token；空字串表示不驗證
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+// walPathEnv 覆寫 WAL 路徑的環境變數 (優先於 wal.path)，容器中用來指向持久化 Volume
+const walPathEnv = "LEDGER_WAL_PATH"
+
+// WALConfig WAL 設定
+type WALConfig struct {
+	// Path 交易 WAL 檔案路徑 (預設 "wal.log")，帳戶事件 WAL (accounts.wal) 放在同一個目錄
+	Path string `yaml:"path"`
+	// RecoveryMode 啟動時遇到損毀紀錄的處理方式: "strict" (預設), "lenient", "last_good"
+	RecoveryMode string `yaml:"recovery_mode"`
+	// RetryMax / RetryBaseDelay WAL 寫入失敗時的重試次數與第一次重試的等待時間 (指數退避，0 表示不重試)
+	RetryMax       int           `yaml:"retry_max"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	// Mode LMAXLedger 的 WAL 寫入模式: "sync" (預設), "async", "disabled"
+	Mode string `yaml:"mode"`
+}
+
+// retryPolicy 轉換為記憶體帳本的 WAL 重試策略
+func (c WALConfig) retryPolicy() memory_adapter.RetryPolicy {
+	return memory_adapter.RetryPolicy{MaxRetries: c.RetryMax, BaseDelay: c.RetryBaseDelay}
+}
+
+// LedgerConfig 記憶體帳本設定
+type LedgerConfig struct {
+	// CheckpointMode 定期將 MutexLedger 的餘額寫入 MySQL，重啟時只重放檢查點之後的 WAL
+	CheckpointMode     bool          `yaml:"checkpoint_mode"`
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"` // 檢查點寫入間隔 (預設 1 分鐘)
+	// BalanceCacheTTL MySQLLedger 餘額查詢的快取時間 (0 表示不快取)，只影響 Level 0 的 GetBalance
+	BalanceCacheTTL time.Duration `yaml:"balance_cache_ttl"`
+	// CoreBalanceCacheTTL CoreUseCase 層的餘額快取時間 (0 表示不快取)，適用所有帳本類型
+	CoreBalanceCacheTTL time.Duration `yaml:"core_balance_cache_ttl"`
+	// SlowTransactionThreshold MySQLLedger 交易超過此耗時時記錄各階段耗時 (0 表示不記錄)
+	SlowTransactionThreshold time.Duration `yaml:"slow_transaction_threshold"`
+	// DecimalStorage users.balance 為 DECIMAL(20,4) (需先執行 scripts/mysql/migrations/003_balance_decimal.sql)
+	DecimalStorage bool `yaml:"decimal_storage"`
+	// QueueFullTimeout LMAXLedger 佇列已滿時的最長等待時間，超過回傳 ErrQueueFull (0 表示只受請求 ctx 限制)
+	QueueFullTimeout time.Duration `yaml:"queue_full_timeout"`
+	// HistoryCapacity 記憶體帳本保存的最近交易筆數 (所有帳戶合計，供 GetTransactionHistory 查詢)，0 表示不保存
+	HistoryCapacity int `yaml:"history_capacity"`
 }
 
 func main() {
+	enableReflection := flag.Bool("enable-reflection", false, "register gRPC reflection (local development only)")
+	allowInitialBalance := flag.Bool("allow-initial-balance", false, "allow CreateAccount with a non-zero initial_balance (local development and load testing only)")
+	flag.Parse()
+
 	// 1. 設定 Graceful Shutdown Context
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	cfg := loadConfig()
+	if *enableReflection {
+		cfg.GRPCServer.EnableReflection = true
+	}
+
+	// 啟動資訊 (同時有多個版本執行時，用來對應 Log 來自哪個版本)
+	slog.Info("starting ledger core",
+		"version", buildinfo.Version,
+		"git_commit", buildinfo.Commit,
+		"build_time", buildinfo.BuildTime,
+		"go_version", runtime.Version(),
+		"ledger_type", UsedLedgerType.String(),
+		"grpc_port", grpcPort,
+		"pid", os.Getpid(),
+	)
+	// GORM Log 以 JSON 輸出，方便 Log 收集系統解析
+	cfg.MySQL.Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
 	// 初始化 MySQL Client (Base Infrastructure)
 	dbClient, err := mysql.NewClient(cfg.MySQL)
@@ -52,67 +164,166 @@ func main() {
 	defer dbClient.Close()
 	log.Println("Connected to MySQL successfully")
 
-	// 載入account
-	ledgerRepo := mysql_adapter.NewMySQLLedger(dbClient)
+	// 啟動健康檢查 Server (同時提供 Prometheus /metrics，Collector 於各元件初始化後註冊)
+	metricsRegistry := prometheus.NewRegistry()
+	healthServer := healthz.NewServer(healthzAddr, dbClient,
+		healthz.WithHandler("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})),
+	)
+	go func() {
+		log.Printf("Starting healthz server on %s", healthzAddr)
+		if err := healthServer.ListenAndServe(); err != nil {
+			log.Printf("healthz server stopped: %v", err)
+		}
+	}()
+
+	// MySQL Ledger 同時作為記憶體帳本初始化時的帳戶來源 (分頁載入)
+	ledgerOpts := []mysql_adapter.MySQLLedgerOption{
+		mysql_adapter.WithBalanceCache(cfg.Ledger.BalanceCacheTTL),
+		mysql_adapter.WithSlowTransactionThreshold(cfg.Ledger.SlowTransactionThreshold),
+	}
+	if cfg.Ledger.DecimalStorage {
+		ledgerOpts = append(ledgerOpts, mysql_adapter.WithDecimalStorage())
+	}
+	ledgerRepo := mysql_adapter.NewMySQLLedger(dbClient, ledgerOpts...)
+	// 確認資料表存在後才載入帳戶
+	if err := ledgerRepo.Init(ctx); err != nil {
+		log.Fatalf("Failed to init MySQLLedger: %v", err)
+	}
 
-	accounts, err := ledgerRepo.LoadAllAccounts(ctx)
+	// 每晚清理過期的交易紀錄
+	if cfg.MySQL.TransactionRetentionDays > 0 {
+		schedulePurge(ctx, ledgerRepo, cfg.MySQL.TransactionRetentionDays)
+	}
+
+	recoveryMode, err := memory_adapter.ParseRecoveryMode(cfg.WAL.RecoveryMode)
+	if err != nil {
+		log.Fatalf("Invalid WAL config: %v", err)
+	}
+	walMode, err := memory_adapter.ParseWALMode(cfg.WAL.Mode)
 	if err != nil {
-		log.Fatalf("Failed to load all accounts: %v", err)
+		log.Fatalf("Invalid WAL config: %v", err)
 	}
-	log.Printf("Loaded %d accounts", len(accounts))
 
+	// shutdownDeadline 收到關機信號時設為信號後 walCloseTimeout，關閉 WAL 的 defer 最多等到此時
+	var shutdownDeadline time.Time
 	var usedLedger usecase.Ledger
+	// LMAX 事件迴圈不跟隨訊號 ctx 結束，由關機流程在 gRPC Server 停止後呼叫 Stop
+	var lmaxLedger *memory_adapter.LMAXLedger
 	switch UsedLedgerType {
 	case LedgerType_Level0_MySQL:
 		usedLedger = ledgerRepo
 	case LedgerType_Level1_Memory_Mutex:
 		// 初始化 WAL
-		walFile, err := wal.NewWAL("wal.log", 0)
+		walFile, err := wal.NewWALFromFile(cfg.WAL.Path, 0)
 		if err != nil {
 			log.Fatalf("Failed to init WAL: %v", err)
 		}
-		defer walFile.Close()
+		defer func() { closeWAL(walFile, shutdownDeadline) }()
+		// 帳戶事件 (建立/凍結/刪除) 使用獨立的 WAL
+		accountWALFile, err := wal.NewWALFromFile(filepath.Join(filepath.Dir(cfg.WAL.Path), "accounts.wal"), 0)
+		if err != nil {
+			log.Fatalf("Failed to init account WAL: %v", err)
+		}
+		defer func() { closeWAL(accountWALFile, shutdownDeadline) }()
 
-		mutexLedger, err := memory_adapter.NewMutexLedger(accounts, walFile)
+		mutexOpts := []memory_adapter.MutexLedgerOption{
+			memory_adapter.WithRecoveryMode(recoveryMode),
+			memory_adapter.WithRetryPolicy(cfg.WAL.retryPolicy()),
+			memory_adapter.WithHistoryCapacity(cfg.Ledger.HistoryCapacity),
+		}
+		if cfg.Ledger.CheckpointMode {
+			sequence, err := ledgerRepo.LoadCheckpointSequence(ctx)
+			if err != nil {
+				log.Fatalf("Failed to load checkpoint: %v", err)
+			}
+			log.Printf("Replaying WAL after checkpoint sequence %d", sequence)
+			mutexOpts = append(mutexOpts, memory_adapter.WithReplayAfterSequence(sequence))
+		}
+		mutexLedger, err := memory_adapter.NewMutexLedger(ctx, ledgerRepo, accountWALFile, walFile, mutexOpts...)
 		if err != nil {
 			log.Fatalf("Failed to init MutexLedger: %v", err)
 		}
+		if cfg.Ledger.CheckpointMode {
+			mutexLedger.StartCheckpointing(ctx, ledgerRepo, cfg.Ledger.CheckpointInterval)
+		}
 		usedLedger = mutexLedger
 	case LedgerType_Level2_Memory_LMAX:
-		walFile, err := wal.NewWAL("wal.log", 0)
+		walFile, err := wal.NewWALFromFile(cfg.WAL.Path, 0)
 		if err != nil {
 			log.Fatalf("Failed to init WAL: %v", err)
 		}
-		defer walFile.Close()
+		defer func() { closeWAL(walFile, shutdownDeadline) }()
 
-		lmaxLedger, err := memory_adapter.NewLMAXLedger(accounts, walFile)
+		lmaxLedger, err = memory_adapter.NewLMAXLedger(ctx, ledgerRepo, walFile,
+			memory_adapter.WithLMAXRecoveryMode(recoveryMode),
+			memory_adapter.WithLMAXRetryPolicy(cfg.WAL.retryPolicy()),
+			memory_adapter.WithLMAXWALMode(walMode),
+			memory_adapter.WithLMAXQueueFullTimeout(cfg.Ledger.QueueFullTimeout),
+			memory_adapter.WithLMAXHistoryCapacity(cfg.Ledger.HistoryCapacity),
+		)
 		if err != nil {
 			log.Fatalf("Failed to init LMAXLedger: %v", err)
 		}
-		lmaxLedger.Start(ctx)
+		lmaxLedger.Start(context.Background())
+		metricsRegistry.MustRegister(metrics.NewLMAXCollector(func() metrics.LMAXStats {
+			return metrics.LMAXStats(lmaxLedger.Stats())
+		}))
 		usedLedger = lmaxLedger
 	default:
 		log.Fatalf("Invalid ledger type: %d", UsedLedgerType)
 	}
 	// 初始化 UseCase
-	coreUseCase := usecase.NewCoreUseCase(usedLedger)
+	var useCaseOpts []usecase.UseCaseOption
+	if cfg.Ledger.CoreBalanceCacheTTL > 0 {
+		useCaseOpts = append(useCaseOpts,
+			usecase.WithBalanceCache(cache_adapter.NewTTLCache()),
+			usecase.WithBalanceCacheTTL(cfg.Ledger.CoreBalanceCacheTTL),
+		)
+	}
+	coreUseCase := usecase.NewCoreUseCase(usedLedger, useCaseOpts...)
 
 	// 初始化 gRPC Adapter (Driving Adapter)
-	grpcServer := grpc_adapter.NewGrpcServer(coreUseCase)
+	grpcServer := grpc_adapter.NewGrpcServer(coreUseCase, grpc_adapter.NewTransactionFactory(),
+		grpc_adapter.WithAllowInitialBalance(*allowInitialBalance),
+	)
+	if *allowInitialBalance {
+		log.Println("CreateAccount initial_balance enabled (clients can create funds)")
+	}
 
 	// 6. 啟動 gRPC Server
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
+	// RecoveryInterceptor 放在最外層，其他 Interceptor 發生 Panic 也會被攔截
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		grpc_adapter.RecoveryInterceptor(),
+		grpc_adapter.RequestIDInterceptor(),
+	}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if cfg.Auth.JWTSecret != "" {
+		secret := []byte(cfg.Auth.JWTSecret)
+		unaryInterceptors = append(unaryInterceptors, auth.JWTInterceptor(secret))
+		streamInterceptors = append(streamInterceptors, auth.JWTStreamInterceptor(secret))
+		log.Println("gRPC JWT authentication enabled")
+	}
+	unaryInterceptors = append(unaryInterceptors, grpc_adapter.WithMaxRPCDuration(maxRPCDuration))
+	serverOpts := append(cfg.GRPCServer.ServerOptions(),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+	s := grpc.NewServer(serverOpts...)
 	pb.RegisterLedgerServiceServer(s, grpcServer)
-	reflection.Register(s) // 方便 gRPC Client 測試 (如 Postman/BloomRPC)
+	if cfg.GRPCServer.EnableReflection {
+		// 方便 gRPC Client 測試 (如 Postman/BloomRPC)，正式環境不開啟以免暴露服務定義
+		reflection.Register(s)
+		log.Println("gRPC reflection enabled")
+	}
 
 	// Graceful Shutdown
 	go func() {
-		log.Printf("Starting gRPC server on :50051")
+		log.Printf("Starting gRPC server on :%d", grpcPort)
 		if err := s.Serve(lis); err != nil {
 			log.Fatalf("failed to serve: %v", err)
 		}
@@ -120,13 +331,60 @@ func main() {
 
 	// Wait for interrupt
 	<-ctx.Done()
+	shutdownDeadline = time.Now().Add(walCloseTimeout)
 	stop()
 	log.Println("Shutting down server...")
 
+	// 先停止接收新的 RPC，再讓 Ledger 處理完已排隊的交易 (之後才關閉 WAL)
 	s.GracefulStop()
+	if lmaxLedger != nil {
+		<-lmaxLedger.Stop()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shutdown healthz server: %v", err)
+	}
 	log.Println("Server exited")
 }
 
+// schedulePurge 每天凌晨清理超過保留天數的交易紀錄，直到 ctx 結束
+func schedulePurge(ctx context.Context, ledgerRepo *mysql_adapter.MySQLLedger, retentionDays int) {
+	var purge func()
+	purge = func() {
+		if ctx.Err() != nil {
+			return
+		}
+		before := time.Now().AddDate(0, 0, -retentionDays)
+		rows, err := ledgerRepo.PurgeTransactionsBefore(ctx, before)
+		if err != nil {
+			log.Printf("Failed to purge transactions: %v", err)
+		} else {
+			log.Printf("Purged %d transactions created before %s", rows, before.Format(time.RFC3339))
+		}
+		time.AfterFunc(untilNextMidnight(time.Now()), purge)
+	}
+	time.AfterFunc(untilNextMidnight(time.Now()), purge)
+}
+
+// untilNextMidnight 計算距離下一個午夜 (本地時間) 的時間
+func untilNextMidnight(now time.Time) time.Duration {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+// closeWAL 關閉 WAL，最多等到 deadline 將緩衝區刷入硬碟 (未收到關機信號時 deadline 為零值，改為等待 walCloseTimeout)
+func closeWAL(walFile *wal.WAL, deadline time.Time) {
+	if deadline.IsZero() {
+		deadline = time.Now().Add(walCloseTimeout)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := walFile.CloseWithContext(ctx); err != nil {
+		log.Printf("Failed to close WAL: %v", err)
+	}
+}
+
 // loadConfig 載入設定
 func loadConfig() Config {
 	cfgData, err := os.ReadFile("config/config.yaml")
@@ -148,5 +406,19 @@ func loadConfig() Config {
 	if cfg.MySQL.ConnMaxLifetime == 0 {
 		cfg.MySQL.ConnMaxLifetime = 30 * time.Minute
 	}
+	// 補全 gRPC Server 預設限制
+	cfg.GRPCServer.SetDefaults()
+	if path := os.Getenv(walPathEnv); path != "" {
+		cfg.WAL.Path = path
+	}
+	if secret := os.Getenv(jwtSecretEnv); secret != "" {
+		cfg.Auth.JWTSecret = secret
+	}
+	if cfg.WAL.Path == "" {
+		cfg.WAL.Path = "wal.log"
+	}
+	if cfg.Ledger.CheckpointInterval == 0 {
+		cfg.Ledger.CheckpointInterval = time.Minute
+	}
 	return cfg
 }
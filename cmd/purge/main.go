@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	mysql_adapter "github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/mysql"
+	"github.com/JoeShih716/go-mem-ledger/pkg/mysql"
+)
+
+type Config struct {
+	MySQL mysql.Config `yaml:"mysql"`
+}
+
+// purge 刪除超過保留天數的交易紀錄
+// 用法: go run ./cmd/purge [-config config/config.yaml] [-days 365]
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "config file path")
+	days := flag.Int("days", 0, "retention days (default: mysql.transaction_retention_days)")
+	flag.Parse()
+
+	cfg := loadConfig(*configPath)
+	retentionDays := cfg.MySQL.TransactionRetentionDays
+	if *days > 0 {
+		retentionDays = *days
+	}
+	if retentionDays <= 0 {
+		log.Fatalf("retention days must be positive, got %d", retentionDays)
+	}
+
+	dbClient, err := mysql.NewClient(cfg.MySQL)
+	if err != nil {
+		log.Fatalf("Failed to connect to MySQL: %v", err)
+	}
+	defer dbClient.Close()
+
+	ledgerRepo := mysql_adapter.NewMySQLLedger(dbClient)
+	before := time.Now().AddDate(0, 0, -retentionDays)
+	rows, err := ledgerRepo.PurgeTransactionsBefore(context.Background(), before)
+	if err != nil {
+		log.Fatalf("Failed to purge transactions: %v", err)
+	}
+	fmt.Printf("Deleted %d transactions created before %s\n", rows, before.Format(time.RFC3339))
+}
+
+// loadConfig 載入設定
+func loadConfig(path string) Config {
+	cfgData, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read config file: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(cfgData, &cfg); err != nil {
+		log.Fatalf("Failed to parse config: %v", err)
+	}
+
+	// 清理工具只需要少量連線
+	if cfg.MySQL.MaxOpenConns == 0 {
+		cfg.MySQL.MaxOpenConns = 1
+	}
+	if cfg.MySQL.MaxIdleConns == 0 {
+		cfg.MySQL.MaxIdleConns = 1
+	}
+	return cfg
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pkguuid "github.com/JoeShih716/go-mem-ledger/pkg/uuid"
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// setupAccounts 壓測前建立情境使用的所有帳戶 (--setup)，已存在的帳戶略過
+// 帳戶不存在時每筆交易都會回傳 account not found，TPS 沒有參考價值
+//
+// 參數:
+//
+//	ctx: 上下文
+//	c: gRPC Client
+//	accounts: 帳戶 ID
+//	initialBalance: 新帳戶的初始餘額 (已存在的帳戶不會調整)
+//
+// 回傳:
+//
+//	error: 建立失敗的錯誤 (帳戶已存在不算錯誤)
+func setupAccounts(ctx context.Context, c pb.LedgerServiceClient, accounts []int64, initialBalance int64) error {
+	uuidGen := pkguuid.NewBatchGenerator(len(accounts))
+	created, existing := 0, 0
+	for _, id := range accounts {
+		_, err := c.CreateAccount(ctx, &pb.CreateAccountRequest{
+			RefId:          uuidGen.New().String(),
+			AccountId:      id,
+			InitialBalance: initialBalance,
+		})
+		switch {
+		case err == nil:
+			created++
+		case status.Code(err) == codes.AlreadyExists:
+			existing++
+		default:
+			return fmt.Errorf("create account %d: %w", id, err)
+		}
+	}
+	fmt.Printf("Setup: %d accounts created, %d already existed\n", created, existing)
+	return nil
+}
+
+// sumBalances 查詢所有帳戶的餘額總和 (--verify 在壓測前後各查詢一次)
+func sumBalances(ctx context.Context, c pb.LedgerServiceClient, accounts []int64) (int64, error) {
+	var total int64
+	for _, id := range accounts {
+		resp, err := c.GetBalance(ctx, &pb.GetBalanceRequest{AccountId: id})
+		if err != nil {
+			return 0, fmt.Errorf("get balance of account %d: %w", id, err)
+		}
+		total += resp.GetBalance()
+	}
+	return total, nil
+}
+
+// verifyBalances 確認餘額守恆：壓測後的餘額總和 - 壓測前的餘額總和 = 成功存款總額 - 成功提款總額 (轉帳不影響總和)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	c: gRPC Client
+//	accounts: 帳戶 ID
+//	before: 壓測前的餘額總和
+//	report: 壓測結果
+//	amount: 每筆交易的金額
+//
+// 回傳:
+//
+//	error: 查詢失敗或餘額不守恆
+func verifyBalances(ctx context.Context, c pb.LedgerServiceClient, accounts []int64, before int64, report *loadReport, amount int64) error {
+	after, err := sumBalances(ctx, c, accounts)
+	if err != nil {
+		return err
+	}
+	expected, uncertain := report.NetChange(amount)
+	fmt.Printf("Verify: balance sum before %d, after %d, expected change %d, actual change %d\n",
+		before, after, expected, after-before)
+	if after-before == expected {
+		fmt.Println("Verify: total balance conserved")
+		return nil
+	}
+	if uncertain > 0 {
+		// gRPC 錯誤 (如 Deadline Exceeded) 的請求可能已在伺服器端成功，無法判斷是否計入
+		return fmt.Errorf("total balance mismatch: expected change %d, actual %d (%d deposit/withdraw requests failed with gRPC errors and may have been applied)",
+			expected, after-before, uncertain)
+	}
+	return fmt.Errorf("total balance mismatch: expected change %d, actual %d", expected, after-before)
+}
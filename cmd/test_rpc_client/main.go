@@ -4,35 +4,91 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	grpcpool "github.com/JoeShih716/go-mem-ledger/pkg/grpc"
+	pkguuid "github.com/JoeShih716/go-mem-ledger/pkg/uuid"
 	pb "github.com/JoeShih716/go-mem-ledger/proto"
 )
 
 const (
 	TotalCount  = 1000000
 	Concurrency = 1000
+	// UUIDBatchSize 每次預先產生的 UUID 數量 (減少 crypto/rand syscall)
+	UUIDBatchSize = 4096
+	// ServerAddr 壓測目標
+	ServerAddr = "localhost:50051"
+	// WarmUpTimeout 開始壓測前等待連線就緒的最長時間
+	WarmUpTimeout = 5 * time.Second
+	// TransactionAmount 每筆交易的金額 (定點數, 放大 10000 倍)
+	TransactionAmount = 10000
+	// AccountCheckTimeout --setup / --verify 建立帳戶與查詢餘額的最長時間
+	AccountCheckTimeout = 60 * time.Second
 )
 
 func main() {
+	outputCSV := flag.String("output-csv", "", "將每筆請求的延遲寫入 CSV 檔案 (離線分析用)")
+	txType := flag.String("type", "deposit", "交易類型: deposit, withdraw, transfer (設定各類型比例時忽略)")
+	fromAccount := flag.Int64("from-account", 2, "提款與轉帳的來源帳戶")
+	toAccount := flag.Int64("to-account", 1, "存款與轉帳的目標帳戶")
+	accountsFile := flag.String("accounts-file", "", "帳戶 ID CSV 檔案 (每列第一欄)，設定後輪流使用其中的帳戶")
+	depositPct := flag.Int("deposit-pct", 0, "存款比例 (%)，與另外兩個比例總和需為 100")
+	withdrawPct := flag.Int("withdraw-pct", 0, "提款比例 (%)")
+	transferPct := flag.Int("transfer-pct", 0, "轉帳比例 (%)")
+	setup := flag.Bool("setup", false, "壓測前建立情境使用的所有帳戶 (已存在的略過)")
+	setupBalance := flag.Int64("setup-balance", 0, "--setup 建立帳戶時的初始餘額 (定點數, 放大 10000 倍；大於 0 時 Core 需以 --allow-initial-balance 啟動)")
+	verify := flag.Bool("verify", false, "壓測後確認餘額總和的變化等於成功存款減成功提款的總額")
+	flag.Parse()
+
+	scn, err := newScenario(*txType, *depositPct, *withdrawPct, *transferPct, *fromAccount, *toAccount, *accountsFile)
+	if err != nil {
+		log.Fatalf("invalid scenario: %v", err)
+	}
+
 	// 計算單筆交易 buffer大小
 	// measureTransactionSize()
 	// return
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	pool := grpcpool.NewPool()
+	defer pool.Close()
+	// 先完成握手，避免第一批請求的延遲包含建立連線的時間
+	warmUpCtx, warmUpCancel := context.WithTimeout(context.Background(), WarmUpTimeout)
+	err = pool.WarmUp(warmUpCtx, []string{ServerAddr})
+	warmUpCancel()
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	conn, err := pool.GetConnection(ServerAddr)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
-	defer conn.Close()
 	c := pb.NewLedgerServiceClient(conn)
 
+	accounts := scn.Accounts()
+	if *setup {
+		setupCtx, setupCancel := context.WithTimeout(context.Background(), AccountCheckTimeout)
+		err := setupAccounts(setupCtx, c, accounts, *setupBalance)
+		setupCancel()
+		if err != nil {
+			log.Fatalf("setup failed: %v", err)
+		}
+	}
+	var balanceBefore int64
+	if *verify {
+		verifyCtx, verifyCancel := context.WithTimeout(context.Background(), AccountCheckTimeout)
+		balanceBefore, err = sumBalances(verifyCtx, c, accounts)
+		verifyCancel()
+		if err != nil {
+			log.Fatalf("verify failed (run with --setup if the accounts do not exist): %v", err)
+		}
+	}
+
 	totalCount := TotalCount
 	concurrency := Concurrency
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
@@ -42,6 +98,8 @@ func main() {
 	wg.Add(totalCount)
 
 	sem := make(chan struct{}, concurrency)
+	uuidGen := pkguuid.NewBatchGenerator(UUIDBatchSize)
+	report := newLoadReport(totalCount, *outputCSV != "")
 
 	startTime := time.Now()
 
@@ -52,18 +110,17 @@ func main() {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			refID := uuid.New().String()
-			_, err := c.Transfer(ctx, &pb.TransferRequest{
-				RefId:         refID,
-				Type:          pb.TransactionType_DEPOSIT,
-				FromAccountId: 0,
-				ToAccountId:   1,
-				Amount:        10000,
-			})
-
-			if err != nil {
-				if idx%10000 == 0 {
+			refID := uuidGen.New().String()
+			reqStart := time.Now()
+			req := scn.Request(idx, refID, TransactionAmount)
+			resp, err := c.Transfer(ctx, req)
+			report.Record(idx, req.Type, time.Since(reqStart), resp.GetMessage(), resp.GetSuccess(), err)
+
+			if idx%10000 == 0 {
+				if err != nil {
 					log.Printf("Transfer %d failed: %v", idx, err)
+				} else {
+					log.Printf("Transfer %d sequence: %d", idx, resp.GetSequence())
 				}
 			}
 		}(i)
@@ -74,6 +131,24 @@ func main() {
 	elapsed := time.Since(startTime)
 	fmt.Printf("Completed %d requests in %v\n", totalCount, elapsed)
 	fmt.Printf("TPS: %.2f\n", float64(totalCount)/elapsed.Seconds())
+	report.Print()
+	report.PrintByType(elapsed)
+
+	if *outputCSV != "" {
+		if err := report.WriteCSV(*outputCSV); err != nil {
+			log.Fatalf("write csv failed: %v", err)
+		}
+		fmt.Printf("Latency samples written to %s\n", *outputCSV)
+	}
+
+	if *verify {
+		verifyCtx, verifyCancel := context.WithTimeout(context.Background(), AccountCheckTimeout)
+		err := verifyBalances(verifyCtx, c, accounts, balanceBefore, report, TransactionAmount)
+		verifyCancel()
+		if err != nil {
+			log.Fatalf("verify failed: %v", err)
+		}
+	}
 }
 
 // measureTransactionSize 測試計算單筆交易大小
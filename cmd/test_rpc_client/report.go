@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// 錯誤分類 (印出報表時依此順序)
+const (
+	errorKindInsufficientBalance = "insufficient balance"
+	errorKindAccountNotFound     = "account not found"
+	errorKindWALFailed           = "wal write failed"
+	errorKindDeadlineExceeded    = "grpc deadline exceeded"
+	errorKindOther               = "other"
+)
+
+var errorKinds = []string{
+	errorKindInsufficientBalance,
+	errorKindAccountNotFound,
+	errorKindWALFailed,
+	errorKindDeadlineExceeded,
+	errorKindOther,
+}
+
+// 延遲直方圖的記錄範圍 (微秒) 與精度 (有效位數)
+const (
+	histogramMinLatency = 1
+	histogramMaxLatency = int64(2 * time.Minute / time.Microsecond)
+	histogramSigFigs    = 3
+)
+
+// loadReport 收集壓測結果
+// 延遲記錄在 HDR 直方圖中 (記憶體用量固定，不隨請求數成長)，直方圖與錯誤統計以 mu 保護；
+// 每個請求寫入自己的 types[idx] / failed[idx] / grpcFailed[idx] (驗證餘額時使用)，不需要加鎖
+type loadReport struct {
+	types  []pb.TransactionType
+	failed []bool
+	// grpcFailed gRPC 錯誤 (非業務錯誤)，伺服器端是否已處理無法得知
+	grpcFailed []bool
+	// samples 原始延遲樣本，只有指定 --output-csv 時才保留 (否則為 nil)
+	samples   []time.Duration
+	mu        sync.Mutex
+	histogram *hdrhistogram.Histogram
+	errors    map[string]int
+}
+
+// newLoadReport 建立 loadReport
+//
+// 參數:
+//
+//	total: 請求總數
+//	keepSamples: 是否保留原始延遲樣本 (WriteCSV 使用)
+//
+// 回傳:
+//
+//	*loadReport: loadReport 實例
+func newLoadReport(total int, keepSamples bool) *loadReport {
+	r := &loadReport{
+		types:      make([]pb.TransactionType, total),
+		failed:     make([]bool, total),
+		grpcFailed: make([]bool, total),
+		histogram:  hdrhistogram.New(histogramMinLatency, histogramMaxLatency, histogramSigFigs),
+		errors:     make(map[string]int),
+	}
+	if keepSamples {
+		r.samples = make([]time.Duration, total)
+	}
+	return r
+}
+
+// Record 記錄單筆請求的延遲與結果
+//
+// 參數:
+//
+//	idx: 請求序號
+//	txType: 交易類型
+//	latency: 請求延遲
+//	message: 業務錯誤訊息 (success=false 時)
+//	success: 業務是否成功
+//	err: gRPC 錯誤
+func (r *loadReport) Record(idx int, txType pb.TransactionType, latency time.Duration, message string, success bool, err error) {
+	r.types[idx] = txType
+	if r.samples != nil {
+		r.samples[idx] = latency
+	}
+	// 超出範圍的延遲以上下限記錄 (RecordValue 對超出範圍的值回傳錯誤且不記錄)
+	us := min(max(latency.Microseconds(), histogramMinLatency), histogramMaxLatency)
+	failed := err != nil || !success
+	if failed {
+		r.failed[idx] = true
+		r.grpcFailed[idx] = err != nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.histogram.RecordValue(us)
+	if failed {
+		r.errors[classifyError(message, err)]++
+	}
+}
+
+// classifyError 將 gRPC 錯誤與業務錯誤訊息分類
+func classifyError(message string, err error) string {
+	if err != nil {
+		if status.Code(err) == codes.DeadlineExceeded {
+			return errorKindDeadlineExceeded
+		}
+		return errorKindOther
+	}
+	switch message {
+	case domain.ErrInsufficientBalance.Error():
+		return errorKindInsufficientBalance
+	case domain.ErrAccountNotFound.Error():
+		return errorKindAccountNotFound
+	case domain.ErrWALWriteFailed.Error():
+		return errorKindWALFailed
+	}
+	return errorKindOther
+}
+
+// Print 印出延遲百分位數與錯誤分類統計
+func (r *loadReport) Print() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Println("Latency:")
+	for _, p := range []float64{50, 95, 99, 99.9} {
+		fmt.Printf("  p%-5v %v\n", p, time.Duration(r.histogram.ValueAtQuantile(p))*time.Microsecond)
+	}
+	if r.histogram.TotalCount() > 0 {
+		fmt.Printf("  max    %v\n", time.Duration(r.histogram.Max())*time.Microsecond)
+	}
+
+	fmt.Println("Errors:")
+	total := 0
+	for _, kind := range errorKinds {
+		if count := r.errors[kind]; count > 0 {
+			fmt.Printf("  %-24s %d\n", kind, count)
+			total += count
+		}
+	}
+	if total == 0 {
+		fmt.Println("  none")
+	}
+}
+
+// PrintByType 依交易類型印出 TPS 與錯誤率 (TPS 以整體壓測時間計算)
+func (r *loadReport) PrintByType(elapsed time.Duration) {
+	counts := make(map[pb.TransactionType]int)
+	failures := make(map[pb.TransactionType]int)
+	for idx, txType := range r.types {
+		counts[txType]++
+		if r.failed[idx] {
+			failures[txType]++
+		}
+	}
+
+	fmt.Println("By type:")
+	for _, txType := range scenarioTypes {
+		count := counts[txType]
+		if count == 0 {
+			continue
+		}
+		fmt.Printf("  %-10s requests %-8d TPS %-10.2f error rate %.2f%%\n",
+			txType, count, float64(count)/elapsed.Seconds(), float64(failures[txType])*100/float64(count))
+	}
+}
+
+// NetChange 成功的交易對餘額總和的影響 (存款增加、提款減少，轉帳不變)
+//
+// 參數:
+//
+//	amount: 每筆交易的金額
+//
+// 回傳:
+//
+//	int64: 餘額總和的預期變化
+//	int: 結果不確定 (gRPC 錯誤) 的存款與提款筆數
+func (r *loadReport) NetChange(amount int64) (int64, int) {
+	var net int64
+	uncertain := 0
+	for idx, txType := range r.types {
+		if txType == pb.TransactionType_TRANSFER {
+			continue
+		}
+		if r.grpcFailed[idx] {
+			uncertain++
+		}
+		if r.failed[idx] {
+			continue
+		}
+		switch txType {
+		case pb.TransactionType_DEPOSIT:
+			net += amount
+		case pb.TransactionType_WITHDRAW:
+			net -= amount
+		}
+	}
+	return net, uncertain
+}
+
+// WriteCSV 將原始延遲樣本寫入 CSV (index, latency_us)，供離線分析 (需以 keepSamples 建立)
+func (r *loadReport) WriteCSV(path string) error {
+	if r.samples == nil {
+		return fmt.Errorf("latency samples were not kept")
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"index", "latency_us"}); err != nil {
+		return err
+	}
+	for idx, latency := range r.samples {
+		if err := w.Write([]string{strconv.Itoa(idx), strconv.FormatInt(latency.Microseconds(), 10)}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// scenarioTypes 壓測支援的交易類型 (報表依此順序印出)
+var scenarioTypes = []pb.TransactionType{
+	pb.TransactionType_DEPOSIT,
+	pb.TransactionType_WITHDRAW,
+	pb.TransactionType_TRANSFER,
+}
+
+// scenario 壓測情境：各交易類型的比例與使用的帳戶
+//
+// 結構:
+//
+//	percents: 各交易類型的百分比 (與 scenarioTypes 對應，總和為 100)
+//	accounts: 帳戶 ID 清單 (--accounts-file)，有設定時忽略 from / to
+//	from: 提款與轉帳的來源帳戶
+//	to: 存款與轉帳的目標帳戶
+type scenario struct {
+	percents []int
+	accounts []int64
+	from     int64
+	to       int64
+}
+
+// newScenario 依 Flag 建立壓測情境
+//
+// 參數:
+//
+//	txType: 單一交易類型 (deposit / withdraw / transfer)，各比例皆為 0 時使用
+//	depositPct, withdrawPct, transferPct: 各類型的百分比 (總和需為 100)
+//	from, to: 來源 / 目標帳戶
+//	accountsFile: 帳戶 ID CSV 檔案路徑 (可為空)
+//
+// 回傳:
+//
+//	*scenario: 壓測情境
+//	error: Flag 設定錯誤
+func newScenario(txType string, depositPct, withdrawPct, transferPct int, from, to int64, accountsFile string) (*scenario, error) {
+	s := &scenario{from: from, to: to}
+	if depositPct == 0 && withdrawPct == 0 && transferPct == 0 {
+		switch txType {
+		case "deposit":
+			depositPct = 100
+		case "withdraw":
+			withdrawPct = 100
+		case "transfer":
+			transferPct = 100
+		default:
+			return nil, fmt.Errorf("unknown --type %q (deposit, withdraw, transfer)", txType)
+		}
+	}
+	if depositPct < 0 || withdrawPct < 0 || transferPct < 0 || depositPct+withdrawPct+transferPct != 100 {
+		return nil, fmt.Errorf("--deposit-pct, --withdraw-pct and --transfer-pct must be non-negative and sum to 100")
+	}
+	s.percents = []int{depositPct, withdrawPct, transferPct}
+
+	if accountsFile != "" {
+		accounts, err := loadAccountIDs(accountsFile)
+		if err != nil {
+			return nil, err
+		}
+		if transferPct > 0 && len(accounts) < 2 {
+			return nil, errors.New("transfers need at least two accounts in --accounts-file")
+		}
+		s.accounts = accounts
+	} else if transferPct > 0 && from == to {
+		return nil, errors.New("--from-account and --to-account must differ for transfers")
+	}
+	return s, nil
+}
+
+// Request 產生第 idx 筆請求 (依 idx 決定類型，各類型依比例交錯出現)
+func (s *scenario) Request(idx int, refID string, amount int64) *pb.TransferRequest {
+	txType := s.typeAt(idx)
+	from, to := s.accountsAt(idx)
+	req := &pb.TransferRequest{
+		RefId:  refID,
+		Type:   txType,
+		Amount: amount,
+	}
+	switch txType {
+	case pb.TransactionType_DEPOSIT:
+		req.ToAccountId = to
+	case pb.TransactionType_WITHDRAW:
+		req.FromAccountId = from
+	default:
+		req.FromAccountId = from
+		req.ToAccountId = to
+	}
+	return req
+}
+
+// Accounts 情境會使用到的帳戶 (不重複，依出現順序)
+func (s *scenario) Accounts() []int64 {
+	if len(s.accounts) > 0 {
+		return uniqueAccounts(s.accounts)
+	}
+	var accounts []int64
+	// 依類型比例判斷：存款只用 to，提款只用 from，轉帳兩者都用
+	if s.percents[1] > 0 || s.percents[2] > 0 {
+		accounts = append(accounts, s.from)
+	}
+	if s.percents[0] > 0 || s.percents[2] > 0 {
+		accounts = append(accounts, s.to)
+	}
+	return uniqueAccounts(accounts)
+}
+
+// uniqueAccounts 去除重複的帳戶 ID (保留第一次出現的順序)
+func uniqueAccounts(accounts []int64) []int64 {
+	seen := make(map[int64]struct{}, len(accounts))
+	unique := make([]int64, 0, len(accounts))
+	for _, id := range accounts {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// typeAt 第 idx 筆請求的交易類型 (每 100 筆依比例分配)
+func (s *scenario) typeAt(idx int) pb.TransactionType {
+	slot := idx % 100
+	for i, pct := range s.percents {
+		if slot < pct {
+			return scenarioTypes[i]
+		}
+		slot -= pct
+	}
+	return scenarioTypes[len(scenarioTypes)-1]
+}
+
+// accountsAt 第 idx 筆請求的來源與目標帳戶 (使用帳戶清單時依序輪替，兩者不會相同)
+func (s *scenario) accountsAt(idx int) (int64, int64) {
+	n := len(s.accounts)
+	if n == 0 {
+		return s.from, s.to
+	}
+	if n == 1 {
+		return s.accounts[0], s.accounts[0]
+	}
+	from := idx % n
+	// 目標帳戶跳過來源帳戶，讓每個帳戶都會轉給其他所有帳戶
+	to := (from + 1 + (idx/n)%(n-1)) % n
+	return s.accounts[from], s.accounts[to]
+}
+
+// loadAccountIDs 從 CSV 讀取帳戶 ID (每列第一欄；無法解析的列 (如標題) 略過)
+func loadAccountIDs(path string) ([]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	var accounts []int64
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil || id <= 0 {
+			continue
+		}
+		accounts = append(accounts, id)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no account ids in %s", path)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// sequenceKeys 紀錄中順序號的欄位名稱 (新格式為縮寫 "seq"，舊格式為完整名稱 "Sequence")
+var sequenceKeys = []string{"seq", "Sequence"}
+
+// repairSummary 修復結果統計
+type repairSummary struct {
+	recovered     int
+	skipped       []wal.SkippedEntry
+	skippedBytes  int64
+	firstSequence uint64
+	lastSequence  uint64
+	hasSequence   bool
+	gaps          []sequenceGap
+}
+
+// sequenceGap 一段缺號的順序號範圍 [from, to]
+type sequenceGap struct {
+	from uint64
+	to   uint64
+}
+
+// wal_repair 從損毀的 WAL 救回所有可解析的紀錄，保留原本的順序號寫入新檔案
+// 不重新編號：順序號需要與 ledger_checkpoints.sequence 及 transactions.sequence 一致，重新編號會讓檢查點之後的交易在重放時被略過
+// 略過的紀錄造成的缺號會列在摘要中，由操作人員對照 MySQL 確認是否需要補回
+// 用法: go run ./cmd/wal_repair -input data/wal.log [-output repaired.wal]
+// 註: WAL 格式沒有逐筆 Checksum，損毀判斷以該行能否解析為 JSON 為準
+func main() {
+	input := flag.String("input", "", "corrupted WAL file path")
+	output := flag.String("output", "repaired.wal", "repaired WAL file path (must not exist)")
+	flag.Parse()
+
+	if *input == "" {
+		log.Fatal("-input is required")
+	}
+	if _, err := os.Stat(*output); err == nil {
+		log.Fatalf("output file %s already exists", *output)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Failed to stat output file: %v", err)
+	}
+
+	summary, err := repair(context.Background(), *input, *output)
+	if err != nil {
+		log.Fatalf("Failed to repair WAL: %v", err)
+	}
+	summary.Print(*output)
+}
+
+// repair 以寬鬆模式讀取 input，將可解析的紀錄依原本的順序號原樣寫入 output
+func repair(ctx context.Context, input, output string) (*repairSummary, error) {
+	reader, err := wal.OpenReader(input)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	out, err := wal.NewWALFromFile(output, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &repairSummary{}
+	err = reader.ReadAllLenient(ctx, func(jsonRaw []byte) error {
+		var fields map[string]json.RawMessage
+		// 合法 JSON 但不是物件時沒有順序號，一樣原樣保留
+		if err := json.Unmarshal(jsonRaw, &fields); err == nil {
+			if sequence, ok := sequenceField(fields); ok {
+				summary.observe(sequence)
+			}
+		}
+		summary.recovered++
+		return out.Write(ctx, json.RawMessage(jsonRaw))
+	}, summary.skip)
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// sequenceField 取得紀錄中的順序號
+func sequenceField(fields map[string]json.RawMessage) (uint64, bool) {
+	for _, key := range sequenceKeys {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		var sequence uint64
+		if err := json.Unmarshal(raw, &sequence); err != nil {
+			return 0, false
+		}
+		return sequence, true
+	}
+	return 0, false
+}
+
+// skip 記錄一筆略過的紀錄
+func (s *repairSummary) skip(entry wal.SkippedEntry) {
+	s.skipped = append(s.skipped, entry)
+	s.skippedBytes += entry.Length
+}
+
+// observe 記錄一筆可解析紀錄的順序號，並記錄與上一筆之間的缺號範圍
+func (s *repairSummary) observe(sequence uint64) {
+	if !s.hasSequence {
+		s.firstSequence = sequence
+		s.hasSequence = true
+	} else if sequence > s.lastSequence+1 {
+		s.gaps = append(s.gaps, sequenceGap{from: s.lastSequence + 1, to: sequence - 1})
+	}
+	s.lastSequence = sequence
+}
+
+// Print 印出修復摘要與每筆略過紀錄的位置
+func (s *repairSummary) Print(output string) {
+	fmt.Printf("Recovered %d entries to %s\n", s.recovered, output)
+	if s.hasSequence {
+		fmt.Printf("  first good sequence  %d\n", s.firstSequence)
+		fmt.Printf("  last good sequence   %d\n", s.lastSequence)
+	} else {
+		fmt.Println("  no entries with a sequence number")
+	}
+	fmt.Printf("  gaps                 %d\n", len(s.gaps))
+	for _, gap := range s.gaps {
+		fmt.Printf("    missing sequence %d - %d\n", gap.from, gap.to)
+	}
+	fmt.Printf("  skipped entries      %d\n", len(s.skipped))
+	fmt.Printf("  skipped bytes        %d\n", s.skippedBytes)
+	for _, entry := range s.skipped {
+		fmt.Printf("    offset %-12d length %d\n", entry.Offset, entry.Length)
+	}
+}
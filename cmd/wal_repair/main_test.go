@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+func TestRepairKeepsOriginalSequences(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "corrupted.wal")
+	output := filepath.Join(dir, "repaired.wal")
+	content := `{"seq":1}` + "\n" + `{"seq":2}` + "\n" + `{"seq":3,` + "\n" + `{"seq":4}` + "\n" + `{"seq":5}` + "\n"
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := repair(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if summary.recovered != 4 || len(summary.skipped) != 1 {
+		t.Fatalf("recovered %d, skipped %d; want 4, 1", summary.recovered, len(summary.skipped))
+	}
+	if summary.firstSequence != 1 || summary.lastSequence != 5 {
+		t.Fatalf("first %d, last %d; want 1, 5", summary.firstSequence, summary.lastSequence)
+	}
+	if want := []sequenceGap{{from: 3, to: 3}}; !reflect.DeepEqual(summary.gaps, want) {
+		t.Fatalf("gaps = %v, want %v", summary.gaps, want)
+	}
+
+	// 修復後的檔案保留原本的順序號 (不重新編號)
+	repaired, err := wal.NewWALFromFile(output, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repaired.Close()
+	var seqs []uint64
+	err = repaired.ReadAll(context.Background(), func(jsonRaw []byte) error {
+		var entry struct {
+			Seq uint64 `json:"seq"`
+		}
+		if err := json.Unmarshal(jsonRaw, &entry); err != nil {
+			return err
+		}
+		seqs = append(seqs, entry.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if want := []uint64{1, 2, 4, 5}; !reflect.DeepEqual(seqs, want) {
+		t.Fatalf("sequences = %v, want %v", seqs, want)
+	}
+}
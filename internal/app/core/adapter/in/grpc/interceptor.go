@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// panicsTotal RecoveryInterceptor 攔截到的 Panic 次數 (見 PanicsTotal)
+var panicsTotal atomic.Uint64
+
+// RecoveryInterceptor 攔截 Handler 與之後的 Interceptor 發生的 Panic
+// 以 slog.Error 記錄 Panic 內容與 Stack Trace，累加 PanicsTotal，並回傳 codes.Internal 給 Client (不暴露內部訊息)
+// 應註冊為最外層的 Interceptor，才能涵蓋其他 Interceptor
+func RecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				panicsTotal.Add(1)
+				slog.Error("grpc handler panic",
+					slog.String("method", info.FullMethod),
+					slog.Any("panic", r),
+					slog.String("stack", string(debug.Stack())),
+				)
+				resp = nil
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// PanicsTotal 回傳啟動以來 RecoveryInterceptor 攔截到的 Panic 次數 (供監控使用)
+func PanicsTotal() uint64 {
+	return panicsTotal.Load()
+}
+
+// WithMaxRPCDuration 限制每個 RPC 在伺服器內部的最長執行時間
+// 以 min(d, Client 剩餘的 Deadline) 作為新的 Context Timeout，
+// 避免 Client 給了過長的 Deadline (例如壓測的 120 秒) 時，伺服器內部操作卡住太久
+func WithMaxRPCDuration(d time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		timeout := d
+		if deadline, ok := ctx.Deadline(); ok {
+			if remaining := time.Until(deadline); remaining < timeout {
+				timeout = remaining
+			}
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
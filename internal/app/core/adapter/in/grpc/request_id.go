@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader 傳遞 Request ID 的 Metadata Key (gRPC Metadata 的 Key 一律為小寫)
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength Client 帶入的 Request ID 長度上限，超過則改為自行產生 (避免超長字串寫入 Log)
+const maxRequestIDLength = 128
+
+// requestIDKey Context 中存放 Request ID 的 Key
+type requestIDKey struct{}
+
+// RequestIDInterceptor 為每個請求分配 Request ID 供 Log 關聯
+// 優先使用 Client 在 Metadata 帶入的 X-Request-ID，沒有 (或過長) 時產生 UUID；
+// Request ID 會存入 Context (見 RequestIDFromContext) 並寫入回應的 Header
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := incomingRequestID(ctx)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		// 寫入 Header 失敗 (如 Stream 已送出 Header) 不影響請求處理
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+	}
+}
+
+// RequestIDFromContext 取得 RequestIDInterceptor 分配的 Request ID (沒有時回傳空字串)
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// incomingRequestID 讀取 Client 帶入的 Request ID
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 || len(values[0]) > maxRequestIDLength {
+		return ""
+	}
+	return values[0]
+}
+
+// loggerFromContext 回傳帶有 request_id 欄位的 Logger (Handler 內的 Log 一律使用)
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	return slog.With(slog.String("request_id", RequestIDFromContext(ctx)))
+}
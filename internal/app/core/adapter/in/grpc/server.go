@@ -2,7 +2,8 @@ package grpc
 
 import (
 	"context"
-	"time"
+	"errors"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -13,89 +14,161 @@ import (
 	pb "github.com/JoeShih716/go-mem-ledger/proto"
 )
 
+// balanceNotFetched TransferResponse.current_balance 的 Sentinel：未要求 include_balance 或查詢失敗
+const balanceNotFetched int64 = -1
+
 type GrpcServer struct {
 	pb.UnimplementedLedgerServiceServer
-	core *usecase.CoreUseCase
+	core    *usecase.CoreUseCase
+	factory *TransactionFactory
+	// 是否允許 CreateAccount 帶大於 0 的初始餘額 (見 WithAllowInitialBalance)
+	allowInitialBalance bool
 }
 
-func NewGrpcServer(core *usecase.CoreUseCase) *GrpcServer {
-	return &GrpcServer{
-		core: core,
+// GrpcServerOption 定義了 GrpcServer 的配置選項函數
+type GrpcServerOption func(*GrpcServer)
+
+// WithAllowInitialBalance 允許 CreateAccount 帶大於 0 的初始餘額 (預設不允許)
+// 初始餘額不經過任何轉出帳戶，等同讓呼叫端憑空增加資金，只應在本機開發或壓測環境開啟
+func WithAllowInitialBalance(allow bool) GrpcServerOption {
+	return func(s *GrpcServer) {
+		s.allowInitialBalance = allow
 	}
 }
 
-func (s *GrpcServer) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
-	// 1. UUID 解析
-	uuid, err := uuid.Parse(req.RefId)
-	if err != nil {
-		return &pb.TransferResponse{
-			Success: false,
-			Message: "invalid ref_id: " + err.Error(),
-		}, nil
+// NewGrpcServer 建立 GrpcServer
+//
+// 參數:
+//
+//	core: 核心業務邏輯
+//	factory: 請求轉換為交易的工廠 (nil 則使用 NewTransactionFactory)
+//	opts: 可選的配置選項
+//
+// 回傳:
+//
+//	*GrpcServer: GrpcServer 實例
+func NewGrpcServer(core *usecase.CoreUseCase, factory *TransactionFactory, opts ...GrpcServerOption) *GrpcServer {
+	if factory == nil {
+		factory = NewTransactionFactory()
 	}
-	// 2. 轉換交易類型
-	var txType domain.TransactionType
-	switch req.Type {
-	case pb.TransactionType_DEPOSIT:
-		txType = domain.TransactionTypeDeposit
-	case pb.TransactionType_WITHDRAW:
-		txType = domain.TransactionTypeWithdraw
-	case pb.TransactionType_TRANSFER:
-		txType = domain.TransactionTypeTransfer
-	default:
-		return &pb.TransferResponse{
-			Success: false,
-			Message: "invalid transaction type",
-		}, nil
+	s := &GrpcServer{
+		core:    core,
+		factory: factory,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
 
-	// 3. 組裝 Domain Transaction
-	// domain.TransactionID 是 [16]byte, uuid.UUID 是 [16]byte
-	tx := &domain.Transaction{
-		TransactionID: uuid,
-		From:          req.FromAccountId,
-		To:            req.ToAccountId,
-		Amount:        req.Amount,
-		Type:          txType,
-		CreatedAt:     time.Now().UnixMilli(),
+func (s *GrpcServer) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	// 1. 欄位檢查並組裝 Domain Transaction (InvalidArgument + FieldViolation)
+	tx, err := s.factory.FromTransferRequest(req)
+	if err != nil {
+		return nil, err
 	}
+	txType := tx.Type
 
-	// 4. 執行交易
+	// 2. 執行交易 (Ledger 回傳後就不再持有 tx，可以放回 Pool)
 	err = s.core.PostTransaction(ctx, tx)
+	// 放回 Pool 前取出 Ledger 分配的順序號
+	sequence := tx.Sequence
+	s.factory.Release(tx)
+	requestID := RequestIDFromContext(ctx)
 	if err != nil {
 		// 業務邏輯錯誤，回傳 Success=false (Soft Failure)
+		loggerFromContext(ctx).Debug("transfer rejected", slog.Any("error", err))
 		return &pb.TransferResponse{
-			Success: false,
-			Message: err.Error(),
+			Success:   false,
+			Message:   err.Error(),
+			RequestId: requestID,
 		}, nil
 	}
 
-	// 5. [Optional] 取得最新餘額 (Best Effort)
+	// 3. [Optional] 取得最新餘額 (Best Effort，Client 要求 include_balance 時才查詢，避免每筆交易多一次讀取)
 	// 根據 Proto 定義，轉帳/提款回傳 From 的餘額，存款回傳 To 的餘額
-	var targetAccountID int64
-	if txType == domain.TransactionTypeDeposit {
-		targetAccountID = req.ToAccountId
-	} else {
-		targetAccountID = req.FromAccountId
+	balance := balanceNotFetched
+	if req.GetIncludeBalance() {
+		targetAccountID := req.FromAccountId
+		if txType == domain.TransactionTypeDeposit {
+			targetAccountID = req.ToAccountId
+		}
+		if current, err := s.core.GetAccountBalance(ctx, targetAccountID); err == nil {
+			balance = current
+		}
 	}
 
-	balance, _ := s.core.GetAccountBalance(ctx, targetAccountID)
-
 	return &pb.TransferResponse{
 		Success:        true,
 		CurrentBalance: balance,
+		Sequence:       sequence,
+		RequestId:      requestID,
 	}, nil
 }
 
 func (s *GrpcServer) GetBalance(ctx context.Context, req *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
-	balance, err := s.core.GetAccountBalance(ctx, req.AccountId)
+	account, err := s.core.GetAccount(ctx, req.AccountId)
 	if err != nil {
 		if err == domain.ErrAccountNotFound {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		loggerFromContext(ctx).Error("get balance failed",
+			slog.Int64("account_id", req.AccountId),
+			slog.Any("error", err),
+		)
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	return &pb.GetBalanceResponse{
-		Balance: balance,
-	}, nil
+	resp := &pb.GetBalanceResponse{
+		Balance:             account.Balance,
+		AvailableBalance:    account.AvailableBalance(),
+		Currency:            account.CurrencyCode,
+		LastTransactionAt:   account.LastTransactionAt,
+		LastTransactionType: toProtoTransactionType(account.LastTransactionType),
+		RequestId:           RequestIDFromContext(ctx),
+	}
+	if account.LastTransactionID != uuid.Nil {
+		resp.LastTransactionRefId = account.LastTransactionID.String()
+	}
+	return resp, nil
+}
+
+// CreateAccount 建立帳戶並設定初始餘額
+// 帳戶已存在回傳 codes.AlreadyExists (同一個 ref_id 重送視為成功)，欄位錯誤回傳 codes.InvalidArgument；
+// 未以 WithAllowInitialBalance 開啟時，初始餘額大於 0 回傳 codes.PermissionDenied (請建立後以存款入帳)
+func (s *GrpcServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	refID, err := validateCreateAccountRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetInitialBalance() > 0 && !s.allowInitialBalance {
+		return nil, status.Error(codes.PermissionDenied, "initial_balance must be 0 unless the server allows initial balances")
+	}
+	account := domain.NewAccount(req.GetAccountId(), 0)
+	account.CurrencyCode = req.GetCurrency()
+	err = s.core.CreateAccountWithInitialBalance(ctx, account, req.GetInitialBalance(), refID)
+	switch {
+	case err == nil, errors.Is(err, domain.ErrTransactionAlreadyProcessed):
+		return &pb.CreateAccountResponse{RequestId: RequestIDFromContext(ctx)}, nil
+	case errors.Is(err, domain.ErrAccountAlreadyExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	loggerFromContext(ctx).Error("create account failed",
+		slog.Int64("account_id", req.GetAccountId()),
+		slog.Any("error", err),
+	)
+	return nil, status.Error(codes.Internal, err.Error())
+}
+
+// toProtoTransactionType 轉換為 Proto 的交易類型 (Proto 沒有定義的類型回傳 UNKNOWN)
+func toProtoTransactionType(t domain.TransactionType) pb.TransactionType {
+	switch t {
+	case domain.TransactionTypeDeposit:
+		return pb.TransactionType_DEPOSIT
+	case domain.TransactionTypeWithdraw:
+		return pb.TransactionType_WITHDRAW
+	case domain.TransactionTypeTransfer:
+		return pb.TransactionType_TRANSFER
+	default:
+		return pb.TransactionType_UNKNOWN
+	}
 }
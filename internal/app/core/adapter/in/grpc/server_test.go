@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/memory"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// emptyLoader 不載入任何帳戶
+type emptyLoader struct{}
+
+func (emptyLoader) LoadAccountsBatch(ctx context.Context, offset, limit int64) ([]*domain.Account, error) {
+	return nil, nil
+}
+
+// newTestServer 建立以 MutexLedger (MemWriter 為 WAL) 為後端的 GrpcServer
+func newTestServer(t testing.TB, opts ...GrpcServerOption) *GrpcServer {
+	t.Helper()
+	ledger, err := memory.NewMutexLedger(context.Background(), emptyLoader{}, nil, wal.NewMemWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGrpcServer(usecase.NewCoreUseCase(ledger), nil, opts...)
+}
+
+func TestCreateAccountInitialBalance(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []GrpcServerOption
+		initialBalance int64
+		wantCode       codes.Code
+		wantBalance    int64
+	}{
+		{name: "zero balance by default", initialBalance: 0, wantCode: codes.OK},
+		{name: "positive balance denied by default", initialBalance: 100, wantCode: codes.PermissionDenied},
+		{name: "positive balance when allowed", opts: []GrpcServerOption{WithAllowInitialBalance(true)}, initialBalance: 100, wantCode: codes.OK, wantBalance: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestServer(t, tt.opts...)
+			_, err := s.CreateAccount(ctx, &pb.CreateAccountRequest{
+				AccountId:      1,
+				InitialBalance: tt.initialBalance,
+				RefId:          uuid.NewString(),
+			})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.wantCode, err)
+			}
+			if tt.wantCode != codes.OK {
+				return
+			}
+			balance, err := s.core.GetAccountBalance(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance {
+				t.Fatalf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+		})
+	}
+}
+
+// BenchmarkGrpcServerTransfer 量測 Transfer 每次請求的配置次數
+// without_pool 每次請求後取走放回 Pool 的交易 (等同沒有 sync.Pool 時每次配置新的 domain.Transaction)，作為比較基準
+func BenchmarkGrpcServerTransfer(b *testing.B) {
+	b.Run("with_pool", func(b *testing.B) { benchmarkTransfer(b, false) })
+	b.Run("without_pool", func(b *testing.B) { benchmarkTransfer(b, true) })
+}
+
+func benchmarkTransfer(b *testing.B, drainPool bool) {
+	ctx := context.Background()
+	s := newTestServer(b, WithAllowInitialBalance(true))
+	for id := int64(1); id <= 2; id++ {
+		_, err := s.CreateAccount(ctx, &pb.CreateAccountRequest{AccountId: id, InitialBalance: 1 << 40, RefId: uuid.NewString()})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	// 先產生請求，不把 RefId 的配置算進 Transfer
+	reqs := make([]*pb.TransferRequest, b.N)
+	for i := range reqs {
+		reqs[i] = &pb.TransferRequest{
+			RefId:         uuid.NewString(),
+			Type:          pb.TransactionType_TRANSFER,
+			FromAccountId: 1 + int64(i%2),
+			ToAccountId:   2 - int64(i%2),
+			Amount:        100,
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := s.Transfer(ctx, reqs[i])
+		if err != nil || !resp.Success {
+			b.Fatalf("Transfer: %v %v", resp, err)
+		}
+		if drainPool {
+			s.factory.pool.Get()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// TransactionFactory 將 gRPC 請求轉換為 domain.Transaction
+// 負責欄位檢查、UUID 解析、交易類型對應與交易時間，新的 RPC 不需要重複這段邏輯
+type TransactionFactory struct {
+	// Pool 重複使用 domain.Transaction，減少每筆請求的 GC 壓力
+	pool sync.Pool
+	// now 取得交易時間 (預設 time.Now)
+	now func() time.Time
+}
+
+// NewTransactionFactory 建立 TransactionFactory
+//
+// 回傳:
+//
+//	*TransactionFactory: TransactionFactory 實例
+func NewTransactionFactory() *TransactionFactory {
+	return &TransactionFactory{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return &domain.Transaction{}
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// FromTransferRequest 由 TransferRequest 建立交易 (從 Pool 取得，使用完畢需呼叫 Release)
+//
+// 參數:
+//
+//	req: 交易請求
+//
+// 回傳:
+//
+//	*domain.Transaction: 交易
+//	error: 欄位錯誤 (codes.InvalidArgument + BadRequest.FieldViolation)
+func (f *TransactionFactory) FromTransferRequest(req *pb.TransferRequest) (*domain.Transaction, error) {
+	// 1. 欄位檢查
+	refID, err := validateTransferRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	// 2. 轉換交易類型 (已通過檢查，只會是已知類型)
+	var txType domain.TransactionType
+	switch req.Type {
+	case pb.TransactionType_DEPOSIT:
+		txType = domain.TransactionTypeDeposit
+	case pb.TransactionType_WITHDRAW:
+		txType = domain.TransactionTypeWithdraw
+	case pb.TransactionType_TRANSFER:
+		txType = domain.TransactionTypeTransfer
+	}
+
+	// 3. 組裝 Domain Transaction
+	// domain.TransactionID 是 [16]byte, uuid.UUID 是 [16]byte
+	tx := f.pool.Get().(*domain.Transaction)
+	*tx = domain.Transaction{
+		TransactionID: refID,
+		From:          req.FromAccountId,
+		To:            req.ToAccountId,
+		Amount:        req.Amount,
+		Type:          txType,
+		CreatedAt:     f.now().UnixMilli(),
+	}
+	return tx, nil
+}
+
+// Release 清空交易並放回 Pool (呼叫後不可再使用 tx)
+func (f *TransactionFactory) Release(tx *domain.Transaction) {
+	*tx = domain.Transaction{}
+	f.pool.Put(tx)
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+func TestTransactionFactoryFromTransferRequest(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	refID := uuid.New()
+
+	tests := []struct {
+		name       string
+		req        *pb.TransferRequest
+		want       domain.Transaction
+		wantFields []string
+	}{
+		{
+			name: "deposit",
+			req:  &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_DEPOSIT, ToAccountId: 2, Amount: 100},
+			want: domain.Transaction{TransactionID: refID, Type: domain.TransactionTypeDeposit, To: 2, Amount: 100, CreatedAt: now.UnixMilli()},
+		},
+		{
+			name: "withdraw",
+			req:  &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_WITHDRAW, FromAccountId: 1, Amount: 100},
+			want: domain.Transaction{TransactionID: refID, Type: domain.TransactionTypeWithdraw, From: 1, Amount: 100, CreatedAt: now.UnixMilli()},
+		},
+		{
+			name: "transfer",
+			req:  &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_TRANSFER, FromAccountId: 1, ToAccountId: 2, Amount: 100},
+			want: domain.Transaction{TransactionID: refID, Type: domain.TransactionTypeTransfer, From: 1, To: 2, Amount: 100, CreatedAt: now.UnixMilli()},
+		},
+		{
+			name:       "missing ref_id",
+			req:        &pb.TransferRequest{Type: pb.TransactionType_DEPOSIT, ToAccountId: 2, Amount: 100},
+			wantFields: []string{"ref_id"},
+		},
+		{
+			name:       "malformed ref_id",
+			req:        &pb.TransferRequest{RefId: "not-a-uuid", Type: pb.TransactionType_DEPOSIT, ToAccountId: 2, Amount: 100},
+			wantFields: []string{"ref_id"},
+		},
+		{
+			name:       "nil ref_id",
+			req:        &pb.TransferRequest{RefId: uuid.Nil.String(), Type: pb.TransactionType_DEPOSIT, ToAccountId: 2, Amount: 100},
+			wantFields: []string{"ref_id"},
+		},
+		{
+			name:       "zero amount",
+			req:        &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_DEPOSIT, ToAccountId: 2},
+			wantFields: []string{"amount"},
+		},
+		{
+			name:       "unknown type",
+			req:        &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_UNKNOWN, ToAccountId: 2, Amount: 100},
+			wantFields: []string{"type"},
+		},
+		{
+			name:       "deposit to system account",
+			req:        &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_DEPOSIT, Amount: 100},
+			wantFields: []string{"to_account_id"},
+		},
+		{
+			name:       "withdraw from system account",
+			req:        &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_WITHDRAW, Amount: 100},
+			wantFields: []string{"from_account_id"},
+		},
+		{
+			name:       "transfer to same account",
+			req:        &pb.TransferRequest{RefId: refID.String(), Type: pb.TransactionType_TRANSFER, FromAccountId: 1, ToAccountId: 1, Amount: 100},
+			wantFields: []string{"to_account_id"},
+		},
+		{
+			name:       "multiple violations",
+			req:        &pb.TransferRequest{Type: pb.TransactionType_TRANSFER, FromAccountId: -1, ToAccountId: 2, Amount: -1},
+			wantFields: []string{"ref_id", "amount", "from_account_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewTransactionFactory()
+			f.now = func() time.Time { return now }
+
+			tx, err := f.FromTransferRequest(tt.req)
+			if tt.wantFields != nil {
+				if tx != nil {
+					t.Fatalf("got transaction %+v, want nil", tx)
+				}
+				if got := violatedFields(t, err); !reflect.DeepEqual(got, tt.wantFields) {
+					t.Fatalf("violated fields = %v, want %v", got, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromTransferRequest: %v", err)
+			}
+			if *tx != tt.want {
+				t.Fatalf("transaction = %+v, want %+v", *tx, tt.want)
+			}
+			f.Release(tx)
+		})
+	}
+}
+
+// violatedFields 取出 codes.InvalidArgument 錯誤中 BadRequest.FieldViolation 的欄位名稱
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		t.Fatalf("error = %v, want codes.InvalidArgument", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/JoeShih716/go-mem-ledger/proto"
+)
+
+// validateTransferRequest 檢查 TransferRequest 的欄位
+// 回傳 codes.InvalidArgument 並附上 BadRequest.FieldViolation，Client 可直接判斷是哪個欄位錯誤
+//
+// 參數:
+//
+//	req: 交易請求
+//
+// 回傳:
+//
+//	uuid.UUID: 解析後的 ref_id
+//	error: 欄位錯誤 (gRPC status)，沒有錯誤時為 nil
+func validateTransferRequest(req *pb.TransferRequest) (uuid.UUID, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	addViolation := func(field, description string) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	}
+
+	refID, description := parseRefID(req.GetRefId())
+	if description != "" {
+		addViolation("ref_id", description)
+	}
+
+	if req.GetAmount() <= 0 {
+		addViolation("amount", "amount must be greater than zero")
+	}
+
+	needFrom, needTo := false, false
+	switch req.GetType() {
+	case pb.TransactionType_DEPOSIT:
+		needTo = true
+	case pb.TransactionType_WITHDRAW:
+		needFrom = true
+	case pb.TransactionType_TRANSFER:
+		needFrom, needTo = true, true
+	default:
+		addViolation("type", "type must be DEPOSIT, WITHDRAW or TRANSFER")
+	}
+	if needFrom && req.GetFromAccountId() <= 0 {
+		addViolation("from_account_id", "from_account_id must be positive (0 is the system account)")
+	}
+	if needTo && req.GetToAccountId() <= 0 {
+		addViolation("to_account_id", "to_account_id must be positive (0 is the system account)")
+	}
+	if needFrom && needTo && req.GetFromAccountId() == req.GetToAccountId() {
+		addViolation("to_account_id", "to_account_id must differ from from_account_id")
+	}
+
+	if len(violations) == 0 {
+		return refID, nil
+	}
+	return uuid.Nil, invalidArgument("invalid transfer request", violations)
+}
+
+// validateCreateAccountRequest 檢查 CreateAccountRequest 的欄位 (錯誤格式同 validateTransferRequest)
+//
+// 參數:
+//
+//	req: 建立帳戶請求
+//
+// 回傳:
+//
+//	uuid.UUID: 解析後的 ref_id
+//	error: 欄位錯誤 (gRPC status)，沒有錯誤時為 nil
+func validateCreateAccountRequest(req *pb.CreateAccountRequest) (uuid.UUID, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	addViolation := func(field, description string) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	}
+
+	refID, description := parseRefID(req.GetRefId())
+	if description != "" {
+		addViolation("ref_id", description)
+	}
+	if req.GetAccountId() <= 0 {
+		addViolation("account_id", "account_id must be positive (0 is the system account)")
+	}
+	if req.GetInitialBalance() < 0 {
+		addViolation("initial_balance", "initial_balance must not be negative")
+	}
+
+	if len(violations) == 0 {
+		return refID, nil
+	}
+	return uuid.Nil, invalidArgument("invalid create account request", violations)
+}
+
+// parseRefID 解析 ref_id，不合法時回傳 FieldViolation 的描述 (合法時為空字串)
+func parseRefID(raw string) (uuid.UUID, string) {
+	refID, err := uuid.Parse(raw)
+	switch {
+	case raw == "":
+		return uuid.Nil, "ref_id is required"
+	case err != nil:
+		return uuid.Nil, "ref_id must be a UUID: " + err.Error()
+	case refID == uuid.Nil:
+		return uuid.Nil, "ref_id must not be the nil UUID"
+	}
+	return refID, ""
+}
+
+// invalidArgument 建立附帶 BadRequest.FieldViolation 的 codes.InvalidArgument 錯誤
+func invalidArgument(message string, violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, message)
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,118 @@
+package healthz
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// readyTimeout Readiness 檢查時 Ping MySQL 的超時時間
+const readyTimeout = time.Second
+
+// DBChecker 可回報連線狀態的資料庫客戶端 (*mysql.Client)
+type DBChecker interface {
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// readyResponse /readyz 回傳的 JSON
+type readyResponse struct {
+	Status          string  `json:"status"`
+	Error           string  `json:"error,omitempty"`
+	MySQLPingMs     float64 `json:"mysql_ping_ms"`
+	OpenConnections int     `json:"open_connections"`
+	IdleConnections int     `json:"idle_connections"`
+}
+
+// Server 提供 Liveness (/healthz) 與 Readiness (/readyz) 探針的 HTTP Server (可另外掛載 /metrics 等端點，見 WithHandler)
+type Server struct {
+	db     DBChecker
+	server *http.Server
+}
+
+// ServerOption 定義了 Server 的配置選項函數
+type ServerOption func(mux *http.ServeMux)
+
+// WithHandler 在同一個 HTTP Server 上掛載其他端點 (如 Prometheus 的 /metrics)
+func WithHandler(pattern string, handler http.Handler) ServerOption {
+	return func(mux *http.ServeMux) {
+		mux.Handle(pattern, handler)
+	}
+}
+
+// NewServer 建立健康檢查 Server
+//
+// 參數:
+//
+//	addr: 監聽地址 (e.g., ":8080")
+//	db: 資料庫客戶端
+//	opts: 可選的配置選項
+//
+// 回傳:
+//
+//	*Server: 健康檢查 Server
+func NewServer(addr string, db DBChecker, opts ...ServerOption) *Server {
+	s := &Server{db: db}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	for _, opt := range opts {
+		opt(mux)
+	}
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// ListenAndServe 啟動 HTTP Server (阻塞直到 Shutdown)
+func (s *Server) ListenAndServe() error {
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 關閉 HTTP Server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// handleHealthz Liveness: 程序存活即回傳 200
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleReadyz Readiness: Ping MySQL 並回傳連線池狀態，失敗時回傳 503
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.db.Ping(ctx)
+	elapsed := time.Since(start)
+	stats := s.db.Stats()
+
+	resp := readyResponse{
+		Status:          "ok",
+		MySQLPingMs:     float64(elapsed.Microseconds()) / 1000,
+		OpenConnections: stats.OpenConnections,
+		IdleConnections: stats.Idle,
+	}
+	code := http.StatusOK
+	if err != nil {
+		resp.Status = "unavailable"
+		resp.Error = err.Error()
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+)
+
+// ttlEntry 單一帳戶的快取狀態 (帳戶第一次被查詢時建立，之後不移除)
+//
+// 結構:
+//
+//	mu: 保護以下欄位 (比較版本與寫入需為原子操作)
+//	balance / cached / expiresAt: 快取的餘額，cached 為 false 或已過期時視為未命中
+//	sequence: 已寫入的最新交易順序號 (Evict 後仍保留，較舊的交易不能再覆蓋)
+//	generation: 每次 Set / Evict 遞增，Fill 以此判斷查詢期間是否有交易完成
+type ttlEntry struct {
+	mu         sync.Mutex
+	balance    int64
+	cached     bool
+	expiresAt  time.Time
+	sequence   uint64
+	generation uint64
+}
+
+// TTLCache 程序內的帳戶餘額快取，每個帳戶各自過期 (usecase.BalanceCache 實作)
+// 以 sync.Map 保存 (帳戶各自讀寫，Key 集合穩定)，每個帳戶的新舊判斷由各自的 ttlEntry 加鎖處理
+type TTLCache struct {
+	entries sync.Map // map[int64]*ttlEntry
+}
+
+// NewTTLCache 建立 TTLCache
+func NewTTLCache() *TTLCache {
+	return &TTLCache{}
+}
+
+// entry 取得帳戶的 ttlEntry，不存在時建立
+func (c *TTLCache) entry(accountID int64) *ttlEntry {
+	if value, ok := c.entries.Load(accountID); ok {
+		return value.(*ttlEntry)
+	}
+	value, _ := c.entries.LoadOrStore(accountID, &ttlEntry{})
+	return value.(*ttlEntry)
+}
+
+// Get 取得未過期的餘額
+//
+// 參數:
+//
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	int64: 餘額
+//	uint64: 未命中時供 Fill 使用的版本
+//	bool: 是否命中 (沒有快取或已過期為 false)
+func (c *TTLCache) Get(accountID int64) (int64, uint64, bool) {
+	e := c.entry(accountID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cached && time.Now().Before(e.expiresAt) {
+		return e.balance, e.generation, true
+	}
+	e.cached = false
+	return 0, e.generation, false
+}
+
+// Fill 寫入未命中時向 Ledger 查詢到的餘額
+// Get 之後有 Set 或 Evict 時放棄寫入 (查詢到的可能是交易完成前的舊餘額)
+//
+// 參數:
+//
+//	accountID: 帳戶 ID
+//	balance: 查詢到的餘額
+//	generation: 查詢前 Get 回傳的版本
+//	ttl: 存活時間 (<= 0 時不寫入)
+func (c *TTLCache) Fill(accountID int64, balance int64, generation uint64, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	e := c.entry(accountID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.generation != generation {
+		return
+	}
+	e.store(balance, ttl)
+}
+
+// Set 寫入交易完成後的餘額
+// 只有比已寫入的交易更新 (sequence 較大) 時才寫入，並行交易較晚到達的舊餘額不會覆蓋新的餘額；
+// sequence 為 0 (Ledger 未分配順序號) 時無法判斷新舊，改為移除快取
+//
+// 參數:
+//
+//	accountID: 帳戶 ID
+//	balance: 交易完成後的餘額
+//	sequence: 交易的順序號
+//	ttl: 存活時間 (<= 0 時不寫入)
+func (c *TTLCache) Set(accountID int64, balance int64, sequence uint64, ttl time.Duration) {
+	if sequence == 0 || ttl <= 0 {
+		c.Evict(accountID)
+		return
+	}
+	e := c.entry(accountID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if sequence <= e.sequence {
+		return
+	}
+	e.sequence = sequence
+	e.generation++
+	e.store(balance, ttl)
+}
+
+// Evict 移除帳戶的快取，進行中的 Fill 也會被放棄
+//
+// 參數:
+//
+//	accountID: 帳戶 ID
+func (c *TTLCache) Evict(accountID int64) {
+	e := c.entry(accountID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.generation++
+	e.cached = false
+}
+
+// store 寫入餘額 (呼叫端需持有 e.mu)
+func (e *ttlEntry) store(balance int64, ttl time.Duration) {
+	e.balance = balance
+	e.cached = true
+	e.expiresAt = time.Now().Add(ttl)
+}
+
+var _ usecase.BalanceCache = (*TTLCache)(nil)
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheGetSet(t *testing.T) {
+	c := NewTTLCache()
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("Get on empty cache hit")
+	}
+
+	c.Set(1, 100, 1, time.Minute)
+	if got, _, ok := c.Get(1); !ok || got != 100 {
+		t.Fatalf("Get = %d, %v, want 100, true", got, ok)
+	}
+	if _, _, ok := c.Get(2); ok {
+		t.Fatal("Get on another account hit")
+	}
+
+	c.Evict(1)
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("Get after Evict hit")
+	}
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	c := NewTTLCache()
+	c.Set(1, 100, 1, 20*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("Get after ttl hit")
+	}
+
+	// ttl <= 0 不寫入
+	c.Set(2, 100, 1, 0)
+	if _, _, ok := c.Get(2); ok {
+		t.Fatal("Get after Set with ttl 0 hit")
+	}
+}
+
+// 並行交易較晚到達的舊餘額不能覆蓋新的餘額
+func TestTTLCacheSetIgnoresOlderSequence(t *testing.T) {
+	c := NewTTLCache()
+	c.Set(1, 50, 2, time.Minute)
+	c.Set(1, 100, 1, time.Minute)
+	if got, _, _ := c.Get(1); got != 50 {
+		t.Fatalf("Get = %d, want 50 (sequence 2)", got)
+	}
+
+	// Evict 後仍記得已寫入的順序號
+	c.Evict(1)
+	c.Set(1, 100, 1, time.Minute)
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("older sequence written after Evict")
+	}
+	c.Set(1, 30, 3, time.Minute)
+	if got, _, _ := c.Get(1); got != 30 {
+		t.Fatalf("Get = %d, want 30 (sequence 3)", got)
+	}
+
+	// 沒有順序號時無法判斷新舊，移除快取
+	c.Set(1, 10, 0, time.Minute)
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("Set with sequence 0 kept the cache")
+	}
+}
+
+// 查詢期間有交易完成時，查詢到的舊餘額不能寫入
+func TestTTLCacheFillDiscardedAfterSet(t *testing.T) {
+	c := NewTTLCache()
+
+	_, generation, _ := c.Get(1)
+	c.Fill(1, 100, generation, time.Minute)
+	if got, _, ok := c.Get(1); !ok || got != 100 {
+		t.Fatalf("Get = %d, %v, want 100, true", got, ok)
+	}
+
+	c.Evict(1)
+	_, generation, _ = c.Get(1)
+	c.Set(1, 50, 1, time.Minute)
+	c.Fill(1, 100, generation, time.Minute)
+	if got, _, _ := c.Get(1); got != 50 {
+		t.Fatalf("Get = %d, want 50 (Fill after Set must be discarded)", got)
+	}
+
+	c.Evict(1)
+	_, generation, _ = c.Get(1)
+	c.Evict(1)
+	c.Fill(1, 100, generation, time.Minute)
+	if _, _, ok := c.Get(1); ok {
+		t.Fatal("Fill after Evict was written")
+	}
+}
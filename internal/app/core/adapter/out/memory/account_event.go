@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// recoverAccountWAL 從帳戶 WAL 重建帳戶 Map (需在重放交易 WAL 之前執行)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accounts: 初始帳戶資料 Map (會被直接修改)
+//	accountWAL: 帳戶事件 WAL
+//
+// 回傳:
+//
+//	error: 讀取或解析錯誤
+func recoverAccountWAL(ctx context.Context, accounts map[int64]*domain.Account, accountWAL wal.Writer) error {
+	if accountWAL == nil {
+		return nil
+	}
+	return accountWAL.ReadAll(ctx, func(jsonRaw []byte) error {
+		var event domain.AccountEvent
+		if err := json.Unmarshal(jsonRaw, &event); err != nil {
+			return err
+		}
+		// 重放時以 WAL 為準，忽略與初始資料的衝突 (如帳戶已存在)
+		_ = applyAccountEvent(accounts, &event)
+		return nil
+	})
+}
+
+// applyAccountEvent 將帳戶事件套用至帳戶 Map
+//
+// 參數:
+//
+//	accounts: 帳戶資料 Map
+//	event: 帳戶事件
+//
+// 回傳:
+//
+//	error: 帳戶已存在 / 不存在
+func applyAccountEvent(accounts map[int64]*domain.Account, event *domain.AccountEvent) error {
+	switch event.Type {
+	case domain.AccountEventTypeCreated:
+		if _, ok := accounts[event.AccountID]; ok {
+			return domain.ErrAccountAlreadyExists
+		}
+		account := domain.NewAccount(event.AccountID, event.Balance)
+		account.CurrencyCode = event.Currency
+		accounts[event.AccountID] = account
+	case domain.AccountEventTypeFrozen:
+		account, ok := accounts[event.AccountID]
+		if !ok {
+			return domain.ErrAccountNotFound
+		}
+		account.Frozen = true
+	case domain.AccountEventTypeDeleted:
+		if _, ok := accounts[event.AccountID]; !ok {
+			return domain.ErrAccountNotFound
+		}
+		delete(accounts, event.AccountID)
+	}
+	return nil
+}
+
+// validateAccountEvent 檢查帳戶事件能否套用 (不修改帳戶 Map)
+func validateAccountEvent(accounts map[int64]*domain.Account, event *domain.AccountEvent) error {
+	_, exists := accounts[event.AccountID]
+	switch event.Type {
+	case domain.AccountEventTypeCreated:
+		if exists {
+			return domain.ErrAccountAlreadyExists
+		}
+	case domain.AccountEventTypeFrozen, domain.AccountEventTypeDeleted:
+		if !exists {
+			return domain.ErrAccountNotFound
+		}
+	}
+	return nil
+}
+
+// handleAccountCreation 套用「建立帳戶並設定初始餘額」的交易 (會改變 Map 結構，呼叫端需持有所有分片的寫鎖或位於事件迴圈中)
+//
+// 參數:
+//
+//	accounts: 帳戶資料 Map
+//	tran: domain.TransactionTypeAccountCreation 交易
+//
+// 回傳:
+//
+//	error: 帳戶已存在
+func handleAccountCreation(accounts map[int64]*domain.Account, tran *domain.Transaction) error {
+	if _, ok := accounts[tran.To]; ok {
+		return domain.ErrAccountAlreadyExists
+	}
+	account := domain.NewAccount(tran.To, tran.Amount)
+	account.CurrencyCode = tran.Currency
+	accounts[tran.To] = account
+	return nil
+}
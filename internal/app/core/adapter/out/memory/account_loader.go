@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+)
+
+// AccountPageSize 初始化時每次從 AccountLoader 載入的帳戶筆數
+const AccountPageSize int64 = 10000
+
+// loadAccounts 透過 AccountLoader 分頁載入所有帳戶
+// 每次只向來源要求 AccountPageSize 筆，直到回傳筆數小於 AccountPageSize 為止
+//
+// 參數:
+//
+//	ctx: 上下文
+//	loader: 帳戶資料來源
+//
+// 回傳:
+//
+//	map[int64]*domain.Account: 帳戶 ID 對應的 Domain Account 物件
+//	error: 載入錯誤
+func loadAccounts(ctx context.Context, loader usecase.AccountLoader) (map[int64]*domain.Account, error) {
+	accounts := make(map[int64]*domain.Account)
+	var offset int64
+	for {
+		batch, err := loader.LoadAccountsBatch(ctx, offset, AccountPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range batch {
+			accounts[account.ID] = account
+		}
+		if int64(len(batch)) < AccountPageSize {
+			return accounts, nil
+		}
+		offset += int64(len(batch))
+	}
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+)
+
+// StartCheckpointing 啟動背景 goroutine，每隔 interval 將帳戶餘額寫入檢查點 (非同步備份至 MySQL)
+// 記憶體帳本仍是主要寫入路徑；重啟時搭配 WithReplayAfterSequence 只重放檢查點之後的 WAL
+// ctx 結束時停止
+//
+// 參數:
+//
+//	ctx: 上下文
+//	store: 檢查點儲存 (*mysql.MySQLLedger)
+//	interval: 寫入間隔
+func (m *MutexLedger) StartCheckpointing(ctx context.Context, store usecase.CheckpointStore, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Checkpoint(ctx, store); err != nil {
+					slog.Error("ledger checkpoint failed", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+}
+
+// Checkpoint 將目前的帳戶快照與對應的順序號寫入檢查點
+// 自上次檢查點之後沒有新的交易時不寫入
+//
+// 參數:
+//
+//	ctx: 上下文
+//	store: 檢查點儲存
+//
+// 回傳:
+//
+//	error: 寫入錯誤
+func (m *MutexLedger) Checkpoint(ctx context.Context, store usecase.CheckpointStore) error {
+	accounts, sequence := m.Snapshot()
+	if sequence == m.checkpointSequence.Load() {
+		return nil
+	}
+	if err := store.SaveCheckpoint(ctx, accounts, sequence); err != nil {
+		return err
+	}
+	m.checkpointSequence.Store(sequence)
+	return nil
+}
+
+// CheckpointSequence 回傳最後一次寫入檢查點的順序號
+func (m *MutexLedger) CheckpointSequence() uint64 {
+	return m.checkpointSequence.Load()
+}
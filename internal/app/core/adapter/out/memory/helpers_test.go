@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// testInitialBalance stubLoader 載入的每個帳戶的初始餘額
+const testInitialBalance int64 = 1_000_000
+
+// stubLoader 載入 ID 為 0 ~ n-1 的帳戶，每個帳戶的餘額為 testInitialBalance
+type stubLoader struct {
+	n int64
+}
+
+func (s stubLoader) LoadAccountsBatch(ctx context.Context, offset, limit int64) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	for id := offset; id < s.n && id < offset+limit; id++ {
+		accounts = append(accounts, domain.NewAccount(id, testInitialBalance))
+	}
+	return accounts, nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// ErrHistoryDisabled 記憶體帳本未啟用交易紀錄 (見 WithHistoryCapacity / WithLMAXHistoryCapacity)
+var ErrHistoryDisabled = errors.New("transaction history is disabled")
+
+// transactionHistory 依帳戶保存最近成功套用的交易 (每個帳戶依 Sequence 遞增排序)
+// 容量以所有帳戶合計的筆數計算 (轉帳在雙方各算一筆)，超過時移除全域最舊的一筆，記憶體用量不會隨帳戶數增加；
+// 更早的紀錄請查詢 MySQL。同一帳戶的交易由帳本依序套用，因此 Append 的順序即為 Sequence 順序
+// nil 代表未啟用 (Append 不做任何事，Get 回傳 ErrHistoryDisabled)
+type transactionHistory struct {
+	mu        sync.RWMutex
+	byAccount map[int64][]domain.Transaction
+	// order 依加入順序記錄每筆紀錄所屬的帳戶 (環狀緩衝區，長度為容量)，用來找出全域最舊的紀錄
+	order []int64
+	head  int
+	size  int
+}
+
+// newTransactionHistory 建立最多保存 capacity 筆紀錄的交易紀錄 (capacity <= 0 時回傳 nil，表示不保存)
+func newTransactionHistory(capacity int) *transactionHistory {
+	if capacity <= 0 {
+		return nil
+	}
+	return &transactionHistory{
+		byAccount: make(map[int64][]domain.Transaction),
+		order:     make([]int64, capacity),
+	}
+}
+
+// Append 記錄一筆已成功套用的交易 (存入複本，呼叫端可繼續重複使用 tran)
+func (h *transactionHistory) Append(tran *domain.Transaction) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	lockIDs := tran.GetLockIDs()
+	for i, accountID := range lockIDs {
+		if i > 0 && lockIDs[i-1] == accountID {
+			// 自己轉給自己只記錄一次
+			continue
+		}
+		if h.size == len(h.order) {
+			h.evictOldest()
+		}
+		h.byAccount[accountID] = append(h.byAccount[accountID], *tran)
+		h.order[(h.head+h.size)%len(h.order)] = accountID
+		h.size++
+	}
+}
+
+// evictOldest 移除全域最舊的一筆紀錄 (需持有 mu)
+// 各帳戶的紀錄依加入順序排列，全域最舊的一筆必定是其所屬帳戶的第一筆
+func (h *transactionHistory) evictOldest() {
+	accountID := h.order[h.head]
+	h.head = (h.head + 1) % len(h.order)
+	h.size--
+	list := h.byAccount[accountID]
+	if len(list) <= 1 {
+		delete(h.byAccount, accountID)
+		return
+	}
+	// 移除的元素留在底層陣列中，之後 append 重新配置時才會釋放 (每個帳戶最多多佔用一倍)
+	h.byAccount[accountID] = list[1:]
+}
+
+// Get 取得帳戶在 afterSequence 之後的交易，最多 limit 筆
+//
+// 參數:
+//
+//	accountID: 帳戶 ID
+//	afterSequence: 游標 (只回傳 Sequence 大於此值的交易)
+//	limit: 最多回傳筆數
+//
+// 回傳:
+//
+//	[]*domain.Transaction: 依 Sequence 遞增排序的交易複本
+//	error: 未啟用時回傳 ErrHistoryDisabled
+func (h *transactionHistory) Get(accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error) {
+	if h == nil {
+		return nil, ErrHistoryDisabled
+	}
+	if limit <= 0 {
+		return []*domain.Transaction{}, nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	list := h.byAccount[accountID]
+	start := sort.Search(len(list), func(i int) bool {
+		return list[i].Sequence > afterSequence
+	})
+	end := len(list)
+	if start+limit < end {
+		end = start + limit
+	}
+	result := make([]*domain.Transaction, 0, end-start)
+	for i := start; i < end; i++ {
+		tran := list[i]
+		result = append(result, &tran)
+	}
+	return result, nil
+}
+
+// collectBalances 讀取多個帳戶的餘額 (不存在的帳戶略過，呼叫端需確保讀取期間帳戶不會被修改)
+func collectBalances(accounts map[int64]*domain.Account, accountIDs []int64) map[int64]int64 {
+	balances := make(map[int64]int64, len(accountIDs))
+	for _, id := range accountIDs {
+		if account, ok := accounts[id]; ok {
+			balances[id] = account.Balance
+		}
+	}
+	return balances
+}
+
+// recordLastTransaction 在交易涉及的帳戶上記錄最後一筆交易 (呼叫端需持有這些帳戶的鎖或位於事件迴圈中)
+func recordLastTransaction(accounts map[int64]*domain.Account, tran *domain.Transaction) {
+	for _, id := range tran.GetLockIDs() {
+		if account, ok := accounts[id]; ok {
+			account.RecordTransaction(tran)
+		}
+	}
+}
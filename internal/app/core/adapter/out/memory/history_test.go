@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+func transferTx(sequence uint64, from, to int64) *domain.Transaction {
+	return &domain.Transaction{Sequence: sequence, From: from, To: to, Amount: 1, Type: domain.TransactionTypeTransfer}
+}
+
+func sequencesOf(trans []*domain.Transaction) []uint64 {
+	seqs := make([]uint64, 0, len(trans))
+	for _, tran := range trans {
+		seqs = append(seqs, tran.Sequence)
+	}
+	return seqs
+}
+
+func equalSequences(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransactionHistoryDisabled(t *testing.T) {
+	h := newTransactionHistory(0)
+	h.Append(transferTx(1, 1, 2))
+	if _, err := h.Get(1, 0, 10); !errors.Is(err, ErrHistoryDisabled) {
+		t.Fatalf("Get error = %v, want ErrHistoryDisabled", err)
+	}
+}
+
+func TestTransactionHistoryEvictsGloballyOldest(t *testing.T) {
+	// 容量 4：每筆轉帳在雙方各佔一筆
+	h := newTransactionHistory(4)
+	h.Append(transferTx(1, 1, 2)) // 1:[1] 2:[1]
+	h.Append(transferTx(2, 1, 3)) // 1:[1 2] 2:[1] 3:[2]
+	h.Append(transferTx(3, 2, 3)) // 移除 1 與 2 的 seq 1 -> 1:[2] 2:[3] 3:[2 3]
+
+	tests := []struct {
+		accountID int64
+		want      []uint64
+	}{
+		{accountID: 1, want: []uint64{2}},
+		{accountID: 2, want: []uint64{3}},
+		{accountID: 3, want: []uint64{2, 3}},
+	}
+	for _, tt := range tests {
+		got, err := h.Get(tt.accountID, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalSequences(sequencesOf(got), tt.want) {
+			t.Fatalf("account %d history = %v, want %v", tt.accountID, sequencesOf(got), tt.want)
+		}
+	}
+	if h.size != 4 {
+		t.Fatalf("size = %d, want 4", h.size)
+	}
+
+	// 帳戶的所有紀錄都被移除後不再保留在 Map 中
+	h.Append(transferTx(4, 4, 5))
+	h.Append(transferTx(5, 4, 5))
+	if _, ok := h.byAccount[1]; ok {
+		t.Fatal("account 1 should be evicted")
+	}
+}
+
+func TestTransactionHistoryCursor(t *testing.T) {
+	h := newTransactionHistory(100)
+	for seq := uint64(1); seq <= 10; seq++ {
+		h.Append(transferTx(seq, 1, 2))
+	}
+	got, err := h.Get(1, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{4, 5, 6, 7}; !equalSequences(sequencesOf(got), want) {
+		t.Fatalf("page = %v, want %v", sequencesOf(got), want)
+	}
+}
@@ -0,0 +1,12 @@
+package memory
+
+import "time"
+
+// processedResult 已處理交易的紀錄
+// 重複送出相同交易時回傳原始結果，避免原本失敗 (如餘額不足) 的交易在重試時被誤判為成功
+type processedResult struct {
+	// At: 處理時間 (用於過期清理)
+	At time.Time
+	// Err: 原始處理結果 (nil 代表成功)
+	Err error
+}
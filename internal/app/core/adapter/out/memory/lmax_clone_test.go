@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// PostTransaction 回傳後呼叫端修改 (或重用) 原本的交易，不能影響寫入 WAL 的內容
+// WALModeAsync 在回覆後才寫入 WAL，最容易受影響
+func TestLMAXModifyingTransactionAfterPostDoesNotAffectWAL(t *testing.T) {
+	for _, mode := range []string{"sync", "async"} {
+		t.Run(mode, func(t *testing.T) {
+			walMode, err := ParseWALMode(mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			w := wal.NewMemWriter()
+			ledger, err := NewLMAXLedger(ctx, stubLoader{n: 3}, w, WithLMAXWALMode(walMode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger.Start(ctx)
+
+			const posts = 20
+			want := make([]domain.Transaction, 0, posts)
+			for n := 0; n < posts; n++ {
+				tx := &domain.Transaction{
+					TransactionID: uuid.New(),
+					Type:          domain.TransactionTypeTransfer,
+					From:          1,
+					To:            2,
+					Amount:        int64(100 * (n + 1)),
+				}
+				if err := ledger.PostTransaction(ctx, tx); err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, *tx)
+				// 呼叫端重用同一個物件
+				*tx = domain.Transaction{TransactionID: uuid.New(), Type: domain.TransactionTypeWithdraw, From: 2, Amount: 1}
+			}
+			<-ledger.Stop()
+
+			entries := w.Entries()
+			if len(entries) != posts {
+				t.Fatalf("WAL has %d entries, want %d", len(entries), posts)
+			}
+			for i, raw := range entries {
+				var got domain.Transaction
+				if err := json.Unmarshal(raw, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got != want[i] {
+					t.Fatalf("WAL entry %d = %+v, want %+v", i, got, want[i])
+				}
+			}
+		})
+	}
+}
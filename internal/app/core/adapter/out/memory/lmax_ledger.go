@@ -2,8 +2,9 @@ package memory
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,42 +17,179 @@ import (
 // 交易紀錄保留時間，預設 60 分鐘
 const transactionRecordWindow = 60 * time.Minute
 
+// cleanupInterval 每處理這麼多筆交易就清理一次過期的交易紀錄 (另外每分鐘也會清理一次)
+const cleanupInterval = 100000
+
 // Batch 設定
 const BatchSize = 100                      // 每 100 筆 刷一次
 const BatchTimeout = 10 * time.Millisecond // 或每 10ms 刷一次
 
+// DefaultIdleThreshold 佇列持續沒有請求超過此時間後，事件迴圈停止批次計時器直到下一個請求到達
+const DefaultIdleThreshold = time.Second
+
+// ErrQueueFull 事件迴圈佇列 (transactionChan) 已滿，在 ctx 結束或 QueueFullTimeout 之前都沒能排入
+// 交易尚未送出，呼叫端可以稍後重試
+var ErrQueueFull = errors.New("lmax ledger queue full")
+
+// ErrLedgerStopped 事件迴圈已結束 (Stop 或 Start 的 ctx 結束)，請求不會再被處理
+var ErrLedgerStopped = errors.New("lmax ledger stopped")
+
 // transactionRequest 交易請求包裝channel，讓PostTransaction可以等待結果
+// Tx 為 nil 時代表查詢的 Sentinel 事件 (見 GetAccountBalanceConsistent、LoadAllAccounts)
 type transactionRequest struct {
+	// Tx 指向 tx (呼叫端交易的複本)，事件迴圈與 WAL goroutine 只讀寫複本
 	Tx     *domain.Transaction
+	tx     domain.Transaction
 	Result chan error // 讓 PostTransaction 等這個 channel
+	// 餘額查詢 (Sentinel) 使用
+	AccountID int64
+	Balance   int64
+	// 單一帳戶資料查詢 (Sentinel) 使用：WantAccount 為 true 時由事件迴圈填入 Account 的複本
+	WantAccount bool
+	Account     domain.Account
+	// 全帳戶快照查詢 (Sentinel) 使用：WantSnapshot 為 true 時由事件迴圈填入 Snapshot
+	WantSnapshot bool
+	Snapshot     map[int64]*domain.Account
+	// 多帳戶餘額查詢 (Sentinel) 使用：AccountIDs 不為 nil 時由事件迴圈填入 Balances
+	AccountIDs []int64
+	Balances   map[int64]int64
 }
 
 type LMAXLedger struct {
 	accounts map[int64]*domain.Account
-	// 已處理過的交易
-	processedTransactions map[uuid.UUID]time.Time
-	wal                   *wal.WAL
-	transactionChan       chan *transactionRequest
+	// accountsMu 保護 accounts 的 Map 結構：只有事件迴圈會新增帳戶 (持有寫鎖)，
+	// 事件迴圈以外的讀取 (GetAccountBalance 等) 需持有讀鎖，事件迴圈本身讀取不需加鎖
+	accountsMu sync.RWMutex
+	// 已處理過的交易 (只有事件迴圈會存取)
+	processedTransactions map[uuid.UUID]processedResult
+	// processedTransactions 的大小 (供其他 goroutine 讀取)
+	processedSize atomic.Int64
+	// 距離上次清理處理過的交易筆數
+	eventsSinceCleanup int
+	wal                wal.Writer
+	transactionChan    chan *transactionRequest
+	// 最後一筆分配的交易順序號 (只有事件迴圈會修改)
+	sequence uint64
+	// WAL 寫入管線：walChan 送出批次給 WAL goroutine，walDone 送回寫入完成的批次
+	walChan chan *walBatch
+	walDone chan *walBatch
+	// 等待 WAL 寫入的批次 (依送出順序) 與其中的交易 ID (只有事件迴圈會存取)
+	inflight   []*walBatch
+	pendingIDs map[uuid.UUID]struct{}
+	// 最近成功的交易紀錄 (nil 表示未啟用)
+	history *transactionHistory
 	// Pool 減少 GC 壓力
 	requestPool sync.Pool
+	// 啟動時遇到損毀 WAL 紀錄的處理方式
+	recoveryMode RecoveryMode
+	// 事件迴圈處理統計 (見 Stats)
+	stats lmaxStats
+	// WAL 寫入失敗時的重試策略與累計重試次數 (只有 WAL goroutine 會重試)
+	retryPolicy     RetryPolicy
+	walWriteRetries atomic.Uint64
+	// WAL 寫入模式 (見 WALMode)
+	walMode WALMode
+	// WALModeAsync 使用：asyncWALChan 送出已套用交易的複本，asyncWALDone 在寫完後關閉，
+	// walErrChan 回報寫入失敗；halted 為 true 時拒絕所有交易 (只有事件迴圈會存取)
+	asyncWALChan chan []domain.Transaction
+	asyncWALDone chan struct{}
+	walErrChan   chan error
+	halted       bool
+	// 閒置偵測：超過 idleThreshold 沒有請求時停止批次計時器 (lastActivity 只有事件迴圈會存取)，
+	// idleSince 為進入閒置的時間 (UnixNano，0 表示未閒置)，供監控讀取
+	idleThreshold time.Duration
+	lastActivity  time.Time
+	idleSince     atomic.Int64
+	// 佇列已滿時最多等待多久 (0 表示只受 ctx 限制，見 WithLMAXQueueFullTimeout)
+	queueFullTimeout time.Duration
+	// Stop 通知事件迴圈停止；done 在剩餘交易處理完後關閉
+	stopChan chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// LMAXLedgerOption 定義了 LMAXLedger 的配置選項函數
+type LMAXLedgerOption func(*LMAXLedger)
+
+// WithLMAXRecoveryMode 設定啟動時遇到損毀 WAL 紀錄的處理方式 (預設 RecoveryModeStrict)
+func WithLMAXRecoveryMode(mode RecoveryMode) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.recoveryMode = mode
+	}
+}
+
+// WithLMAXRetryPolicy 設定 WAL 寫入失敗時的重試策略 (預設不重試)
+// 重試發生在 WAL goroutine，期間事件迴圈最多再送出 maxInflightBatches 個批次後就會等待
+func WithLMAXRetryPolicy(policy RetryPolicy) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.retryPolicy = policy
+	}
+}
+
+// WithLMAXWALMode 設定 WAL 寫入模式 (預設 WALModeSync)
+// WALModeDisabled 時忽略傳入的 wal，啟動時也不會從 WAL 恢復
+func WithLMAXWALMode(mode WALMode) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.walMode = mode
+	}
+}
+
+// WithLMAXIdleThreshold 設定閒置判定時間 (預設 DefaultIdleThreshold)
+// 佇列持續沒有請求超過 threshold 後，事件迴圈不再每 BatchTimeout 醒來一次，直到下一個請求到達
+func WithLMAXIdleThreshold(threshold time.Duration) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.idleThreshold = threshold
+	}
+}
+
+// WithLMAXQueueFullTimeout 設定佇列已滿時的最長等待時間 (預設 0，只受 ctx 限制)
+// 與 ctx 的期限取較早者，讓上層 ctx 期限很長時也能在持續過載下快速回傳 ErrQueueFull
+func WithLMAXQueueFullTimeout(timeout time.Duration) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.queueFullTimeout = timeout
+	}
+}
+
+// WithLMAXHistoryCapacity 保存最近 capacity 筆成功的交易供 GetTransactionHistory 查詢 (所有帳戶合計，預設不保存)
+// 每筆約 96 bytes (交易複本加上環狀緩衝區的帳戶 ID)，轉帳在雙方各算一筆
+func WithLMAXHistoryCapacity(capacity int) LMAXLedgerOption {
+	return func(ledger *LMAXLedger) {
+		ledger.history = newTransactionHistory(capacity)
+	}
 }
 
 // NewLMAXLedger 建立一個新的 LMAXLedger 實例
 //
 // 參數:
 //
-//	wal: Write-Ahead Log 實例
+//	ctx: 上下文
+//	loader: 初始帳戶資料來源 (分頁載入)
+//	wal: Write-Ahead Log 實作 (正式環境為 *wal.WAL)
+//	opts: 可選的配置選項
 //
 // 回傳:
 //
 //	*LMAXLedger: LMAXLedger 實例
 //	error: 初始化錯誤
-func NewLMAXLedger(accounts map[int64]*domain.Account, wal *wal.WAL) (*LMAXLedger, error) {
+func NewLMAXLedger(ctx context.Context, loader usecase.AccountLoader, wal wal.Writer, opts ...LMAXLedgerOption) (*LMAXLedger, error) {
+	accounts, err := loadAccounts(ctx, loader)
+	if err != nil {
+		return nil, err
+	}
 	ledger := &LMAXLedger{
-		accounts:              accounts, // 直接引用傳入的 Map
-		processedTransactions: make(map[uuid.UUID]time.Time),
+		accounts:              accounts,
+		processedTransactions: make(map[uuid.UUID]processedResult),
 		wal:                   wal,
 		transactionChan:       make(chan *transactionRequest, 1000),
+		walChan:               make(chan *walBatch, maxInflightBatches),
+		walDone:               make(chan *walBatch, maxInflightBatches),
+		asyncWALChan:          make(chan []domain.Transaction, maxInflightBatches),
+		asyncWALDone:          make(chan struct{}),
+		walErrChan:            make(chan error, 1),
+		pendingIDs:            make(map[uuid.UUID]struct{}),
+		stopChan:              make(chan struct{}),
+		done:                  make(chan struct{}),
+		idleThreshold:         DefaultIdleThreshold,
 		requestPool: sync.Pool{
 			New: func() interface{} {
 				return &transactionRequest{
@@ -60,8 +198,14 @@ func NewLMAXLedger(accounts map[int64]*domain.Account, wal *wal.WAL) (*LMAXLedge
 			},
 		},
 	}
+	for _, opt := range opts {
+		opt(ledger)
+	}
+	if ledger.walMode == WALModeDisabled {
+		ledger.wal = nil
+	}
 
-	if err := ledger.recoverFromWAL(); err != nil {
+	if err := ledger.recoverFromWAL(ctx); err != nil {
 		return nil, err
 	}
 
@@ -70,34 +214,27 @@ func NewLMAXLedger(accounts map[int64]*domain.Account, wal *wal.WAL) (*LMAXLedge
 
 // recoverFromWAL 從 WAL 檔案恢復帳本狀態
 //
+// 參數:
+//
+//	ctx: 上下文
+//
 // 回傳:
 //
 //	error: 恢復過程錯誤
-func (l *LMAXLedger) recoverFromWAL() error {
-	tranHistory := make([]domain.Transaction, 0)
-
-	err := l.wal.ReadAll(func(jsonRaw []byte) error {
-		var tran domain.Transaction
-		if err := json.Unmarshal(jsonRaw, &tran); err != nil {
-			return err
-		}
-		tranHistory = append(tranHistory, tran)
-		return nil
+func (l *LMAXLedger) recoverFromWAL(ctx context.Context) error {
+	now := time.Now()
+	err := replayWALTransactions(ctx, l.wal, l.recoveryMode, func(tran *domain.Transaction) {
+		l.applyRecoverTransaction(tran, now)
 	})
 	if err != nil {
 		return err
 	}
-	now := time.Now()
-	for _, tran := range tranHistory {
-		if err := l.applyRecoverTransaction(&tran, now); err != nil {
-			return err
-		}
-	}
+	l.processedSize.Store(int64(len(l.processedTransactions)))
 	return nil
 }
 
 // applyRecoverTransaction 恢復單筆交易 (不寫 WAL，不透過 Channel)
-func (l *LMAXLedger) applyRecoverTransaction(tran *domain.Transaction, now time.Time) error {
+func (l *LMAXLedger) applyRecoverTransaction(tran *domain.Transaction, now time.Time) {
 	// 直接更新 State，不需要 Lock 因為這是在 NewLMAXLedger 裡跑的 (單執行緒)
 	var err error
 	switch tran.Type {
@@ -107,15 +244,34 @@ func (l *LMAXLedger) applyRecoverTransaction(tran *domain.Transaction, now time.
 		err = l.handleWithdraw(tran)
 	case domain.TransactionTypeTransfer:
 		err = l.handleTransfer(tran)
+	case domain.TransactionTypeFXTransfer:
+		err = l.handleFXTransfer(tran)
+	case domain.TransactionTypeAccountCreation:
+		err = l.handleAccountCreation(tran)
 	}
 
+	// 重放時的業務錯誤 (如餘額不足) 與原始處理結果相同，記錄下來供冪等性檢查回傳
+	l.processedTransactions[tran.TransactionID] = processedResult{At: now, Err: err}
 	if err == nil {
-		l.processedTransactions[tran.TransactionID] = now
+		l.history.Append(tran)
+		recordLastTransaction(l.accounts, tran)
+	}
+	if tran.Sequence > l.sequence {
+		l.sequence = tran.Sequence
 	}
-	return err
 }
 
-// GetAccountBalance 取得指定帳戶的當前餘額
+// GetAccountBalance 取得指定帳戶的當前餘額 (不經過事件迴圈，最終一致)
+//
+// 並行安全性：事件迴圈是唯一的寫入者，但「單一寫入者」本身不足以讓其他 goroutine 直接讀取——
+// Go 記憶體模型只保證同一個 goroutine 內的順序，跨 goroutine 的一般讀寫仍是 Data Race (-race 會回報)。
+// 因此這裡分兩層同步：
+//  1. Map 結構：事件迴圈新增帳戶時持有 accountsMu 寫鎖，這裡持有讀鎖查詢
+//  2. 餘額欄位：Deposit / Withdraw (及轉帳失敗的退款) 以 atomic 寫入，這裡以 AtomicBalance 讀取
+//
+// 讀到的是某一筆交易套用前或後的完整值，不會讀到寫一半的值；同步 WAL 模式下交易在 WAL 寫入後才套用，
+// 因此不會讀到尚未持久化的餘額。轉帳入帳失敗 (餘額溢位) 時，可能短暫讀到扣款後、退款前的值；
+// 需要與交易順序一致的讀取請使用 GetAccountBalanceConsistent
 //
 // 參數:
 //
@@ -127,16 +283,231 @@ func (l *LMAXLedger) applyRecoverTransaction(tran *domain.Transaction, now time.
 //	int64: 帳戶餘額
 //	error: 查詢錯誤 (如帳戶不存在)
 func (l *LMAXLedger) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
+	l.accountsMu.RLock()
 	account, ok := l.accounts[accountID]
+	l.accountsMu.RUnlock()
 	if !ok {
 		return 0, domain.ErrAccountNotFound
 	}
-	return account.Balance, nil
+	return account.AtomicBalance(), nil
+}
+
+// GetAccount 取得指定帳戶資料的複本
+// 複製整個結構會讀到 Balance 以外的欄位 (如 RecordTransaction 寫入的 LastTransactionID)，這些欄位只有事件迴圈會寫入且不是 atomic，
+// 因此與 GetAccountBalanceConsistent 相同送入 Sentinel 事件，由事件迴圈在兩筆交易之間複製，不會讀到更新一半的資料；
+// 呼叫前必須先呼叫 Start
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	domain.Account: 帳戶資料複本
+//	error: 查詢錯誤 (如帳戶不存在)、佇列已滿 (ErrQueueFull) 或事件迴圈已結束 (ErrLedgerStopped)
+func (l *LMAXLedger) GetAccount(ctx context.Context, accountID int64) (domain.Account, error) {
+	req := l.getQueryRequest(accountID)
+	req.WantAccount = true
+	if err := l.enqueue(ctx, req); err != nil {
+		l.requestPool.Put(req)
+		return domain.Account{}, err
+	}
+	answered, err := l.awaitResult(req)
+	if !answered {
+		return domain.Account{}, err
+	}
+	account := req.Account
+	l.requestPool.Put(req)
+	return account, err
 }
 
-// LoadAllAccounts implements usecase.Ledger.
+// GetAvailableBalance 取得指定帳戶的可用餘額 (Balance - Reserved)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	int64: 可用餘額
+//	error: 查詢錯誤 (如帳戶不存在)
+func (l *LMAXLedger) GetAvailableBalance(ctx context.Context, accountID int64) (int64, error) {
+	l.accountsMu.RLock()
+	account, ok := l.accounts[accountID]
+	l.accountsMu.RUnlock()
+	if !ok {
+		return 0, domain.ErrAccountNotFound
+	}
+	// 與 GetAccountBalance 相同以 atomic 讀取餘額 (LMAX 帳本不會在載入後異動 Reserved)
+	return account.AtomicBalance() - account.Reserved, nil
+}
+
+// GetTransactionHistory 取得帳戶的交易紀錄 (以 Sequence 為游標分頁)
+// 記憶體帳本只保留最近 WithLMAXHistoryCapacity 筆成功的交易 (所有帳戶合計)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//	afterSequence: 游標 (只回傳 Sequence 大於此值的交易，第一頁帶 0)
+//	limit: 最多回傳筆數
+//
+// 回傳:
+//
+//	[]*domain.Transaction: 依 Sequence 遞增排序的交易
+//	error: 未啟用時回傳 ErrHistoryDisabled
+func (l *LMAXLedger) GetTransactionHistory(ctx context.Context, accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error) {
+	return l.history.Get(accountID, afterSequence, limit)
+}
+
+// GetAccountBalanceConsistent 取得帳戶餘額 (Read-Your-Writes)
+// 透過 transactionChan 送入一個 Sentinel 事件，由事件迴圈在處理完排在它之前的所有交易後讀取餘額，
+// 保證能看到同一個 Client 先前送出的所有寫入。
+//
+// 與 GetAccountBalance 的取捨: GetAccountBalance 直接讀 Map (最終一致，不佔用事件迴圈)；
+// 本方法需要排隊等待並佔用一個批次名額，延遲最多為一個 BatchTimeout 加上前方排隊的交易量，
+// 只應在需要強一致讀取時使用。
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	int64: 帳戶餘額
+//	error: 查詢錯誤 (如帳戶不存在)、佇列已滿 (ErrQueueFull) 或事件迴圈已結束 (ErrLedgerStopped)
+func (l *LMAXLedger) GetAccountBalanceConsistent(ctx context.Context, accountID int64) (int64, error) {
+	req := l.getQueryRequest(accountID)
+	if err := l.enqueue(ctx, req); err != nil {
+		l.requestPool.Put(req)
+		return 0, err
+	}
+	answered, err := l.awaitResult(req)
+	if !answered {
+		return 0, err
+	}
+	balance := req.Balance
+	l.requestPool.Put(req)
+	return balance, err
+}
+
+// getQueryRequest 從 Pool 取得單一帳戶查詢用的 Sentinel 請求 (清除上一次使用留下的欄位)
+func (l *LMAXLedger) getQueryRequest(accountID int64) *transactionRequest {
+	req := l.requestPool.Get().(*transactionRequest)
+	req.Tx = nil
+	req.AccountID = accountID
+	req.Balance = 0
+	req.WantAccount = false
+	req.Account = domain.Account{}
+	req.WantSnapshot = false
+	req.AccountIDs = nil
+	select {
+	case <-req.Result:
+	default:
+	}
+	return req
+}
+
+// GetMultipleAccountBalances 一次取得多個帳戶的餘額 (同一時間點的一致快照)
+// 與 GetAccountBalanceConsistent 相同送入 Sentinel 事件，由事件迴圈在兩筆交易之間一次讀取所有帳戶；
+// 呼叫前必須先呼叫 Start
+//
+// 參數:
+//
+//	ctx: 上下文 (取消時放棄等待)
+//	accountIDs: 帳戶 ID
+//
+// 回傳:
+//
+//	map[int64]int64: 帳戶 ID 對應的餘額 (不存在的帳戶不會出現在結果中)
+//	error: ctx 取消錯誤或事件迴圈已結束 (ErrLedgerStopped)
+func (l *LMAXLedger) GetMultipleAccountBalances(ctx context.Context, accountIDs []int64) (map[int64]int64, error) {
+	if accountIDs == nil {
+		accountIDs = []int64{}
+	}
+	req := &transactionRequest{
+		Result:     make(chan error, 1),
+		AccountIDs: accountIDs,
+	}
+	select {
+	case l.transactionChan <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, ErrLedgerStopped
+	}
+	select {
+	case err := <-req.Result:
+		return req.Balances, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		if answered, err := l.awaitResult(req); answered {
+			return req.Balances, err
+		}
+		return nil, ErrLedgerStopped
+	}
+}
+
+// CreateAccountWithInitialBalance 建立帳戶並設定初始餘額
+// 以單一筆 domain.TransactionTypeAccountCreation 交易送入事件迴圈並寫入 WAL，不會只建立帳戶而沒有初始餘額
+//
+// 參數:
+//
+//	ctx: 上下文
+//	account: 新帳戶 (使用 ID 與 CurrencyCode)
+//	initialAmount: 初始餘額 (可為 0)
+//	refID: 外部追蹤號 (冪等性)
+//
+// 回傳:
+//
+//	error: 帳戶已存在 (domain.ErrAccountAlreadyExists)、參數錯誤或 WAL 寫入錯誤
+func (l *LMAXLedger) CreateAccountWithInitialBalance(ctx context.Context, account *domain.Account, initialAmount int64, refID uuid.UUID) error {
+	tran := domain.NewAccountCreation(account, initialAmount, refID, time.Now().UnixMilli())
+	if err := tran.Validate(); err != nil {
+		return err
+	}
+	return l.postTransactionInternal(ctx, tran)
+}
+
+// LoadAllAccounts 取得所有帳戶資料的深拷貝
+// 帳戶 Map 只有事件迴圈可以存取，因此與 GetAccountBalanceConsistent 相同，送入 Sentinel 事件由事件迴圈複製；
+// 呼叫前必須先呼叫 Start
+//
+// 參數:
+//
+//	ctx: 上下文 (取消時放棄等待)
+//
+// 回傳:
+//
+//	map[int64]*domain.Account: 帳戶資料深拷貝 (呼叫端可自由修改)
+//	error: ctx 取消錯誤或事件迴圈已結束 (ErrLedgerStopped)
 func (l *LMAXLedger) LoadAllAccounts(ctx context.Context) (map[int64]*domain.Account, error) {
-	return l.accounts, nil
+	req := &transactionRequest{
+		Result:       make(chan error, 1),
+		WantSnapshot: true,
+	}
+	select {
+	case l.transactionChan <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, ErrLedgerStopped
+	}
+	select {
+	case err := <-req.Result:
+		return req.Snapshot, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		if answered, err := l.awaitResult(req); answered {
+			return req.Snapshot, err
+		}
+		return nil, ErrLedgerStopped
+	}
 }
 
 // PostTransaction 接收交易請求
@@ -148,48 +519,178 @@ func (l *LMAXLedger) LoadAllAccounts(ctx context.Context) (map[int64]*domain.Acc
 //
 // 回傳:
 //
-//	error: 處理錯誤
+//	error: 處理錯誤；佇列已滿且在 ctx 結束 (或 QueueFullTimeout) 前都沒能排入時回傳 ErrQueueFull，
+//	事件迴圈已結束時回傳 ErrLedgerStopped (交易未處理)
 //
-// PostTransaction(等待) -> Channel -> Run Loop (核心) -> WAL -> Map Update -> Result Channel -> PostTransaction(收到結果)
+// PostTransaction(等待) -> Channel -> Run Loop (核心) -> WAL goroutine -> Run Loop: Map Update -> Result Channel -> PostTransaction(收到結果)
 func (l *LMAXLedger) PostTransaction(ctx context.Context, tran *domain.Transaction) error {
-	return l.postTransactionInternal(tran)
+	return l.postTransactionInternal(ctx, tran)
 }
 
-func (l *LMAXLedger) postTransactionInternal(tran *domain.Transaction) error {
+func (l *LMAXLedger) postTransactionInternal(ctx context.Context, tran *domain.Transaction) error {
 	// 1. 放入輸送帶 (使用 sync.Pool 減少 GC)
 	req := l.requestPool.Get().(*transactionRequest)
-	req.Tx = tran
+	// 複製交易 (等同 tran.Clone()，但重用 Pool 中的記憶體)：
+	// 呼叫端之後修改或重用 tran (如放回 TransactionFactory 的 Pool) 不會影響 WAL 與記憶體狀態
+	req.tx = *tran
+	req.Tx = &req.tx
+	req.AccountID = 0
+	req.Balance = 0
+	req.WantAccount = false
+	req.WantSnapshot = false
+	req.AccountIDs = nil
 	// 清空 Channel (雖然理論上應該是空的，但保險起見)
 	select {
 	case <-req.Result:
 	default:
 	}
 
-	l.transactionChan <- req
-	err := <-req.Result
+	if err := l.enqueue(ctx, req); err != nil {
+		req.Tx = nil
+		l.requestPool.Put(req)
+		return err
+	}
+	// 事件迴圈執行中，已排入的請求一定會被回覆，必須等到結果才能將 req 放回 Pool
+	answered, err := l.awaitResult(req)
+	if !answered {
+		return err
+	}
+	// 回寫事件迴圈分配的順序號 (呼叫端以 tran.Sequence 取得)
+	tran.Sequence = req.tx.Sequence
+	req.Tx = nil
 	l.requestPool.Put(req)
 	return err
 }
 
+// enqueue 將請求送入 transactionChan
+// 佇列已滿時等待空位，直到 ctx 結束或超過 queueFullTimeout 時回傳 ErrQueueFull，避免持續過載時呼叫端無限期阻塞
+//
+// 參數:
+//
+//	ctx: 上下文
+//	req: 交易或查詢請求
+//
+// 回傳:
+//
+//	error: 佇列已滿 (ErrQueueFull) 或事件迴圈已結束 (ErrLedgerStopped)
+func (l *LMAXLedger) enqueue(ctx context.Context, req *transactionRequest) error {
+	// 事件迴圈已結束時佇列仍可能有空位，但不會再有人處理
+	select {
+	case <-l.done:
+		return ErrLedgerStopped
+	default:
+	}
+	// 快速路徑：佇列有空位時不建立計時器
+	select {
+	case l.transactionChan <- req:
+		return nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if l.queueFullTimeout > 0 {
+		timer := time.NewTimer(l.queueFullTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.transactionChan <- req:
+		return nil
+	case <-ctx.Done():
+		return ErrQueueFull
+	case <-timeout:
+		return ErrQueueFull
+	case <-l.done:
+		return ErrLedgerStopped
+	}
+}
+
+// awaitResult 等待事件迴圈回覆已排入的請求
+// 事件迴圈在 drain 之後才排入的請求不會被回覆，事件迴圈結束時回傳 ErrLedgerStopped 而不是無限期等待
+//
+// 參數:
+//
+//	req: 已排入的請求
+//
+// 回傳:
+//
+//	bool: 是否收到回覆 (false 時 req 仍留在佇列中，不可放回 Pool)
+//	error: 事件迴圈回覆的結果，或 ErrLedgerStopped
+func (l *LMAXLedger) awaitResult(req *transactionRequest) (bool, error) {
+	select {
+	case err := <-req.Result:
+		return true, err
+	case <-l.done:
+		// 事件迴圈可能在結束前剛好回覆
+		select {
+		case err := <-req.Result:
+			return true, err
+		default:
+			return false, ErrLedgerStopped
+		}
+	}
+}
+
 // Start 啟動核心引擎 (非同步)
+// ctx 結束或呼叫 Stop 都會讓事件迴圈處理完剩餘交易後結束
 func (l *LMAXLedger) Start(ctx context.Context) {
+	switch {
+	case l.wal == nil:
+	case l.walMode == WALModeAsync:
+		go l.asyncWALLoop()
+	default:
+		go l.walLoop()
+	}
 	go l.run(ctx)
 }
 
+// Stop 停止核心引擎，不依賴 Start 的 ctx
+// 事件迴圈會先處理完已排隊的交易，完成後關閉回傳的 channel
+// 呼叫前應先停止新的請求進入 (如 grpc.Server.GracefulStop)，可重複呼叫；必須先呼叫過 Start
+//
+// 回傳:
+//
+//	<-chan struct{}: 剩餘交易處理完成後關閉
+func (l *LMAXLedger) Stop() <-chan struct{} {
+	l.stopOnce.Do(func() {
+		close(l.stopChan)
+	})
+	return l.done
+}
+
 func (l *LMAXLedger) run(ctx context.Context) {
+	defer close(l.done)
+	// 剩餘交易處理完、WAL goroutine 寫完所有批次後才結束
+	defer l.stopWALWriter()
+	defer l.waitInflight()
 	batch := make([]*transactionRequest, 0, BatchSize)
 	timer := time.NewTimer(BatchTimeout)
 	defer timer.Stop()
 	// 1 分鐘檢查一次
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
+	// 每秒更新處理速率
+	rateTicker := time.NewTicker(statsRateInterval)
+	defer rateTicker.Stop()
+	l.lastActivity = time.Now()
 	for {
 		select {
 		case <-ctx.Done():
 			// 收到關閉信號，把剩下的交易處理完
+			l.processBatch(batch)
+			l.drain()
+			return
+		case <-l.stopChan:
+			l.processBatch(batch)
 			l.drain()
 			return
 		case req := <-l.transactionChan:
+			l.lastActivity = time.Now()
+			if l.idleSince.Load() != 0 {
+				// 閒置後的第一個請求：恢復批次計時器 (閒置期間計時器已觸發且未重設)
+				l.idleSince.Store(0)
+				timer.Reset(BatchTimeout)
+			}
 			batch = append(batch, req)
 			if len(batch) >= BatchSize {
 				l.processBatch(batch)
@@ -200,19 +701,76 @@ func (l *LMAXLedger) run(ctx context.Context) {
 			if len(batch) > 0 {
 				l.processBatch(batch)
 				batch = batch[:0]
+			} else if l.idleThreshold > 0 && time.Since(l.lastActivity) >= l.idleThreshold {
+				// 進入閒置：不重設計時器，等下一個請求到達
+				l.idleSince.Store(time.Now().UnixNano())
+				continue
 			}
 			timer.Reset(BatchTimeout)
+		case completed := <-l.walDone:
+			l.completeBatch(completed)
+		case err := <-l.walErrChan:
+			l.halt(err)
+			// 已收集的交易同樣拒絕 (之後排隊的交易在各自的批次中拒絕)
+			l.processBatch(batch)
+			batch = batch[:0]
 		case <-ticker.C:
-			now := time.Now()
-			for txID, txTime := range l.processedTransactions {
-				if now.Sub(txTime) > transactionRecordWindow {
-					delete(l.processedTransactions, txID)
-				}
-			}
+			l.cleanupProcessedTransactions(transactionRecordWindow)
+		case <-rateTicker.C:
+			l.stats.updateRate()
 		}
 	}
 }
 
+// cleanupProcessedTransactions 移除超過 olderThan 的交易紀錄 (只能在事件迴圈中呼叫，不需加鎖)
+// 超過保留時間後重複送出的交易會被視為新交易
+func (l *LMAXLedger) cleanupProcessedTransactions(olderThan time.Duration) {
+	now := time.Now()
+	for txID, processed := range l.processedTransactions {
+		if now.Sub(processed.At) > olderThan {
+			delete(l.processedTransactions, txID)
+		}
+	}
+	l.eventsSinceCleanup = 0
+	l.processedSize.Store(int64(len(l.processedTransactions)))
+}
+
+// ProcessedMapSize 回傳目前保存的已處理交易筆數 (供監控使用)
+func (l *LMAXLedger) ProcessedMapSize() int {
+	return int(l.processedSize.Load())
+}
+
+// IsIdle 事件迴圈是否處於閒置狀態 (佇列持續沒有請求超過閒置判定時間)
+func (l *LMAXLedger) IsIdle() bool {
+	return l.idleSince.Load() != 0
+}
+
+// IdleSince 回傳進入閒置的時間，未閒置時回傳零值
+func (l *LMAXLedger) IdleSince() time.Time {
+	since := l.idleSince.Load()
+	if since == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, since)
+}
+
+// QueueDepth 回傳排隊等待事件迴圈處理的請求數 (供監控使用)
+// 持續接近 QueueCapacity 代表事件迴圈處理速度跟不上請求到達速度，佇列滿後呼叫端會被阻塞
+func (l *LMAXLedger) QueueDepth() int {
+	return len(l.transactionChan)
+}
+
+// QueueCapacity 回傳請求佇列的容量 (使用率 = QueueDepth / QueueCapacity)
+func (l *LMAXLedger) QueueCapacity() int {
+	return cap(l.transactionChan)
+}
+
+// WALWriteRetries 回傳啟動以來 WAL 寫入的累計重試次數
+// 持續增加但沒有 ErrWALWriteFailed 表示暫時性錯誤；兩者一起增加表示磁碟可能已無法寫入
+func (l *LMAXLedger) WALWriteRetries() uint64 {
+	return l.walWriteRetries.Load()
+}
+
 // drain 處理剩餘的交易 (關機時)
 func (l *LMAXLedger) drain() {
 	// 收集所有剩餘的 request
@@ -237,58 +795,108 @@ func (l *LMAXLedger) drain() {
 
 // processBatch 批次處理交易 (Group Commit)
 // 1. 預先篩選出「真正需要處理」的交易
-// 2. 寫入 WAL Buffer
-// 3. Flush
-// 4. 執行記憶體邏輯 & 回覆
+// 2. 分配順序號
+// 3. 交給 WAL goroutine 寫入並 Flush (事件迴圈不等待 fsync，繼續處理下一批)
+// 4. 寫入完成後由事件迴圈執行記憶體邏輯 & 回覆 (見 completeBatch)
 func (l *LMAXLedger) processBatch(batch []*transactionRequest) {
 	// 1.預先篩選出「真正需要處理」的交易
-	validRequests := make([]*transactionRequest, 0, len(batch))
+	pending := &walBatch{requests: make([]*transactionRequest, 0, len(batch))}
 	// 考慮batch 中可能有重複的交易，使用 map 來檢查
 	batchSeen := make(map[uuid.UUID]struct{})
 	for _, req := range batch {
-		// 冪等性檢查
-		if _, ok := l.processedTransactions[req.Tx.TransactionID]; ok {
-			req.Result <- nil
+		// 同一批內重複的交易、餘額查詢 Sentinel 都等到本批交易處理完才回覆
+		if req.Tx == nil {
+			pending.queries = append(pending.queries, req)
+			continue
+		}
+		// WALModeAsync 寫入失敗後不再接受交易
+		if l.halted {
+			req.Result <- domain.ErrWALWriteFailed
 			continue
 		}
-		// 檢查 Batch 內部是否已經有這個 ID
-		if _, ok := batchSeen[req.Tx.TransactionID]; ok {
-			req.Result <- nil
+		// 冪等性檢查 (回傳原始處理結果)
+		if processed, ok := l.processedTransactions[req.Tx.TransactionID]; ok {
+			req.Result <- processed.Err
+			continue
+		}
+		// 檢查 Batch 內部或等待 WAL 寫入的批次是否已經有這個 ID
+		if _, ok := batchSeen[req.Tx.TransactionID]; ok || l.isPending(req.Tx.TransactionID) {
+			pending.duplicates = append(pending.duplicates, req)
 			continue
 		}
 		batchSeen[req.Tx.TransactionID] = struct{}{}
-		validRequests = append(validRequests, req)
+		pending.requests = append(pending.requests, req)
 	}
-	// 0筆 直接結束
-	if len(validRequests) == 0 {
+	// 沒有要寫入的交易：等前面的批次處理完再回覆 (沒有等待中的批次則直接回覆)
+	if len(pending.requests) == 0 {
+		if n := len(l.inflight); n > 0 {
+			last := l.inflight[n-1]
+			last.duplicates = append(last.duplicates, pending.duplicates...)
+			last.queries = append(last.queries, pending.queries...)
+			return
+		}
+		for _, req := range pending.duplicates {
+			l.answerDuplicate(req)
+		}
+		for _, req := range pending.queries {
+			l.answerBalanceQuery(req)
+		}
 		return
 	}
-	// 2. 寫入 WAL Buffer
-	if l.wal != nil {
-		for _, req := range validRequests {
-			if err := l.wal.Write(req.Tx); err != nil {
-				req.Result <- domain.ErrWALWriteFailed
-				break
-			}
-		}
+	// 2. 分配順序號 (送出時推進；WAL 寫入失敗的批次會在順序號中留下空缺)
+	for i, req := range pending.requests {
+		req.Tx.Sequence = l.sequence + uint64(i) + 1
+	}
+	l.sequence += uint64(len(pending.requests))
 
-		// 3. Flush
-		if err := l.wal.Flush(); err != nil {
-			for _, req := range batch {
-				req.Result <- domain.ErrWALWriteFailed
-			}
-			return
-		}
+	// 3. 交給 WAL goroutine
+	l.dispatchBatch(pending)
+}
+
+// answerDuplicate 回覆同一批內重複的交易 (回傳第一筆的處理結果)
+func (l *LMAXLedger) answerDuplicate(req *transactionRequest) {
+	processed, ok := l.processedTransactions[req.Tx.TransactionID]
+	if !ok {
+		// 第一筆未被處理 (WAL 寫入失敗)
+		req.Result <- domain.ErrWALWriteFailed
+		return
 	}
+	req.Result <- processed.Err
+}
 
-	// 4. 執行記憶體邏輯 & 回覆
-	for _, req := range validRequests {
-		l.processTransactionRequest(req)
+// answerBalanceQuery 在事件迴圈中讀取餘額 (或複製帳戶資料) 並回覆 Sentinel 事件
+func (l *LMAXLedger) answerBalanceQuery(req *transactionRequest) {
+	if req.WantSnapshot {
+		snapshot := make(map[int64]*domain.Account, len(l.accounts))
+		for id, account := range l.accounts {
+			copied := *account
+			snapshot[id] = &copied
+		}
+		req.Snapshot = snapshot
+		req.Result <- nil
+		return
+	}
+	if req.AccountIDs != nil {
+		req.Balances = collectBalances(l.accounts, req.AccountIDs)
+		req.Result <- nil
+		return
+	}
+	account, ok := l.accounts[req.AccountID]
+	if !ok {
+		req.Result <- domain.ErrAccountNotFound
+		return
 	}
+	if req.WantAccount {
+		req.Account = *account
+	} else {
+		req.Balance = account.Balance
+	}
+	req.Result <- nil
 }
 
 // processTransactionRequest 處理記憶體邏輯
 func (l *LMAXLedger) processTransactionRequest(req *transactionRequest) {
+	start := time.Now()
 	tran := req.Tx
 
 	// 執行業務邏輯
@@ -300,17 +908,37 @@ func (l *LMAXLedger) processTransactionRequest(req *transactionRequest) {
 		err = l.handleWithdraw(tran)
 	case domain.TransactionTypeTransfer:
 		err = l.handleTransfer(tran)
+	case domain.TransactionTypeFXTransfer:
+		err = l.handleFXTransfer(tran)
+	case domain.TransactionTypeAccountCreation:
+		err = l.handleAccountCreation(tran)
 	default:
 		err = nil
 	}
-	// 更新 Idempotency (加上時間)
 	if err == nil {
-		l.processedTransactions[tran.TransactionID] = time.Now()
+		l.history.Append(tran)
+		recordLastTransaction(l.accounts, tran)
+	}
+	// 更新 Idempotency (加上時間與結果，已寫入 WAL 的交易不論成功與否都記錄)
+	now := time.Now()
+	l.processedTransactions[tran.TransactionID] = processedResult{At: now, Err: err}
+	l.processedSize.Store(int64(len(l.processedTransactions)))
+	l.stats.observe(start, now)
+	l.eventsSinceCleanup++
+	if l.eventsSinceCleanup >= cleanupInterval {
+		l.cleanupProcessedTransactions(transactionRecordWindow)
 	}
 	// 回傳結果
 	req.Result <- err
 }
 
+// handleAccountCreation 建立帳戶並設定初始餘額 (改變 Map 結構，持有 accountsMu 寫鎖)
+func (l *LMAXLedger) handleAccountCreation(tran *domain.Transaction) error {
+	l.accountsMu.Lock()
+	defer l.accountsMu.Unlock()
+	return handleAccountCreation(l.accounts, tran)
+}
+
 func (l *LMAXLedger) handleDeposit(tran *domain.Transaction) error {
 	toAccount, ok := l.accounts[tran.To]
 	if !ok {
@@ -337,11 +965,53 @@ func (l *LMAXLedger) handleTransfer(tran *domain.Transaction) error {
 	if !ok {
 		return domain.ErrAccountNotFound
 	}
+	if fromAccount.CurrencyCode != toAccount.CurrencyCode {
+		return domain.ErrCurrencyMismatch
+	}
 
 	if err := fromAccount.Withdraw(tran.Amount); err != nil {
 		return err
 	}
-	return toAccount.Deposit(tran.Amount)
+	if err := toAccount.Deposit(tran.Amount); err != nil {
+		// 入帳失敗 (如餘額溢位)，退回已扣除的金額
+		atomic.AddInt64(&fromAccount.Balance, tran.Amount)
+		return err
+	}
+	return nil
+}
+
+// handleFXTransfer 處理跨幣別轉帳邏輯 (扣 Amount，入帳 CreditAmount)
+//
+// 參數:
+//
+//	tran: 交易物件
+//
+// 回傳:
+//
+//	error: 處理錯誤 (如餘額不足、匯率錯誤)
+func (l *LMAXLedger) handleFXTransfer(tran *domain.Transaction) error {
+	fromAccount, ok := l.accounts[tran.From]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	toAccount, ok := l.accounts[tran.To]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	credit, err := tran.CreditAmount()
+	if err != nil {
+		return err
+	}
+
+	if err := fromAccount.Withdraw(tran.Amount); err != nil {
+		return err
+	}
+	if err := toAccount.Deposit(credit); err != nil {
+		// 入帳失敗 (如餘額溢位)，退回已扣除的金額
+		atomic.AddInt64(&fromAccount.Balance, tran.Amount)
+		return err
+	}
+	return nil
 }
 
 var _ usecase.Ledger = (*LMAXLedger)(nil)
@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// newStartedLMAXLedger 建立以檔案 WAL 為底的 LMAXLedger 並啟動事件迴圈，測試結束時停止
+func newStartedLMAXLedger(t testing.TB, accounts int64, opts ...LMAXLedgerOption) *LMAXLedger {
+	t.Helper()
+	w, err := wal.NewWALFromFile(filepath.Join(t.TempDir(), "wal.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ledger, err := NewLMAXLedger(ctx, stubLoader{n: accounts}, w, opts...)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	ledger.Start(ctx)
+	t.Cleanup(func() {
+		<-ledger.Stop()
+		cancel()
+		w.Close()
+	})
+	return ledger
+}
+
+// 在 -race 下執行：事件迴圈處理交易時，其他 goroutine 不經過事件迴圈讀取餘額
+func TestLMAXGetAccountBalanceConcurrentWithTransactions(t *testing.T) {
+	ctx := context.Background()
+	ledger := newStartedLMAXLedger(t, 11)
+
+	const readers = 100
+	var wg sync.WaitGroup
+	for g := 0; g < readers; g++ {
+		wg.Add(1)
+		go func(accountID int64) {
+			defer wg.Done()
+			// 讀取次數有上限，避免單核心環境下讀取者佔滿 CPU 讓事件迴圈無法前進
+			for n := 0; n < 100; n++ {
+				if _, err := ledger.GetAccountBalance(ctx, accountID); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := ledger.GetAvailableBalance(ctx, accountID); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(int64(1 + g%10))
+	}
+
+	for i := 0; i < 100; i++ {
+		err := ledger.PostTransaction(ctx, &domain.Transaction{
+			TransactionID: uuid.New(),
+			From:          int64(1 + i%10),
+			To:            int64(1 + (i+1)%10),
+			Amount:        100,
+			Type:          domain.TransactionTypeTransfer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	var total int64
+	for id := int64(1); id <= 10; id++ {
+		balance, err := ledger.GetAccountBalance(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += balance
+	}
+	if total != 10*testInitialBalance {
+		t.Fatalf("total balance = %d, want %d", total, 10*testInitialBalance)
+	}
+}
+
+// 在 -race 下執行：GetAccount 複製整個帳戶 (含 RecordTransaction 寫入的欄位)，不能與存款同時讀寫
+func TestLMAXGetAccountConcurrentWithDeposits(t *testing.T) {
+	ctx := context.Background()
+	ledger := newStartedLMAXLedger(t, 2)
+
+	const deposits = 200
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				account, err := ledger.GetAccount(ctx, 1)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if account.Balance < testInitialBalance || account.Balance > testInitialBalance+deposits*10 {
+					t.Errorf("unexpected balance %d", account.Balance)
+					return
+				}
+			}
+		}()
+	}
+
+	var last uuid.UUID
+	for i := 0; i < deposits; i++ {
+		last = uuid.New()
+		err := ledger.PostTransaction(ctx, &domain.Transaction{
+			TransactionID: last,
+			To:            1,
+			Amount:        10,
+			Type:          domain.TransactionTypeDeposit,
+			CreatedAt:     int64(i + 1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	account, err := ledger.GetAccount(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != testInitialBalance+deposits*10 {
+		t.Fatalf("balance = %d, want %d", account.Balance, testInitialBalance+deposits*10)
+	}
+	if account.LastTransactionID != last || account.LastTransactionAt != deposits || account.LastTransactionType != domain.TransactionTypeDeposit {
+		t.Fatalf("last transaction = (%s, %d, %d), want (%s, %d, deposit)",
+			account.LastTransactionID, account.LastTransactionAt, account.LastTransactionType, last, deposits)
+	}
+	if _, err := ledger.GetAccount(ctx, 99); err != domain.ErrAccountNotFound {
+		t.Fatalf("GetAccount of a missing account error = %v, want ErrAccountNotFound", err)
+	}
+}
+
+// 事件迴圈結束後 (Stop 或 Start 的 ctx 結束)，請求要回傳 ErrLedgerStopped，不能無限期等待回覆
+func TestLMAXRequestsAfterStopReturnError(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(ledger *LMAXLedger, cancel context.CancelFunc)
+	}{
+		{name: "Stop", stop: func(ledger *LMAXLedger, _ context.CancelFunc) { <-ledger.Stop() }},
+		{name: "Start ctx canceled", stop: func(ledger *LMAXLedger, cancel context.CancelFunc) {
+			cancel()
+			<-ledger.done
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ledger, err := NewLMAXLedger(startCtx, stubLoader{n: 3}, wal.NewMemWriter())
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger.Start(startCtx)
+			tt.stop(ledger, cancel)
+
+			ctx := context.Background()
+			requests := map[string]func() error{
+				"PostTransaction": func() error {
+					return ledger.PostTransaction(ctx, &domain.Transaction{
+						TransactionID: uuid.New(),
+						Type:          domain.TransactionTypeDeposit,
+						To:            1,
+						Amount:        100,
+					})
+				},
+				"GetAccount": func() error {
+					_, err := ledger.GetAccount(ctx, 1)
+					return err
+				},
+				"GetAccountBalanceConsistent": func() error {
+					_, err := ledger.GetAccountBalanceConsistent(ctx, 1)
+					return err
+				},
+				"GetMultipleAccountBalances": func() error {
+					_, err := ledger.GetMultipleAccountBalances(ctx, []int64{1, 2})
+					return err
+				},
+				"LoadAllAccounts": func() error {
+					_, err := ledger.LoadAllAccounts(ctx)
+					return err
+				},
+			}
+			for name, request := range requests {
+				errCh := make(chan error, 1)
+				go func() { errCh <- request() }()
+				select {
+				case err := <-errCh:
+					if !errors.Is(err, ErrLedgerStopped) {
+						t.Errorf("%s error = %v, want ErrLedgerStopped", name, err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("%s still waiting after the event loop stopped", name)
+				}
+			}
+
+			// 排入時事件迴圈尚未結束、但已錯過 drain 的請求 (直接放入佇列模擬)
+			req := ledger.getQueryRequest(1)
+			ledger.transactionChan <- req
+			if answered, err := ledger.awaitResult(req); answered || !errors.Is(err, ErrLedgerStopped) {
+				t.Fatalf("awaitResult = %v, %v, want false, ErrLedgerStopped", answered, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// statsRateInterval 計算每秒事件數的頻率
+	statsRateInterval = time.Second
+	// statsRateWindow 每秒事件數的指數加權平均 (EWMA) 時間窗
+	statsRateWindow = 5 * time.Second
+)
+
+// statsRateAlpha 每次更新 EWMA 時新樣本的權重
+var statsRateAlpha = 1 - math.Exp(-statsRateInterval.Seconds()/statsRateWindow.Seconds())
+
+// LMAXStats 事件迴圈的處理統計 (供監控、評估 Channel 容量使用)
+type LMAXStats struct {
+	// EventsProcessed 已套用的交易數 (含業務錯誤)
+	EventsProcessed uint64
+	// EventsPerSecond 最近約 5 秒的每秒處理事件數 (EWMA)
+	EventsPerSecond float64
+	// LastEventAt 最後一筆交易套用的時間 (尚未處理任何交易時為零值)
+	LastEventAt time.Time
+	// AvgProcessingNs 每筆交易在事件迴圈中套用記憶體邏輯的平均耗時 (奈秒，不含 WAL 寫入)
+	AvgProcessingNs int64
+}
+
+// lmaxStats 事件迴圈寫入、其他 goroutine 讀取的統計值
+type lmaxStats struct {
+	eventsProcessed atomic.Uint64
+	processingNs    atomic.Int64
+	lastEventAt     atomic.Int64  // Unix 奈秒
+	rateBits        atomic.Uint64 // EventsPerSecond (math.Float64bits)
+	// 上次計算速率時的事件數 (只有事件迴圈存取)
+	lastRateEvents uint64
+}
+
+// observe 記錄一筆交易的處理耗時 (只能在事件迴圈中呼叫)
+func (s *lmaxStats) observe(start, end time.Time) {
+	s.eventsProcessed.Add(1)
+	s.processingNs.Add(end.Sub(start).Nanoseconds())
+	s.lastEventAt.Store(end.UnixNano())
+}
+
+// updateRate 依距離上次的事件數更新 EWMA (只能在事件迴圈中每 statsRateInterval 呼叫一次)
+func (s *lmaxStats) updateRate() {
+	events := s.eventsProcessed.Load()
+	sample := float64(events-s.lastRateEvents) / statsRateInterval.Seconds()
+	s.lastRateEvents = events
+	rate := math.Float64frombits(s.rateBits.Load())
+	rate += statsRateAlpha * (sample - rate)
+	s.rateBits.Store(math.Float64bits(rate))
+}
+
+// Stats 回傳事件迴圈的處理統計 (可由任意 goroutine 呼叫)
+func (l *LMAXLedger) Stats() LMAXStats {
+	stats := LMAXStats{
+		EventsProcessed: l.stats.eventsProcessed.Load(),
+		EventsPerSecond: math.Float64frombits(l.stats.rateBits.Load()),
+	}
+	if at := l.stats.lastEventAt.Load(); at != 0 {
+		stats.LastEventAt = time.Unix(0, at)
+	}
+	if stats.EventsProcessed > 0 {
+		stats.AvgProcessingNs = l.stats.processingNs.Load() / int64(stats.EventsProcessed)
+	}
+	return stats
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+func TestLMAXStatsCountsEvents(t *testing.T) {
+	ctx := context.Background()
+	ledger := newStartedLMAXLedger(t, 2)
+
+	const deposits = 20
+	for n := 0; n < deposits; n++ {
+		err := ledger.PostTransaction(ctx, &domain.Transaction{
+			TransactionID: uuid.New(),
+			Type:          domain.TransactionTypeDeposit,
+			To:            1,
+			Amount:        100,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := ledger.Stats()
+	if stats.EventsProcessed != deposits {
+		t.Errorf("EventsProcessed = %d, want %d", stats.EventsProcessed, deposits)
+	}
+	if stats.LastEventAt.IsZero() {
+		t.Error("LastEventAt is zero after processing events")
+	}
+}
+
+// BenchmarkLMAXThroughput 多個 goroutine 同時送出轉帳，量測事件迴圈 (含 WAL 寫入) 的吞吐量
+func BenchmarkLMAXThroughput(b *testing.B) {
+	const accounts = 1024
+	ctx := context.Background()
+	ledger := newStartedLMAXLedger(b, accounts)
+
+	var next atomic.Int64
+	// 多個請求同時排隊，事件迴圈才能把它們合併成同一批 WAL 寫入 (與正式環境的多個 RPC 相同)
+	b.SetParallelism(64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := next.Add(1)
+			from := 1 + n%(accounts-1)
+			err := ledger.PostTransaction(ctx, &domain.Transaction{
+				TransactionID: uuid.New(),
+				Type:          domain.TransactionTypeTransfer,
+				From:          from,
+				To:            1 + from%(accounts-1),
+				Amount:        100,
+			})
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "tx/s")
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// WALMode LMAXLedger 寫入 WAL 與套用業務邏輯的先後順序
+type WALMode int
+
+const (
+	// WALModeSync WAL 寫入 (fsync) 完成後才套用記憶體邏輯並回覆 (預設)
+	WALModeSync WALMode = iota
+	// WALModeAsync 先套用記憶體邏輯並回覆，WAL 由獨立 goroutine 在背景寫入
+	// 已回覆成功的交易在寫入前崩潰會遺失；寫入失敗時帳本停止接受新交易 (回傳 domain.ErrWALWriteFailed)，需重啟後從 WAL 恢復
+	WALModeAsync
+	// WALModeDisabled 不讀寫 WAL (純記憶體，重啟後只有初始帳戶資料)
+	WALModeDisabled
+)
+
+// ParseWALMode 解析設定檔中的 WAL 模式 ("sync", "async", "disabled"，空字串為 sync)
+func ParseWALMode(s string) (WALMode, error) {
+	switch s {
+	case "", "sync":
+		return WALModeSync, nil
+	case "async":
+		return WALModeAsync, nil
+	case "disabled":
+		return WALModeDisabled, nil
+	default:
+		return WALModeSync, fmt.Errorf("unknown wal mode %q", s)
+	}
+}
+
+// maxInflightBatches 同時等待 WAL 寫入 (fsync) 的批次上限
+// 達到上限時事件迴圈會先等最舊的批次寫入完成並套用，避免無限制地堆積
+const maxInflightBatches = 4
+
+// walBatch 已分配順序號、交給 WAL goroutine 寫入的批次 (兩階段提交)
+// 1. 事件迴圈分配順序號後送入 walChan，繼續處理下一批
+// 2. WAL goroutine 寫入並 Flush 後送回 walDone，事件迴圈才套用記憶體邏輯並回覆
+type walBatch struct {
+	// 要寫入 WAL 的交易 (WAL goroutine 只讀取)
+	requests []*transactionRequest
+	// WAL 寫入結果 (由 WAL goroutine 設定，送回 walDone 後事件迴圈才讀取)
+	err error
+	// 以下只有事件迴圈存取：等本批套用後才回覆的重複交易與查詢 Sentinel
+	duplicates []*transactionRequest
+	queries    []*transactionRequest
+}
+
+// walLoop WAL goroutine：依序寫入批次並 Flush，完成後送回事件迴圈 (walChan 關閉時結束)
+// 只有一個 WAL goroutine，因此批次完成的順序與送出的順序相同
+func (l *LMAXLedger) walLoop() {
+	for batch := range l.walChan {
+		batch.err = l.writeBatch(batch.requests)
+		l.walDone <- batch
+	}
+}
+
+// writeBatch 將整批交易寫入 WAL Buffer 後 Flush (Group Commit)
+// 失敗時依 retryPolicy 重新寫入整批
+func (l *LMAXLedger) writeBatch(requests []*transactionRequest) error {
+	// 整批寫入不隸屬於單一請求，關機 drain 時也必須寫入，因此不使用請求的 ctx
+	ctx := context.Background()
+	return l.retryPolicy.do(ctx, &l.walWriteRetries, func() error {
+		for _, req := range requests {
+			if err := l.wal.Write(ctx, req.Tx); err != nil {
+				return err
+			}
+		}
+		return l.wal.Flush()
+	})
+}
+
+// asyncWALLoop WALModeAsync 的 WAL goroutine：依序寫入事件迴圈已套用的交易 (asyncWALChan 關閉時結束)
+// 第一次寫入失敗 (重試後) 時通知事件迴圈停止接受交易，之後的批次不再寫入，只消耗 Channel 避免事件迴圈阻塞
+func (l *LMAXLedger) asyncWALLoop() {
+	defer close(l.asyncWALDone)
+	failed := false
+	for trans := range l.asyncWALChan {
+		if failed {
+			continue
+		}
+		if err := l.writeTransactions(trans); err != nil {
+			failed = true
+			l.walErrChan <- err
+		}
+	}
+}
+
+// writeTransactions 將交易複本寫入 WAL Buffer 後 Flush (WALModeAsync 使用，失敗時依 retryPolicy 重試)
+func (l *LMAXLedger) writeTransactions(trans []domain.Transaction) error {
+	ctx := context.Background()
+	return l.retryPolicy.do(ctx, &l.walWriteRetries, func() error {
+		for i := range trans {
+			if err := l.wal.Write(ctx, &trans[i]); err != nil {
+				return err
+			}
+		}
+		return l.wal.Flush()
+	})
+}
+
+// halt WALModeAsync 的 WAL 寫入失敗：之後的交易一律回傳 domain.ErrWALWriteFailed (只能在事件迴圈中呼叫)
+// 記憶體狀態可能包含未寫入 WAL 的交易，重啟後以 WAL 為準
+func (l *LMAXLedger) halt(err error) {
+	l.halted = true
+	slog.Error("lmax ledger halted: async wal write failed", slog.Any("error", err))
+}
+
+// stopWALWriter 關閉 WAL goroutine 的輸入，WALModeAsync 時等待剩餘交易寫入完成 (關機時)
+func (l *LMAXLedger) stopWALWriter() {
+	close(l.walChan)
+	close(l.asyncWALChan)
+	if l.wal != nil && l.walMode == WALModeAsync {
+		<-l.asyncWALDone
+	}
+}
+
+// dispatchBatch 送出批次等待 WAL 寫入 (只能在事件迴圈中呼叫)
+// 沒有 WAL 時直接套用；WALModeAsync 時先複製交易交給 WAL goroutine 再直接套用
+// (套用後請求會被放回 Pool 重用，因此不能把 req.Tx 交給 WAL goroutine)
+func (l *LMAXLedger) dispatchBatch(batch *walBatch) {
+	if l.wal == nil {
+		l.inflight = append(l.inflight, batch)
+		l.completeBatch(batch)
+		return
+	}
+	if l.walMode == WALModeAsync {
+		trans := make([]domain.Transaction, len(batch.requests))
+		for i, req := range batch.requests {
+			trans[i] = *req.Tx
+		}
+		l.asyncWALChan <- trans
+		l.inflight = append(l.inflight, batch)
+		l.completeBatch(batch)
+		return
+	}
+	if len(l.inflight) >= maxInflightBatches {
+		l.completeBatch(<-l.walDone)
+	}
+	for _, req := range batch.requests {
+		l.pendingIDs[req.Tx.TransactionID] = struct{}{}
+	}
+	l.inflight = append(l.inflight, batch)
+	l.walChan <- batch
+}
+
+// completeBatch 套用 WAL 寫入完成的批次並回覆 (只能在事件迴圈中呼叫)
+// batch 必定是 inflight 中最舊的批次
+func (l *LMAXLedger) completeBatch(batch *walBatch) {
+	l.inflight[0] = nil
+	l.inflight = l.inflight[1:]
+	for _, req := range batch.requests {
+		delete(l.pendingIDs, req.Tx.TransactionID)
+	}
+
+	if batch.err != nil {
+		// 整批視為失敗，每個請求只回覆一次 (已分配的順序號不再使用)
+		for _, req := range batch.requests {
+			req.Result <- domain.ErrWALWriteFailed
+		}
+	} else {
+		for _, req := range batch.requests {
+			l.processTransactionRequest(req)
+		}
+	}
+	for _, req := range batch.duplicates {
+		l.answerDuplicate(req)
+	}
+	for _, req := range batch.queries {
+		l.answerBalanceQuery(req)
+	}
+}
+
+// waitInflight 等待所有送出的批次寫入完成並套用 (關機時)
+func (l *LMAXLedger) waitInflight() {
+	for len(l.inflight) > 0 {
+		l.completeBatch(<-l.walDone)
+	}
+}
+
+// isPending 交易是否已送出、正在等待 WAL 寫入
+func (l *LMAXLedger) isPending(id uuid.UUID) bool {
+	_, ok := l.pendingIDs[id]
+	return ok
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// WALModeAsync 先回覆再寫 WAL，正常關機 (Stop 後 Close) 時仍要把已回覆的交易全部寫入
+func TestWALModeAsyncRecoverableAfterCleanShutdown(t *testing.T) {
+	const accounts = 11
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wal.log")
+	w, err := wal.NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := NewLMAXLedger(ctx, stubLoader{n: accounts}, w, WithLMAXWALMode(WALModeAsync))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.Start(ctx)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				tx := &domain.Transaction{
+					TransactionID: uuid.New(),
+					Type:          domain.TransactionTypeTransfer,
+					From:          int64(1 + g),
+					To:            int64(1 + (g+n%9+1)%10),
+					Amount:        int64(100 * (n + 1)),
+				}
+				if err := ledger.PostTransaction(ctx, tx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	want, err := ledger.LoadAllAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ledger.Stop()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// 重新開啟 WAL，以同樣的初始帳戶重放
+	w, err = wal.NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	recovered, err := NewLMAXLedger(ctx, stubLoader{n: accounts}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id < accounts; id++ {
+		got, err := recovered.GetAccountBalance(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want[id].Balance {
+			t.Errorf("account %d recovered balance %d, want %d", id, got, want[id].Balance)
+		}
+	}
+}
@@ -2,8 +2,9 @@ package memory
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,38 +18,110 @@ import (
 //
 // 結構:
 //
-//	accounts: 帳戶資料 Map
-//	mu: Mutex 用於保護帳戶資料
+//	accounts: 帳戶資料 Map (新增/刪除 key 需持有所有分片的寫鎖)
+//	locks: 依帳戶 ID 分片的讀寫鎖 (stripedMutex)，保護各分片帳戶的餘額
+//	processedMu: Mutex 用於保護 processedTransactions
 //	processedTransactions: 已處理過的交易 Map
-//	wal: Write-Ahead Log 實例
+//	walMu: Mutex 確保順序號分配與 WAL 寫入順序一致
+//	wal: Write-Ahead Log 實例 (交易事件)
+//	accountWAL: 帳戶事件 (建立/凍結/刪除) 的 WAL，可獨立於交易 WAL 截斷
+//	sequence: 最後一筆寫入 WAL 的交易順序號
+//	history: 最近成功的交易紀錄 (nil 表示未啟用，見 WithHistoryCapacity)
+//	replayAfterSequence: 啟動時只重放此順序號之後的 WAL (檢查點模式)
+//	checkpointSequence: 最後一次寫入檢查點的順序號
 type MutexLedger struct {
 	accounts map[int64]*domain.Account
-	mu       sync.RWMutex
+	locks    stripedMutex
 	// 已處理過的交易
-	processedTransactions map[uuid.UUID]time.Time
+	processedMu           sync.Mutex
+	processedTransactions map[uuid.UUID]processedResult
 	// Write-Ahead Logging
-	wal *wal.WAL
+	walMu sync.Mutex
+	wal   wal.Writer
+	// 帳戶事件 WAL (寫入時持有所有分片的寫鎖)
+	accountWAL wal.Writer
+	// 最後一筆寫入 WAL 的交易順序號
+	sequence uint64
+	// 最近成功的交易紀錄 (nil 表示未啟用)
+	history *transactionHistory
+	// 檢查點 (見 StartCheckpointing)
+	replayAfterSequence uint64
+	checkpointSequence  atomic.Uint64
+	// 啟動時遇到損毀 WAL 紀錄的處理方式
+	recoveryMode RecoveryMode
+	// WAL 寫入失敗時的重試策略與累計重試次數
+	retryPolicy     RetryPolicy
+	walWriteRetries atomic.Uint64
+}
+
+// MutexLedgerOption 定義了 MutexLedger 的配置選項函數
+type MutexLedgerOption func(*MutexLedger)
+
+// WithRecoveryMode 設定啟動時遇到損毀 WAL 紀錄的處理方式 (預設 RecoveryModeStrict)
+func WithRecoveryMode(mode RecoveryMode) MutexLedgerOption {
+	return func(ledger *MutexLedger) {
+		ledger.recoveryMode = mode
+	}
+}
+
+// WithReplayAfterSequence 啟動時只重放順序號大於 sequence 的 WAL 紀錄 (檢查點模式)
+// 初始帳戶資料 (loader) 必須是該檢查點當下的餘額
+func WithReplayAfterSequence(sequence uint64) MutexLedgerOption {
+	return func(ledger *MutexLedger) {
+		ledger.replayAfterSequence = sequence
+	}
+}
+
+// WithRetryPolicy 設定 WAL 寫入失敗時的重試策略 (預設不重試)
+// 重試期間持有 walMu，其他交易的 WAL 寫入會一起等待
+func WithRetryPolicy(policy RetryPolicy) MutexLedgerOption {
+	return func(ledger *MutexLedger) {
+		ledger.retryPolicy = policy
+	}
+}
+
+// WithHistoryCapacity 保存最近 capacity 筆成功的交易供 GetTransactionHistory 查詢 (所有帳戶合計，預設不保存)
+// 每筆約 96 bytes (交易複本加上環狀緩衝區的帳戶 ID)，轉帳在雙方各算一筆
+func WithHistoryCapacity(capacity int) MutexLedgerOption {
+	return func(ledger *MutexLedger) {
+		ledger.history = newTransactionHistory(capacity)
+	}
 }
 
 // NewMutexLedger 建立一個新的 MutexLedger 實例
 //
 // 參數:
 //
-//	accounts: 初始帳戶資料 Map
-//	wal: Write-Ahead Log 實例
+//	ctx: 上下文
+//	loader: 初始帳戶資料來源 (分頁載入)
+//	accountWAL: 帳戶事件 WAL (可為 nil)
+//	wal: Write-Ahead Log 實作 (正式環境為 *wal.WAL)
+//	opts: 可選的配置選項
 //
 // 回傳:
 //
 //	*MutexLedger: MutexLedger 實例
-//	error: 初始化錯誤 (如 WAL 恢復失敗)
-func NewMutexLedger(accounts map[int64]*domain.Account, wal *wal.WAL) (*MutexLedger, error) {
+//	error: 初始化錯誤 (如帳戶載入或 WAL 恢復失敗)
+func NewMutexLedger(ctx context.Context, loader usecase.AccountLoader, accountWAL, wal wal.Writer, opts ...MutexLedgerOption) (*MutexLedger, error) {
+	accounts, err := loadAccounts(ctx, loader)
+	if err != nil {
+		return nil, err
+	}
+	// 先以帳戶 WAL 重建帳戶 Map，再重放交易 WAL
+	if err := recoverAccountWAL(ctx, accounts, accountWAL); err != nil {
+		return nil, err
+	}
 	ledger := &MutexLedger{
 		accounts:              accounts,
-		mu:                    sync.RWMutex{},
-		processedTransactions: make(map[uuid.UUID]time.Time),
+		processedTransactions: make(map[uuid.UUID]processedResult),
 		wal:                   wal,
+		accountWAL:            accountWAL,
+	}
+	for _, opt := range opts {
+		opt(ledger)
 	}
-	err := ledger.recoverFromWAL()
+	ledger.checkpointSequence.Store(ledger.replayAfterSequence)
+	err = ledger.recoverFromWAL(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -57,35 +130,31 @@ func NewMutexLedger(accounts map[int64]*domain.Account, wal *wal.WAL) (*MutexLed
 
 // recoverFromWAL 從 WAL 檔案恢復帳本狀態
 //
+// 參數:
+//
+//	ctx: 上下文
+//
 // 回傳:
 //
 //	error: 恢復過程錯誤
-func (m *MutexLedger) recoverFromWAL() error {
-	tranHistory := make([]domain.Transaction, 0)
-
-	err := m.wal.ReadAll(func(jsonRaw []byte) error {
-		var tran domain.Transaction
-		if err := json.Unmarshal(jsonRaw, &tran); err != nil {
-			return err
-		}
-		tranHistory = append(tranHistory, tran)
-		return nil
-	})
-	if err != nil {
-		return err
-	}
+func (m *MutexLedger) recoverFromWAL(ctx context.Context) error {
 	now := time.Now()
-	for _, tran := range tranHistory {
-		if err := m.applyRecoverTransaction(&tran, now); err != nil {
-			return err
-		}
-	}
-	return nil
+	return replayWALTransactions(ctx, m.wal, m.recoveryMode, func(tran *domain.Transaction) {
+		m.applyRecoverTransaction(tran, now)
+	})
 }
 
 // applyRecoverTransaction 恢復單筆交易至記憶體 (不寫入 WAL)
 // 只有 NewMutexLedger 呼叫，無需 Lock (單執行緒)
-func (m *MutexLedger) applyRecoverTransaction(tran *domain.Transaction, now time.Time) error {
+func (m *MutexLedger) applyRecoverTransaction(tran *domain.Transaction, now time.Time) {
+	if tran.Sequence != 0 && tran.Sequence <= m.replayAfterSequence {
+		// 已包含在檢查點的餘額中，不重複套用；原始結果未知，重複提交時回傳已處理
+		m.processedTransactions[tran.TransactionID] = processedResult{At: now, Err: domain.ErrTransactionAlreadyProcessed}
+		if tran.Sequence > m.sequence {
+			m.sequence = tran.Sequence
+		}
+		return
+	}
 	var err error
 	switch tran.Type {
 	case domain.TransactionTypeDeposit:
@@ -94,12 +163,21 @@ func (m *MutexLedger) applyRecoverTransaction(tran *domain.Transaction, now time
 		err = m.handleWithdraw(tran)
 	case domain.TransactionTypeTransfer:
 		err = m.handleTransfer(tran)
+	case domain.TransactionTypeFXTransfer:
+		err = m.handleFXTransfer(tran)
+	case domain.TransactionTypeAccountCreation:
+		err = handleAccountCreation(m.accounts, tran)
 	}
 
+	// 重放時的業務錯誤 (如餘額不足) 與原始處理結果相同，記錄下來供冪等性檢查回傳
+	m.processedTransactions[tran.TransactionID] = processedResult{At: now, Err: err}
 	if err == nil {
-		m.processedTransactions[tran.TransactionID] = now
+		m.history.Append(tran)
+		recordLastTransaction(m.accounts, tran)
+	}
+	if tran.Sequence > m.sequence {
+		m.sequence = tran.Sequence
 	}
-	return err
 }
 
 // GetAccountBalance 取得指定帳戶的當前餘額
@@ -114,8 +192,7 @@ func (m *MutexLedger) applyRecoverTransaction(tran *domain.Transaction, now time
 //	int64: 帳戶餘額
 //	error: 查詢錯誤 (如帳戶不存在)
 func (m *MutexLedger) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	defer m.locks.RLockAccount(accountID)()
 	account, ok := m.accounts[accountID]
 	if !ok {
 		return 0, domain.ErrAccountNotFound
@@ -123,7 +200,82 @@ func (m *MutexLedger) GetAccountBalance(ctx context.Context, accountID int64) (i
 	return account.Balance, nil
 }
 
-// LoadAllAccounts 載入系統所有帳戶資料 (Level 1 實作直接回傳當前 Map)
+// GetMultipleAccountBalances 一次取得多個帳戶的餘額 (同一時間點的一致快照)
+// 同時持有所有相關分片的讀鎖，讀取期間這些帳戶不會有進行中的交易
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountIDs: 帳戶 ID
+//
+// 回傳:
+//
+//	map[int64]int64: 帳戶 ID 對應的餘額 (不存在的帳戶不會出現在結果中)
+//	error: 查詢錯誤
+func (m *MutexLedger) GetMultipleAccountBalances(ctx context.Context, accountIDs []int64) (map[int64]int64, error) {
+	defer m.locks.RLockAccounts(accountIDs)()
+	return collectBalances(m.accounts, accountIDs), nil
+}
+
+// GetAccount 取得指定帳戶資料的複本
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	domain.Account: 帳戶資料複本
+//	error: 查詢錯誤 (如帳戶不存在)
+func (m *MutexLedger) GetAccount(ctx context.Context, accountID int64) (domain.Account, error) {
+	defer m.locks.RLockAccount(accountID)()
+	account, ok := m.accounts[accountID]
+	if !ok {
+		return domain.Account{}, domain.ErrAccountNotFound
+	}
+	return *account, nil
+}
+
+// GetAvailableBalance 取得指定帳戶的可用餘額 (Balance - Reserved)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	int64: 可用餘額
+//	error: 查詢錯誤 (如帳戶不存在)
+func (m *MutexLedger) GetAvailableBalance(ctx context.Context, accountID int64) (int64, error) {
+	defer m.locks.RLockAccount(accountID)()
+	account, ok := m.accounts[accountID]
+	if !ok {
+		return 0, domain.ErrAccountNotFound
+	}
+	return account.AvailableBalance(), nil
+}
+
+// GetTransactionHistory 取得帳戶的交易紀錄 (以 Sequence 為游標分頁)
+// 記憶體帳本只保留最近 WithHistoryCapacity 筆成功的交易 (所有帳戶合計)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	accountID: 帳戶 ID
+//	afterSequence: 游標 (只回傳 Sequence 大於此值的交易，第一頁帶 0)
+//	limit: 最多回傳筆數
+//
+// 回傳:
+//
+//	[]*domain.Transaction: 依 Sequence 遞增排序的交易
+//	error: 未啟用時回傳 ErrHistoryDisabled
+func (m *MutexLedger) GetTransactionHistory(ctx context.Context, accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error) {
+	return m.history.Get(accountID, afterSequence, limit)
+}
+
+// LoadAllAccounts 載入系統所有帳戶資料 (回傳深拷貝，避免呼叫端與交易處理同時存取內部 Map)
 //
 // 參數:
 //
@@ -134,10 +286,116 @@ func (m *MutexLedger) GetAccountBalance(ctx context.Context, accountID int64) (i
 //	map[int64]*domain.Account: 帳戶 ID 對應的 Domain Account 物件
 //	error: 查詢錯誤
 func (m *MutexLedger) LoadAllAccounts(ctx context.Context) (map[int64]*domain.Account, error) {
-	return m.accounts, nil
+	accounts, _ := m.Snapshot()
+	return accounts, nil
+}
+
+// Snapshot 取得帳戶資料的一致性快照
+//
+// 回傳:
+//
+//	map[int64]*domain.Account: 帳戶資料深拷貝
+//	uint64: 快照當下最後一筆寫入 WAL 的交易順序號
+func (m *MutexLedger) Snapshot() (map[int64]*domain.Account, uint64) {
+	// 依序鎖住所有分片，確保快照期間沒有進行中的交易
+	defer m.locks.RLockAll()()
+
+	accounts := make(map[int64]*domain.Account, len(m.accounts))
+	for id, account := range m.accounts {
+		copied := *account
+		accounts[id] = &copied
+	}
+
+	m.walMu.Lock()
+	sequence := m.sequence
+	m.walMu.Unlock()
+	return accounts, sequence
+}
+
+// CreateAccountWithInitialBalance 建立帳戶並設定初始餘額
+// 以單一筆 domain.TransactionTypeAccountCreation 交易寫入交易 WAL，不會只建立帳戶而沒有初始餘額
+//
+// 參數:
+//
+//	ctx: 上下文
+//	account: 新帳戶 (使用 ID 與 CurrencyCode)
+//	initialAmount: 初始餘額 (可為 0)
+//	refID: 外部追蹤號 (冪等性)
+//
+// 回傳:
+//
+//	error: 帳戶已存在 (domain.ErrAccountAlreadyExists)、參數錯誤或 WAL 寫入錯誤
+func (m *MutexLedger) CreateAccountWithInitialBalance(ctx context.Context, account *domain.Account, initialAmount int64, refID uuid.UUID) error {
+	tran := domain.NewAccountCreation(account, initialAmount, refID, time.Now().UnixMilli())
+	if err := tran.Validate(); err != nil {
+		return err
+	}
+	return m.PostTransaction(ctx, tran)
+}
+
+// ApplyAccountEvent 寫入帳戶事件 (建立/凍結/刪除) 至帳戶 WAL 並套用
+// 新增/刪除帳戶會改變 Map 結構，因此持有所有分片的寫鎖
+//
+// 參數:
+//
+//	ctx: 上下文
+//	event: 帳戶事件
+//
+// 回傳:
+//
+//	error: 帳戶已存在 / 不存在，或 WAL 寫入錯誤
+func (m *MutexLedger) ApplyAccountEvent(ctx context.Context, event *domain.AccountEvent) error {
+	defer m.locks.LockAll()()
+
+	// 先驗證，避免寫入一筆無法套用的事件
+	if err := validateAccountEvent(m.accounts, event); err != nil {
+		return err
+	}
+	if m.accountWAL != nil {
+		if err := m.accountWAL.Write(ctx, event); err != nil {
+			return domain.ErrWALWriteFailed
+		}
+		if err := m.accountWAL.Flush(); err != nil {
+			return domain.ErrWALWriteFailed
+		}
+	}
+	return applyAccountEvent(m.accounts, event)
+}
+
+// ExportToMySQL 將記憶體中的帳戶餘額同步至 MySQL (Level 1 -> Level 0 遷移用)
+// 應在維護時段、伺服器暫停接收交易時執行，匯出後會逐一核對雙方餘額
+//
+// 參數:
+//
+//	ctx: 上下文
+//	store: 目標儲存 (*mysql.MySQLLedger)
+//
+// 回傳:
+//
+//	error: 寫入錯誤，或餘額核對不一致 (domain.ErrBalanceMismatch)
+func (m *MutexLedger) ExportToMySQL(ctx context.Context, store usecase.AccountSyncer) error {
+	accounts, _ := m.Snapshot()
+	for _, account := range accounts {
+		if err := store.UpsertAccount(ctx, account); err != nil {
+			return err
+		}
+	}
+
+	// 核對餘額一致性
+	for id, account := range accounts {
+		balance, err := store.GetAccountBalance(ctx, id)
+		if err != nil {
+			return err
+		}
+		if balance != account.Balance {
+			return fmt.Errorf("%w: account %d memory=%d mysql=%d", domain.ErrBalanceMismatch, id, account.Balance, balance)
+		}
+	}
+	return nil
 }
 
 // PostTransaction 處理交易請求 (Level 1: Mutex Lock)
+// 只鎖定交易涉及帳戶所在的分片，不同分片的交易可以並行處理
 //
 // 參數:
 //
@@ -148,37 +406,39 @@ func (m *MutexLedger) LoadAllAccounts(ctx context.Context) (map[int64]*domain.Ac
 //
 //	error: 處理錯誤
 func (m *MutexLedger) PostTransaction(ctx context.Context, tran *domain.Transaction) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.postTransactionInternal(tran)
+	var unlock func()
+	if tran.Type == domain.TransactionTypeAccountCreation {
+		// 新增帳戶會改變 Map 結構，需持有所有分片的寫鎖
+		unlock = m.locks.LockAll()
+	} else {
+		unlock = m.locks.LockAccounts(tran.GetLockIDs())
+	}
+	defer unlock()
+	return m.postTransactionInternal(ctx, tran)
 }
 
 // postTransactionInternal 執行交易核心邏輯 (內部方法)
 //
 // 參數:
 //
+//	ctx: 上下文
 //	tran: 交易物件
 //
 // 回傳:
 //
 //	error: 處理錯誤
-func (m *MutexLedger) postTransactionInternal(tran *domain.Transaction) error {
-	_, ok := m.processedTransactions[tran.TransactionID]
+func (m *MutexLedger) postTransactionInternal(ctx context.Context, tran *domain.Transaction) error {
+	m.processedMu.Lock()
+	processed, ok := m.processedTransactions[tran.TransactionID]
+	m.processedMu.Unlock()
 	if ok {
-		return nil
+		// 重複的交易回傳原始處理結果
+		return processed.Err
 	}
 
 	// 1. 寫入 WAL (Critical Path)
-	if m.wal != nil {
-		// 寫入記憶體
-		if err := m.wal.Write(tran); err != nil {
-			return domain.ErrWALWriteFailed
-		}
-
-		// 刷入硬碟
-		if err := m.wal.Flush(); err != nil {
-			return domain.ErrWALWriteFailed
-		}
+	if err := m.writeWAL(ctx, tran); err != nil {
+		return err
 	}
 
 	// 2. 核心交易分發
@@ -190,16 +450,66 @@ func (m *MutexLedger) postTransactionInternal(tran *domain.Transaction) error {
 		err = m.handleWithdraw(tran)
 	case domain.TransactionTypeTransfer:
 		err = m.handleTransfer(tran)
+	case domain.TransactionTypeFXTransfer:
+		err = m.handleFXTransfer(tran)
+	case domain.TransactionTypeAccountCreation:
+		err = handleAccountCreation(m.accounts, tran)
 	default:
 		return nil // Unknown type, ignore or error
 	}
 
 	if err == nil {
-		m.processedTransactions[tran.TransactionID] = time.Now()
+		// 仍持有帳戶分片鎖，同一帳戶的紀錄依 Sequence 順序寫入
+		m.history.Append(tran)
+		recordLastTransaction(m.accounts, tran)
 	}
+
+	// 不論成功或業務錯誤都記錄結果 (交易已寫入 WAL)
+	m.processedMu.Lock()
+	m.processedTransactions[tran.TransactionID] = processedResult{At: time.Now(), Err: err}
+	m.processedMu.Unlock()
 	return err
 }
 
+// writeWAL 分配全局順序號並寫入 WAL
+// 持有 walMu 確保 WAL 中的順序與順序號一致，WAL 寫入成功後才正式推進順序號
+//
+// 參數:
+//
+//	ctx: 上下文
+//	tran: 交易物件
+//
+// 回傳:
+//
+//	error: WAL 寫入錯誤
+func (m *MutexLedger) writeWAL(ctx context.Context, tran *domain.Transaction) error {
+	m.walMu.Lock()
+	defer m.walMu.Unlock()
+
+	tran.Sequence = m.sequence + 1
+	if m.wal != nil {
+		err := m.retryPolicy.do(ctx, &m.walWriteRetries, func() error {
+			// 寫入記憶體
+			if err := m.wal.Write(ctx, tran); err != nil {
+				return err
+			}
+			// 刷入硬碟
+			return m.wal.Flush()
+		})
+		if err != nil {
+			return domain.ErrWALWriteFailed
+		}
+	}
+	m.sequence = tran.Sequence
+	return nil
+}
+
+// WALWriteRetries 回傳啟動以來 WAL 寫入的累計重試次數
+// 持續增加但沒有 ErrWALWriteFailed 表示暫時性錯誤；兩者一起增加表示磁碟可能已無法寫入
+func (m *MutexLedger) WALWriteRetries() uint64 {
+	return m.walWriteRetries.Load()
+}
+
 // handleDeposit 處理存款邏輯
 //
 // 參數:
@@ -253,11 +563,53 @@ func (m *MutexLedger) handleTransfer(tran *domain.Transaction) error {
 	if !ok {
 		return domain.ErrAccountNotFound
 	}
+	if fromAccount.CurrencyCode != toAccount.CurrencyCode {
+		return domain.ErrCurrencyMismatch
+	}
 
 	if err := fromAccount.Withdraw(tran.Amount); err != nil {
 		return err
 	}
-	return toAccount.Deposit(tran.Amount)
+	if err := toAccount.Deposit(tran.Amount); err != nil {
+		// 入帳失敗 (如餘額溢位)，退回已扣除的金額
+		atomic.AddInt64(&fromAccount.Balance, tran.Amount)
+		return err
+	}
+	return nil
+}
+
+// handleFXTransfer 處理跨幣別轉帳邏輯 (扣 Amount，入帳 CreditAmount)
+//
+// 參數:
+//
+//	tran: 交易物件
+//
+// 回傳:
+//
+//	error: 處理錯誤 (如餘額不足、匯率錯誤)
+func (m *MutexLedger) handleFXTransfer(tran *domain.Transaction) error {
+	fromAccount, ok := m.accounts[tran.From]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	toAccount, ok := m.accounts[tran.To]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	credit, err := tran.CreditAmount()
+	if err != nil {
+		return err
+	}
+
+	if err := fromAccount.Withdraw(tran.Amount); err != nil {
+		return err
+	}
+	if err := toAccount.Deposit(credit); err != nil {
+		// 入帳失敗 (如餘額溢位)，退回已扣除的金額
+		atomic.AddInt64(&fromAccount.Balance, tran.Amount)
+		return err
+	}
+	return nil
 }
 
 var _ usecase.Ledger = (*MutexLedger)(nil)
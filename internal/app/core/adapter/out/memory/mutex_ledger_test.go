@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// newMutexLedger 建立以 MemWriter 為 WAL 的 MutexLedger
+func newMutexLedger(t testing.TB, accounts int64, opts ...MutexLedgerOption) *MutexLedger {
+	t.Helper()
+	ledger, err := NewMutexLedger(context.Background(), stubLoader{n: accounts}, nil, wal.NewMemWriter(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ledger
+}
+
+// 在 -race 下執行：GetAccount 複製的 LastTransaction* 欄位由 RecordTransaction 在分片寫鎖下寫入
+func TestMutexGetAccountConcurrentWithDeposits(t *testing.T) {
+	ctx := context.Background()
+	ledger := newMutexLedger(t, 2)
+
+	const deposits = 200
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				if _, err := ledger.GetAccount(ctx, 1); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	var last uuid.UUID
+	for i := 0; i < deposits; i++ {
+		last = uuid.New()
+		err := ledger.PostTransaction(ctx, &domain.Transaction{
+			TransactionID: last,
+			To:            1,
+			Amount:        10,
+			Type:          domain.TransactionTypeDeposit,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	account, err := ledger.GetAccount(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != testInitialBalance+deposits*10 {
+		t.Fatalf("balance = %d, want %d", account.Balance, testInitialBalance+deposits*10)
+	}
+	if account.LastTransactionID != last || account.LastTransactionType != domain.TransactionTypeDeposit {
+		t.Fatalf("last transaction = (%s, %d), want (%s, deposit)", account.LastTransactionID, account.LastTransactionType, last)
+	}
+}
+
+// 在 -race 下執行：LoadAllAccounts 回傳深拷貝，呼叫端走訪結果時 PostTransaction 可同時修改帳本
+func TestMutexLoadAllAccountsConcurrentWithTransactions(t *testing.T) {
+	const accounts = 11
+	ctx := context.Background()
+	ledger := newMutexLedger(t, accounts)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(accountID int64) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				all, err := ledger.LoadAllAccounts(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// 快照期間沒有進行中的轉帳，總額不變
+				var total int64
+				for _, account := range all {
+					total += account.Balance
+				}
+				if total != accounts*testInitialBalance {
+					t.Errorf("snapshot total = %d, want %d", total, accounts*testInitialBalance)
+					return
+				}
+				if _, err := ledger.GetAccountBalance(ctx, accountID); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(int64(1 + g))
+	}
+
+	for n := 0; n < 200; n++ {
+		err := ledger.PostTransaction(ctx, &domain.Transaction{
+			TransactionID: uuid.New(),
+			Type:          domain.TransactionTypeTransfer,
+			From:          int64(1 + n%10),
+			To:            int64(1 + (n+1)%10),
+			Amount:        100,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// maxRetryDelay 單次重試等待時間上限
+const maxRetryDelay = 5 * time.Second
+
+// RetryPolicy WAL 寫入失敗時的重試策略 (指數退避)
+// 零值表示不重試，第一次失敗就回傳 domain.ErrWALWriteFailed
+//
+// 結構:
+//
+//	MaxRetries: 失敗後最多重試次數
+//	BaseDelay: 第一次重試前的等待時間，之後每次加倍 (上限 maxRetryDelay)
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+}
+
+// delay 第 attempt 次重試 (從 0 開始) 前的等待時間
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 0; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// do 執行 fn，失敗時依策略重試
+// 每次重試都會累加 retries；ctx 結束時停止重試並回傳最後一次的錯誤
+//
+// 參數:
+//
+//	ctx: 上下文
+//	retries: 重試次數計數器 (供 WALWriteRetries 回報)
+//	fn: 要執行的寫入，必須可安全重複執行 (wal.WAL 失敗時會捨棄未 Flush 的資料)
+//
+// 回傳:
+//
+//	error: 最後一次執行的錯誤
+func (p RetryPolicy) do(ctx context.Context, retries *atomic.Uint64, fn func() error) error {
+	err := fn()
+	for attempt := 0; err != nil && attempt < p.MaxRetries; attempt++ {
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		retries.Add(1)
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+var errDiskBusy = errors.New("disk busy")
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 10, BaseDelay: time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: 2 * time.Second},
+		{attempt: 2, want: 4 * time.Second},
+		{attempt: 3, want: maxRetryDelay},
+		{attempt: 100, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := p.delay(tt.attempt); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      RetryPolicy
+		failures    int
+		wantErr     error
+		wantCalls   int
+		wantRetries uint64
+	}{
+		{name: "零值不重試", policy: RetryPolicy{}, failures: 1, wantErr: errDiskBusy, wantCalls: 1},
+		{name: "重試後成功", policy: RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}, failures: 2, wantCalls: 3, wantRetries: 2},
+		{name: "超過重試次數", policy: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}, failures: 5, wantErr: errDiskBusy, wantCalls: 3, wantRetries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries atomic.Uint64
+			calls := 0
+			err := tt.policy.do(context.Background(), &retries, func() error {
+				calls++
+				if calls <= tt.failures {
+					return errDiskBusy
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || retries.Load() != tt.wantRetries {
+				t.Fatalf("calls = %d, retries = %d; want %d, %d", calls, retries.Load(), tt.wantCalls, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoStopsOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var retries atomic.Uint64
+	calls := 0
+	err := RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour}.do(ctx, &retries, func() error {
+		calls++
+		return errDiskBusy
+	})
+	if !errors.Is(err, errDiskBusy) || calls != 1 || retries.Load() != 0 {
+		t.Fatalf("do() = %v after %d calls and %d retries, want errDiskBusy after 1 call", err, calls, retries.Load())
+	}
+}
+
+// flakyWriter 前 failures 次 Write 回傳錯誤的 WAL
+type flakyWriter struct {
+	*wal.MemWriter
+	failures atomic.Int32
+}
+
+func (w *flakyWriter) Write(ctx context.Context, v any) error {
+	if w.failures.Add(-1) >= 0 {
+		return errDiskBusy
+	}
+	return w.MemWriter.Write(ctx, v)
+}
+
+func TestMutexLedgerRetriesTransientWALFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int32
+		wantErr     error
+		wantRetries uint64
+		wantEntries int
+	}{
+		{name: "暫時性錯誤重試後成功", failures: 2, wantRetries: 2, wantEntries: 1},
+		{name: "持續失敗回傳 ErrWALWriteFailed", failures: 10, wantErr: domain.ErrWALWriteFailed, wantRetries: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			w := &flakyWriter{MemWriter: wal.NewMemWriter()}
+			ledger, err := NewMutexLedger(ctx, stubLoader{n: 2}, nil, w,
+				WithRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.failures.Store(tt.failures)
+
+			err = ledger.PostTransaction(ctx, &domain.Transaction{
+				TransactionID: uuid.New(),
+				Type:          domain.TransactionTypeDeposit,
+				To:            1,
+				Amount:        100,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PostTransaction error = %v, want %v", err, tt.wantErr)
+			}
+			if got := ledger.WALWriteRetries(); got != tt.wantRetries {
+				t.Fatalf("WALWriteRetries = %d, want %d", got, tt.wantRetries)
+			}
+			if got := len(w.Entries()); got != tt.wantEntries {
+				t.Fatalf("WAL entries = %d, want %d", got, tt.wantEntries)
+			}
+		})
+	}
+}
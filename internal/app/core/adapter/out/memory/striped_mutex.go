@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"slices"
+	"sync"
+)
+
+// stripeCount 帳戶鎖分片數量 (2 的次方，以 accountID & stripeMask 取分片，負數 ID 也會落在範圍內)
+const (
+	stripeCount = 128
+	stripeMask  = stripeCount - 1
+)
+
+// stripedMutex 依帳戶 ID 分片的讀寫鎖
+// 不同分片的帳戶可以並行處理；同時鎖定多個分片時一律依索引遞增順序加鎖，避免死鎖
+type stripedMutex struct {
+	stripes [stripeCount]sync.RWMutex
+}
+
+// stripeIndex 取得帳戶所屬的分片
+func stripeIndex(accountID int64) int {
+	return int(accountID & stripeMask)
+}
+
+// RLockAccount 鎖定帳戶所在分片的讀鎖，回傳解鎖函數
+func (s *stripedMutex) RLockAccount(accountID int64) (unlock func()) {
+	stripe := &s.stripes[stripeIndex(accountID)]
+	stripe.RLock()
+	return stripe.RUnlock
+}
+
+// RLockAccounts 依序鎖定帳戶所在分片的讀鎖 (已排序且去重)，回傳解鎖函數
+// 持有期間這些帳戶不會有進行中的交易，可一次讀到同一時間點的多個帳戶
+func (s *stripedMutex) RLockAccounts(accountIDs []int64) (unlock func()) {
+	indexes := stripeIndexes(accountIDs)
+	for _, idx := range indexes {
+		s.stripes[idx].RLock()
+	}
+	return func() {
+		for _, idx := range indexes {
+			s.stripes[idx].RUnlock()
+		}
+	}
+}
+
+// LockAccounts 依序鎖定帳戶所在分片的寫鎖 (已排序且去重)，回傳解鎖函數
+//
+// 參數:
+//
+//	accountIDs: 交易涉及的帳戶 ID
+//
+// 回傳:
+//
+//	func(): 解鎖函數
+func (s *stripedMutex) LockAccounts(accountIDs []int64) (unlock func()) {
+	indexes := stripeIndexes(accountIDs)
+	for _, idx := range indexes {
+		s.stripes[idx].Lock()
+	}
+	return func() {
+		for _, idx := range indexes {
+			s.stripes[idx].Unlock()
+		}
+	}
+}
+
+// stripeIndexes 將帳戶 ID 轉為分片索引 (已排序且去重，避免死鎖與重複加鎖)
+func stripeIndexes(accountIDs []int64) []int {
+	indexes := make([]int, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		indexes = append(indexes, stripeIndex(id))
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes)
+}
+
+// LockAll 依序鎖定所有分片的寫鎖 (改變帳戶 Map 結構時使用)，回傳解鎖函數
+func (s *stripedMutex) LockAll() (unlock func()) {
+	for i := range s.stripes {
+		s.stripes[i].Lock()
+	}
+	return func() {
+		for i := range s.stripes {
+			s.stripes[i].Unlock()
+		}
+	}
+}
+
+// RLockAll 依序鎖定所有分片的讀鎖 (快照時使用，期間沒有進行中的交易)，回傳解鎖函數
+func (s *stripedMutex) RLockAll() (unlock func()) {
+	for i := range s.stripes {
+		s.stripes[i].RLock()
+	}
+	return func() {
+		for i := range s.stripes {
+			s.stripes[i].RUnlock()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// legacyTransaction 舊版 WAL (wal.FormatVersionLegacy) 的交易格式，欄位使用完整名稱
+// TODO: 過渡期 (一個版本) 結束後移除
+type legacyTransaction struct {
+	Sequence      uint64
+	From          int64
+	To            int64
+	Amount        int64
+	CreatedAt     int64
+	TransactionID uuid.UUID
+	Type          domain.TransactionType
+}
+
+// decodeWALTransaction 將一筆 WAL 交易紀錄解析至 tran (供 wal.TypedReader 使用)
+// 舊格式檔案 (沒有 Header) 在升級後會混有新舊兩種紀錄，因此逐筆判斷格式
+//
+// 參數:
+//
+//	jsonRaw: WAL 紀錄
+//	tran: 解析結果 (需為零值)
+//
+// 回傳:
+//
+//	error: 解析錯誤
+func decodeWALTransaction(jsonRaw []byte, tran *domain.Transaction) error {
+	if err := json.Unmarshal(jsonRaw, tran); err != nil {
+		return err
+	}
+	if tran.TransactionID != uuid.Nil {
+		return nil
+	}
+
+	// 縮寫欄位解析不到交易 ID，視為舊格式
+	var legacy legacyTransaction
+	if err := json.Unmarshal(jsonRaw, &legacy); err != nil {
+		return err
+	}
+	// 舊格式不包含之後新增的欄位 (如 ExchangeRate)，逐欄轉換
+	*tran = domain.Transaction{
+		Sequence:      legacy.Sequence,
+		From:          legacy.From,
+		To:            legacy.To,
+		Amount:        legacy.Amount,
+		CreatedAt:     legacy.CreatedAt,
+		TransactionID: legacy.TransactionID,
+		Type:          legacy.Type,
+	}
+	return nil
+}
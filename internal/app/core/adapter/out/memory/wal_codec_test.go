@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// legacyRecord 舊版 WAL 實際寫入的內容 (完整欄位名稱，交易類型為數字)
+type legacyRecord struct {
+	Sequence      uint64
+	From          int64
+	To            int64
+	Amount        int64
+	CreatedAt     int64
+	TransactionID uuid.UUID
+	Type          uint8
+}
+
+func toLegacyRecord(tran *domain.Transaction) legacyRecord {
+	return legacyRecord{
+		Sequence:      tran.Sequence,
+		From:          tran.From,
+		To:            tran.To,
+		Amount:        tran.Amount,
+		CreatedAt:     tran.CreatedAt,
+		TransactionID: tran.TransactionID,
+		Type:          uint8(tran.Type),
+	}
+}
+
+// sampleTransfer 一般大小的轉帳交易 (帳戶 ID 與金額為正式環境常見的位數)
+func sampleTransfer(sequence uint64) *domain.Transaction {
+	return &domain.Transaction{
+		Sequence:      sequence,
+		From:          1_000_000 + int64(sequence%1000),
+		To:            2_000_000 + int64(sequence%1000),
+		Amount:        12_345,
+		CreatedAt:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli() + int64(sequence),
+		TransactionID: uuid.New(),
+		Type:          domain.TransactionTypeTransfer,
+	}
+}
+
+// 舊格式與縮寫格式的紀錄都要解析成同一筆交易
+func TestDecodeWALTransactionFormats(t *testing.T) {
+	want := sampleTransfer(42)
+	ctx := context.Background()
+	w := wal.NewMemWriter()
+	if err := w.Write(ctx, toLegacyRecord(want)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded int
+	err := w.ReadAll(ctx, func(jsonRaw []byte) error {
+		var got domain.Transaction
+		if err := decodeWALTransaction(jsonRaw, &got); err != nil {
+			return err
+		}
+		if got != *want {
+			t.Errorf("decoded %s as %+v, want %+v", jsonRaw, got, *want)
+		}
+		decoded++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != 2 {
+		t.Fatalf("decoded %d records, want 2", decoded)
+	}
+}
+
+// BenchmarkWALEntrySize 以舊格式與縮寫格式寫入 WAL 檔案，回報每筆紀錄佔用的位元組數 (B/entry)
+func BenchmarkWALEntrySize(b *testing.B) {
+	b.Run("legacy", func(b *testing.B) {
+		benchmarkWALEntrySize(b, func(tran *domain.Transaction) any { return toLegacyRecord(tran) })
+	})
+	b.Run("compact", func(b *testing.B) {
+		benchmarkWALEntrySize(b, func(tran *domain.Transaction) any { return tran })
+	})
+}
+
+func benchmarkWALEntrySize(b *testing.B, record func(tran *domain.Transaction) any) {
+	ctx := context.Background()
+	path := filepath.Join(b.TempDir(), "wal.log")
+	w, err := wal.NewWALFromFile(path, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer w.Close()
+	// 第一次寫入會先寫 Header，不計入每筆紀錄的大小
+	if err := w.Write(ctx, record(sampleTransfer(0))); err != nil {
+		b.Fatal(err)
+	}
+	before := walFileSize(b, w, path)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.Write(ctx, record(sampleTransfer(uint64(i+1)))); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(walFileSize(b, w, path)-before)/float64(b.N), "B/entry")
+}
+
+// walFileSize Flush 後回傳 WAL 檔案大小
+func walFileSize(b *testing.B, w *wal.WAL, path string) int64 {
+	b.Helper()
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return info.Size()
+}
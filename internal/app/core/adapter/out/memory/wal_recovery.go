@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// RecoveryMode 啟動時遇到損毀 WAL 紀錄的處理方式
+type RecoveryMode int
+
+const (
+	// RecoveryModeStrict 任何錯誤都中止啟動 (預設)
+	RecoveryModeStrict RecoveryMode = iota
+	// RecoveryModeLenient 略過無法解析的紀錄並記錄 Log，尾端的損毀資料會被截斷
+	RecoveryModeLenient
+	// RecoveryModeLastGood 停在第一個錯誤，只套用之前的紀錄並回報最後一筆成功的順序號，WAL 截斷到該錯誤之前
+	RecoveryModeLastGood
+)
+
+// ParseRecoveryMode 解析設定檔中的恢復模式 ("strict", "lenient", "last_good"，空字串為 strict)
+func ParseRecoveryMode(s string) (RecoveryMode, error) {
+	switch s {
+	case "", "strict":
+		return RecoveryModeStrict, nil
+	case "lenient":
+		return RecoveryModeLenient, nil
+	case "last_good":
+		return RecoveryModeLastGood, nil
+	default:
+		return RecoveryModeStrict, fmt.Errorf("unknown wal recovery mode %q", s)
+	}
+}
+
+// errStopRecovery RecoveryModeLastGood 遇到錯誤時用來停止讀取
+var errStopRecovery = errors.New("stop wal recovery")
+
+// errCorruptEntry 無法解析為 JSON 的 WAL 紀錄
+var errCorruptEntry = errors.New("corrupt wal entry")
+
+// repairableWAL 可另外開啟唯讀 Handle 以換行切分讀取、並截斷尾端的 WAL (如 *wal.WAL)
+// 非 Strict 的恢復模式需要它：損毀的資料必須在接受寫入前移除，否則之後的寫入會接在損毀資料後面
+type repairableWAL interface {
+	NewReader() (*wal.WALReader, error)
+	TruncateTail(offset int64) error
+}
+
+// replayWALTransactions 依恢復模式讀取並解析 WAL 中的所有交易，依 WAL 順序逐筆交給 apply
+// Strict 模式以 wal.TypedReader 解析 (交易物件重用)，不會先把整個 WAL 的交易載入記憶體，任何錯誤都中止啟動
+// Lenient / LastGood 模式以換行切分讀取 (見 replayWALLines)，並在回傳前截斷損毀的資料
+//
+// 參數:
+//
+//	ctx: 上下文
+//	w: 交易 WAL (nil 時不恢復)
+//	mode: 恢復模式
+//	apply: 套用單筆交易 (回傳後 tran 會被重用，需保留時請複製)
+//
+// 回傳:
+//
+//	error: 讀取錯誤 (Strict 模式)、無法截斷損毀資料的錯誤或 ctx 錯誤
+func replayWALTransactions(ctx context.Context, w wal.Writer, mode RecoveryMode, apply func(tran *domain.Transaction)) error {
+	if w == nil {
+		return nil
+	}
+	if mode == RecoveryModeStrict {
+		reader := wal.NewTypedReader(w, decodeWALTransaction, wal.WithReadProgress(logRecoveryProgress))
+		return reader.Read(ctx, func(tran *domain.Transaction) error {
+			apply(tran)
+			return nil
+		})
+	}
+	rw, ok := w.(repairableWAL)
+	if !ok {
+		return fmt.Errorf("wal recovery mode %d requires a file-backed wal", mode)
+	}
+	return replayWALLines(ctx, rw, mode, apply)
+}
+
+// replayWALLines 以換行切分讀取 WAL (wal.WALReader.ReadAllLenientWithOffset)，一筆損毀紀錄不會影響之後的紀錄
+//   - RecoveryModeLenient: 略過無法解析的紀錄並記錄 Log；尾端連續的損毀紀錄 (如寫入一半的最後一筆) 會被截斷，
+//     中間的損毀紀錄保留在檔案中 (以換行分隔，不影響之後的寫入)，可用 cmd/wal_repair 產生乾淨的檔案
+//   - RecoveryModeLastGood: 停在第一筆損毀紀錄，將 WAL 截斷到該紀錄之前 (之後的紀錄一併捨棄)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	w: 交易 WAL
+//	mode: RecoveryModeLenient 或 RecoveryModeLastGood
+//	apply: 套用單筆交易 (回傳後 tran 會被重用，需保留時請複製)
+//
+// 回傳:
+//
+//	error: 讀取、截斷或 ctx 錯誤
+func replayWALLines(ctx context.Context, w repairableWAL, mode RecoveryMode, apply func(tran *domain.Transaction)) error {
+	reader, err := w.NewReader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var (
+		recovered, skipped int
+		lastGood           uint64
+		// 需截斷的位置：LastGood 為第一筆損毀紀錄，Lenient 為最後一筆可解析紀錄之後的第一筆損毀紀錄 (-1 表示不需截斷)
+		truncateAt int64 = -1
+	)
+	skip := func(offset int64, err error) error {
+		if mode == RecoveryModeLastGood {
+			truncateAt = offset
+			return errors.Join(errStopRecovery, err)
+		}
+		skipped++
+		if truncateAt < 0 {
+			truncateAt = offset
+		}
+		slog.Warn("skip undecodable wal entry",
+			slog.Int64("offset", offset),
+			slog.Any("error", err),
+		)
+		return nil
+	}
+
+	tran := new(domain.Transaction)
+	err = reader.ReadAllLenientWithOffset(ctx,
+		func(jsonRaw []byte, offset int64) error {
+			*tran = domain.Transaction{}
+			if err := decodeWALTransaction(jsonRaw, tran); err != nil {
+				return skip(offset, err)
+			}
+			apply(tran)
+			recovered++
+			lastGood = tran.Sequence
+			// 之後的損毀紀錄才算尾端
+			truncateAt = -1
+			return nil
+		},
+		func(entry wal.SkippedEntry) error {
+			return skip(entry.Offset, errCorruptEntry)
+		},
+	)
+	if err != nil && !errors.Is(err, errStopRecovery) {
+		return err
+	}
+	if truncateAt >= 0 {
+		if err := w.TruncateTail(truncateAt); err != nil {
+			return fmt.Errorf("truncate corrupted wal tail at offset %d: %w", truncateAt, err)
+		}
+	}
+	if truncateAt >= 0 || skipped > 0 {
+		slog.Error("wal recovery dropped corrupted entries",
+			slog.Int64("truncated_at", truncateAt),
+			slog.Uint64("last_good_sequence", lastGood),
+			slog.Int("recovered", recovered),
+			slog.Int("skipped", skipped),
+			slog.Any("error", err),
+		)
+	}
+	return nil
+}
+
+// logRecoveryProgress 以 slog 記錄恢復進度，方便觀察大型 WAL 的恢復狀況 (WAL 需支援 wal.ProgressReader)
+func logRecoveryProgress(bytesRead, totalBytes int64) {
+	percent := 100.0
+	if totalBytes > 0 {
+		percent = float64(bytesRead) * 100 / float64(totalBytes)
+	}
+	slog.Info("wal recovery progress",
+		slog.Int64("bytes_read", bytesRead),
+		slog.Int64("total_bytes", totalBytes),
+		slog.Float64("percent", percent),
+	)
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/wal"
+)
+
+// newCorruptedWAL 建立含 3 筆存款 (帳戶 1，每筆 5) 的 WAL，之後接上一筆無法解析為交易的紀錄、
+// 一筆完整的存款 (金額 1) 以及只寫入一部分的最後一筆紀錄
+func newCorruptedWAL(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wal.log")
+	w, err := wal.NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := NewMutexLedger(ctx, stubLoader{n: 3}, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		postDeposit(t, ledger, 1, 5)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tail := "\"not a transaction\"\n" +
+		`{"seq":5,"to":1,"amt":1,"tid":"` + uuid.NewString() + `","tp":1}` + "\n" +
+		`{"seq":6,"to":1,"am`
+	if _, err := f.WriteString(tail); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func postDeposit(t *testing.T, ledger *MutexLedger, accountID, amount int64) {
+	t.Helper()
+	err := ledger.PostTransaction(context.Background(), &domain.Transaction{
+		TransactionID: uuid.New(),
+		To:            accountID,
+		Amount:        amount,
+		Type:          domain.TransactionTypeDeposit,
+	})
+	if err != nil {
+		t.Fatalf("PostTransaction: %v", err)
+	}
+}
+
+// reopen 以指定的恢復模式重新開啟 WAL 與帳本
+func reopen(t *testing.T, path string, mode RecoveryMode) (*MutexLedger, *wal.WAL, error) {
+	t.Helper()
+	w, err := wal.NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := NewMutexLedger(context.Background(), stubLoader{n: 3}, nil, w, WithRecoveryMode(mode))
+	if err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+	return ledger, w, nil
+}
+
+func balanceOf(t *testing.T, ledger *MutexLedger, accountID int64) int64 {
+	t.Helper()
+	balance, err := ledger.GetAccountBalance(context.Background(), accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestReplayWALTransactionsRecoveryModes(t *testing.T) {
+	tests := []struct {
+		name string
+		mode RecoveryMode
+		// 恢復後帳戶 1 增加的金額
+		wantRecovered int64
+		// 截斷後以 Strict 模式是否能重新開啟
+		strictAfter bool
+	}{
+		{name: "lenient 略過損毀紀錄", mode: RecoveryModeLenient, wantRecovered: 16, strictAfter: false},
+		{name: "last_good 截斷到第一筆損毀紀錄", mode: RecoveryModeLastGood, wantRecovered: 15, strictAfter: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := newCorruptedWAL(t)
+			if _, _, err := reopen(t, path, RecoveryModeStrict); err == nil {
+				t.Fatal("strict recovery of a corrupted wal should fail")
+			}
+
+			ledger, w, err := reopen(t, path, tt.mode)
+			if err != nil {
+				t.Fatalf("recover: %v", err)
+			}
+			if got := balanceOf(t, ledger, 1); got != testInitialBalance+tt.wantRecovered {
+				t.Fatalf("balance after recovery = %d, want %d", got, testInitialBalance+tt.wantRecovered)
+			}
+			// 恢復後的寫入不能接在損毀資料後面
+			postDeposit(t, ledger, 1, 100)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			ledger, w, err = reopen(t, path, tt.mode)
+			if err != nil {
+				t.Fatalf("recover after write: %v", err)
+			}
+			defer w.Close()
+			if got := balanceOf(t, ledger, 1); got != testInitialBalance+tt.wantRecovered+100 {
+				t.Fatalf("balance after second recovery = %d, want %d", got, testInitialBalance+tt.wantRecovered+100)
+			}
+
+			_, strictWAL, err := reopen(t, path, RecoveryModeStrict)
+			if (err == nil) != tt.strictAfter {
+				t.Fatalf("strict recovery after repair error = %v, want success %v", err, tt.strictAfter)
+			}
+			if strictWAL != nil {
+				strictWAL.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cachedBalance 快取的餘額與到期時間
+type cachedBalance struct {
+	balance   int64
+	expiresAt int64 // UnixNano
+}
+
+// balanceCache GetAccountBalance 的讀取快取 (見 WithBalanceCache)
+// 寫入成功後一律移除相關帳戶 (不更新)，下次讀取再向 MySQL 取得最新餘額
+//
+// 結構:
+//
+//	ttl: 快取存活時間
+//	entries: 帳戶 ID 對應的 cachedBalance
+//	generation: 每次移除快取時遞增；查詢期間有移除發生時不寫入快取，避免把查詢到的舊餘額放回去
+//	hits / misses: 命中統計 (見 CacheHitRate)
+type balanceCache struct {
+	ttl        time.Duration
+	entries    sync.Map // map[int64]cachedBalance
+	generation atomic.Uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+func newBalanceCache(ttl time.Duration) *balanceCache {
+	return &balanceCache{ttl: ttl}
+}
+
+// get 讀取未過期的快取，未命中時回傳目前的 generation 供 put 使用
+func (c *balanceCache) get(accountID int64) (balance int64, generation uint64, ok bool) {
+	generation = c.generation.Load()
+	if v, found := c.entries.Load(accountID); found {
+		entry := v.(cachedBalance)
+		if time.Now().UnixNano() < entry.expiresAt {
+			c.hits.Add(1)
+			return entry.balance, generation, true
+		}
+		c.entries.CompareAndDelete(accountID, entry)
+	}
+	c.misses.Add(1)
+	return 0, generation, false
+}
+
+// put 寫入查詢結果 (generation 為查詢前 get 回傳的值；期間有移除發生則放棄寫入)
+func (c *balanceCache) put(accountID int64, balance int64, generation uint64) {
+	c.entries.Store(accountID, cachedBalance{
+		balance:   balance,
+		expiresAt: time.Now().Add(c.ttl).UnixNano(),
+	})
+	if c.generation.Load() != generation {
+		// 查詢與寫入之間有交易完成，查詢到的可能是舊餘額
+		c.entries.Delete(accountID)
+	}
+}
+
+// evict 移除帳戶的快取 (寫入成功後呼叫)
+func (c *balanceCache) evict(accountIDs ...int64) {
+	c.generation.Add(1)
+	for _, id := range accountIDs {
+		c.entries.Delete(id)
+	}
+}
+
+// evictAll 清空快取 (批次寫入帳戶後呼叫)
+func (c *balanceCache) evictAll() {
+	c.generation.Add(1)
+	c.entries.Clear()
+}
+
+// hitRate 命中率 (0 ~ 1，沒有任何查詢時為 0)
+func (c *balanceCache) hitRate() float64 {
+	hits := c.hits.Load()
+	total := hits + c.misses.Load()
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// ErrBalanceStorageMismatch users.balance 的欄位型別與 WithDecimalStorage 設定不一致
+// 不一致時寫入的餘額會差 CurrencyScale 倍，因此 Init 直接拒絕啟動
+var ErrBalanceStorageMismatch = errors.New("mysql ledger balance column type mismatch")
+
+// decimalScaleDigits DECIMAL(20,4) 的小數位數，必須與 domain.CurrencyScale 一致
+const decimalScaleDigits = 4
+
+// domain.CurrencyScale 改變時需同步調整 decimalScaleDigits 與 DECIMAL 的小數位數 (不相等時無法編譯)
+var _ = [1]struct{}{}[domain.CurrencyScale-10000]
+
+// decimalBalanceColumn WithDecimalStorage 模式讀取餘額的欄位：在 SQL 中轉回放大 CurrencyScale 倍的整數，
+// 讀取端 (sqlUser.Balance) 不需要區分儲存模式
+var decimalBalanceColumn = fmt.Sprintf("CAST(balance * %d AS SIGNED) AS balance", domain.CurrencyScale)
+
+// sqlDecimalUser WithDecimalStorage 模式寫入 users 表使用的結構
+// balance 以十進位字串傳給 MySQL 轉換為 DECIMAL，不經過浮點數
+type sqlDecimalUser struct {
+	ID        int64  `gorm:"primaryKey"`
+	Balance   string `gorm:"type:decimal(20,4);not null;default:0"`
+	Currency  string `gorm:"type:varchar(8);not null;default:''"`
+	UpdatedAt int64  `gorm:"autoUpdateTime:milli"`
+}
+
+func (*sqlDecimalUser) TableName() string {
+	return (*sqlUser)(nil).TableName()
+}
+
+// formatDecimalBalance 將放大 CurrencyScale 倍的整數轉為十進位字串 (例如 12345 -> "1.2345", -5 -> "-0.0005")
+func formatDecimalBalance(balance int64) string {
+	sign := ""
+	// 以 uint64 取絕對值，math.MinInt64 也不會溢位
+	abs := uint64(balance)
+	if balance < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	frac := strconv.FormatUint(abs%domain.CurrencyScale, 10)
+	return sign + strconv.FormatUint(abs/domain.CurrencyScale, 10) + "." +
+		strings.Repeat("0", decimalScaleDigits-len(frac)) + frac
+}
+
+// balanceColumn 讀取餘額時 SELECT 的欄位
+func (ledger *MySQLLedger) balanceColumn() string {
+	if ledger.decimalStorage {
+		return decimalBalanceColumn
+	}
+	return "balance"
+}
+
+// userColumns 讀取完整 sqlUser 時 SELECT 的欄位
+func (ledger *MySQLLedger) userColumns() []string {
+	return []string{"id", ledger.balanceColumn(), "currency", "updated_at"}
+}
+
+// balanceValue 寫入 balance 欄位的值 (int64 -> DECIMAL 時轉為十進位字串)
+func (ledger *MySQLLedger) balanceValue(balance int64) any {
+	if ledger.decimalStorage {
+		return formatDecimalBalance(balance)
+	}
+	return balance
+}
+
+// insertUsers 批次新增使用者 (呼叫端可先加上 OnConflict 等 Clause)
+//
+// 參數:
+//
+//	db: GORM 查詢 (可為資料庫事務)
+//	users: 待新增的使用者
+//	batchSize: 每批筆數
+//
+// 回傳:
+//
+//	*gorm.DB: 執行結果 (Error / RowsAffected)
+func (ledger *MySQLLedger) insertUsers(db *gorm.DB, users []sqlUser, batchSize int) *gorm.DB {
+	if !ledger.decimalStorage {
+		return db.CreateInBatches(&users, batchSize)
+	}
+	rows := make([]sqlDecimalUser, len(users))
+	for i := range users {
+		rows[i] = sqlDecimalUser{
+			ID:       users[i].ID,
+			Balance:  formatDecimalBalance(users[i].Balance),
+			Currency: users[i].Currency,
+		}
+	}
+	return db.CreateInBatches(&rows, batchSize)
+}
+
+// checkBalanceStorage 確認 users.balance 的欄位型別與 WithDecimalStorage 設定一致
+func (ledger *MySQLLedger) checkBalanceStorage(ctx context.Context) error {
+	var dataType string
+	err := ledger.client.DB().WithContext(ctx).
+		Raw("SELECT DATA_TYPE FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = 'balance'",
+			(*sqlUser)(nil).TableName()).
+		Scan(&dataType).Error
+	if err != nil {
+		return fmt.Errorf("check mysql ledger balance column: %w", err)
+	}
+	isDecimal := strings.EqualFold(dataType, "decimal")
+	if isDecimal != ledger.decimalStorage {
+		return fmt.Errorf("%w: users.balance is %s, decimal storage %t (see scripts/mysql/migrations/003_balance_decimal.sql)",
+			ErrBalanceStorageMismatch, dataType, ledger.decimalStorage)
+	}
+	return nil
+}
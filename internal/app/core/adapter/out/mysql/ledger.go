@@ -2,35 +2,58 @@ package mysql
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+	"github.com/JoeShih716/go-mem-ledger/pkg/circuitbreaker"
 	"github.com/JoeShih716/go-mem-ledger/pkg/mysql"
 )
 
+// ErrSchemaMissing 資料庫缺少 MySQLLedger 需要的資料表 (見 scripts/mysql/01_schema.sql)
+var ErrSchemaMissing = errors.New("mysql ledger schema missing")
+
 // sqlUser 對應資料庫的 users 表
 type sqlUser struct {
 	ID        int64 `gorm:"primaryKey"`
 	Balance   int64
-	UpdatedAt int64 `gorm:"autoUpdateTime:milli"` // 自動更新時間
+	Currency  string `gorm:"type:varchar(8);not null;default:''"` // 幣別 (空字串代表單一幣別模式)
+	UpdatedAt int64  `gorm:"autoUpdateTime:milli"`                // 自動更新時間
+}
+
+// toDomain 轉換為 Domain Account
+func (u *sqlUser) toDomain() *domain.Account {
+	account := domain.NewAccount(u.ID, u.Balance)
+	account.CurrencyCode = u.Currency
+	return account
 }
 
-// Deposit 存款
+// Deposit 存款 (與 domain.Account.Deposit 相同的金額不變量)
 func (u *sqlUser) Deposit(amount int64) error {
-	if amount < 0 {
-		return domain.ErrAmountMustBePositive
+	if err := domain.ValidateAmount(amount); err != nil {
+		return err
+	}
+	if u.Balance > math.MaxInt64-amount {
+		return domain.ErrBalanceOverflow
 	}
 	u.Balance += amount
 	return nil
 }
 
-// Withdraw 提款
+// Withdraw 提款 (與 domain.Account.Withdraw 相同的金額不變量)
 func (u *sqlUser) Withdraw(amount int64) error {
-	if amount < 0 {
-		return domain.ErrAmountMustBePositive
+	if err := domain.ValidateAmount(amount); err != nil {
+		return err
 	}
 	if u.Balance < amount {
 		return domain.ErrInsufficientBalance
@@ -46,21 +69,111 @@ func (*sqlUser) TableName() string {
 // sqlTransaction 對應資料庫的 transactions 表
 type sqlTransaction struct {
 	ID            int64  `gorm:"primaryKey;autoIncrement"`
-	RefID         []byte `gorm:"column:ref_id;type:binary(16);uniqueIndex"` // 對應 domain.TransactionID
+	RefID         string `gorm:"column:ref_id;type:varchar(36);uniqueIndex"` // 對應 domain.TransactionID (標準 UUID 字串)
 	Sequence      uint64 `gorm:"index"`
 	FromAccountID int64
 	ToAccountID   int64
 	Amount        int64
+	ExchangeRate  int64 // 匯率 (僅跨幣別轉帳)
 	Type          uint8
-	CreatedAt     int64 `gorm:"autoCreateTime:milli"` // 自動寫入時間
+	CreatedAt     int64 `gorm:"autoCreateTime:milli"` // 交易時間 (未指定時自動寫入)
 }
 
 func (*sqlTransaction) TableName() string {
 	return "transactions"
 }
 
+// toDomain 轉換為 Domain Transaction
+// MySQL 帳本以 id (AUTO_INCREMENT) 作為交易的順序號 (見 GetTransactionHistory)
+func (t *sqlTransaction) toDomain() *domain.Transaction {
+	tran := &domain.Transaction{
+		Sequence:     uint64(t.ID),
+		From:         t.FromAccountID,
+		To:           t.ToAccountID,
+		Amount:       t.Amount,
+		ExchangeRate: t.ExchangeRate,
+		CreatedAt:    t.CreatedAt,
+		Type:         domain.TransactionType(t.Type),
+	}
+	// 無法解析時保留 uuid.Nil (資料應已由 migrations/002_ref_id_varchar.sql 轉換)
+	if refID, err := uuid.Parse(t.RefID); err == nil {
+		tran.TransactionID = refID
+	}
+	return tran
+}
+
+// sqlCheckpoint 對應資料庫的 ledger_checkpoints 表 (只有一筆 ID = checkpointRowID 的紀錄)
+type sqlCheckpoint struct {
+	ID        int64 `gorm:"primaryKey"`
+	Sequence  uint64
+	UpdatedAt int64 `gorm:"autoUpdateTime:milli"` // 自動更新時間
+}
+
+func (*sqlCheckpoint) TableName() string {
+	return "ledger_checkpoints"
+}
+
+const (
+	// checkpointRowID 檢查點紀錄的固定 ID
+	checkpointRowID = 1
+	// checkpointBatchSize 寫入檢查點時每批 Upsert 的帳戶數
+	checkpointBatchSize = 1000
+)
+
+// purgeBatchSize 清理交易紀錄時每次 DELETE 的筆數，避免長時間鎖表
+const purgeBatchSize = 1000
+
 type MySQLLedger struct {
 	client *mysql.Client
+	// MySQL 暫時無法使用時快速失敗，避免 goroutine 卡在 TCP Timeout
+	breaker *circuitbreaker.CircuitBreaker
+	// SAVEPOINT 名稱的遞增編號 (見 PostTransactionWithSavepoint)
+	savepointSeq atomic.Uint64
+	// GetAccountBalance 的讀取快取 (nil 表示不快取，見 WithBalanceCache)
+	balanceCache *balanceCache
+	// 超過此耗時的交易記錄各階段耗時 (0 表示不記錄，見 WithSlowTransactionThreshold)
+	slowTransactionThreshold time.Duration
+	slowTransactions         atomic.Uint64
+	// users.balance 為 DECIMAL(20,4) (見 WithDecimalStorage)
+	decimalStorage bool
+}
+
+// MySQLLedgerOption 定義了 MySQLLedger 的配置選項函數
+type MySQLLedgerOption func(*MySQLLedger)
+
+// WithCircuitBreaker 替換預設的斷路器
+func WithCircuitBreaker(breaker *circuitbreaker.CircuitBreaker) MySQLLedgerOption {
+	return func(ledger *MySQLLedger) {
+		ledger.breaker = breaker
+	}
+}
+
+// WithBalanceCache 啟用 GetAccountBalance 的讀取快取 (存活時間 ttl)
+// 適合大量輪詢餘額的場景 (如儀表板)，減少對 MySQL 的讀取壓力；
+// 本實例寫入成功後會立即移除相關帳戶的快取，但其他實例 (或直接修改資料庫) 的寫入最多延遲 ttl 才會反映
+func WithBalanceCache(ttl time.Duration) MySQLLedgerOption {
+	return func(ledger *MySQLLedger) {
+		if ttl > 0 {
+			ledger.balanceCache = newBalanceCache(ttl)
+		}
+	}
+}
+
+// WithSlowTransactionThreshold 交易總耗時超過 threshold 時以 slog.Warn 記錄各階段耗時 (phase_breakdown)
+// 並累加 SlowTransactions，用於找出時間花在冪等性檢查、鎖等待、寫入或 Commit；0 表示不記錄
+func WithSlowTransactionThreshold(threshold time.Duration) MySQLLedgerOption {
+	return func(ledger *MySQLLedger) {
+		ledger.slowTransactionThreshold = threshold
+	}
+}
+
+// WithDecimalStorage users.balance 以 DECIMAL(20,4) 儲存實際金額 (需先執行 scripts/mysql/migrations/003_balance_decimal.sql)
+// 記憶體中仍是放大 CurrencyScale 倍的 int64，讀取時在 SQL 轉回整數、寫入時轉為十進位字串；
+// 欄位本身保證最多 4 位小數，SQL 可直接 SUM() 得到正確的十進位結果，代價是每次讀寫多一次轉換 (預估約 15% 額外負擔)
+func WithDecimalStorage() MySQLLedgerOption {
+	return func(ledger *MySQLLedger) {
+		ledger.decimalStorage = true
+	}
 }
 
 // NewMySQLLedger 建立一個新的 MySQLLedger 實例
@@ -68,14 +181,95 @@ type MySQLLedger struct {
 // 參數:
 //
 //	client: MySQL 客戶端連線
+//	opts: 可選的配置選項
 //
 // 回傳:
 //
 //	*MySQLLedger: MySQLLedger 實例
-func NewMySQLLedger(client *mysql.Client) *MySQLLedger {
-	return &MySQLLedger{
+func NewMySQLLedger(client *mysql.Client, opts ...MySQLLedgerOption) *MySQLLedger {
+	ledger := &MySQLLedger{
 		client: client,
+		breaker: circuitbreaker.New(circuitbreaker.Config{
+			IsFailure: isInfrastructureError,
+		}),
+	}
+	for _, opt := range opts {
+		opt(ledger)
+	}
+	return ledger
+}
+
+// Init 確認資料庫已建立 MySQLLedger 需要的資料表 (不會自動建立)，且 users.balance 的型別與 WithDecimalStorage 一致
+// 建構時不連線資料庫，啟動流程應在載入帳戶前呼叫，避免缺少資料表時到處理請求才失敗
+//
+// 參數:
+//
+//	ctx: 上下文
+//
+// 回傳:
+//
+//	error: 缺少資料表時回傳 ErrSchemaMissing (訊息包含缺少的資料表名稱)，
+//	       餘額欄位型別不一致時回傳 ErrBalanceStorageMismatch，或查詢錯誤
+func (ledger *MySQLLedger) Init(ctx context.Context) error {
+	required := []string{
+		(*sqlUser)(nil).TableName(),
+		(*sqlTransaction)(nil).TableName(),
+		(*sqlCheckpoint)(nil).TableName(),
+	}
+	var existing []string
+	err := ledger.client.DB().WithContext(ctx).
+		Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name IN ?", required).
+		Scan(&existing).Error
+	if err != nil {
+		return fmt.Errorf("check mysql ledger schema: %w", err)
 	}
+
+	var missing []string
+	for _, table := range required {
+		found := false
+		for _, name := range existing {
+			if strings.EqualFold(name, table) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaMissing, strings.Join(missing, ", "))
+	}
+	return ledger.checkBalanceStorage(ctx)
+}
+
+// isInfrastructureError 判斷錯誤是否來自資料庫本身 (業務錯誤不觸發斷路器)
+func isInfrastructureError(err error) bool {
+	switch {
+	case errors.Is(err, domain.ErrInsufficientBalance),
+		errors.Is(err, domain.ErrAccountNotFound),
+		errors.Is(err, domain.ErrAmountMustBePositive),
+		errors.Is(err, domain.ErrAmountPrecisionExceeded),
+		errors.Is(err, domain.ErrInvalidTransactionType),
+		errors.Is(err, domain.ErrInvalidAccountID),
+		errors.Is(err, domain.ErrSystemAccountNotAllowed),
+		errors.Is(err, domain.ErrAccountAlreadyExists),
+		errors.Is(err, domain.ErrSameAccountTransfer),
+		errors.Is(err, domain.ErrMissingTransactionID),
+		errors.Is(err, domain.ErrBalanceOverflow),
+		errors.Is(err, domain.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrInvalidExchangeRate),
+		errors.Is(err, gorm.ErrRecordNotFound),
+		errors.Is(err, context.Canceled): // 呼叫端放棄請求，不代表資料庫異常
+		return false
+	default:
+		return true
+	}
+}
+
+// CircuitState 回傳斷路器目前狀態 ("closed", "open", "half-open")，供 Readiness Probe 使用
+func (ledger *MySQLLedger) CircuitState() string {
+	return ledger.breaker.State().String()
 }
 
 // PostTransaction 處理交易請求 (Level 0: MySQL Transaction)
@@ -87,38 +281,201 @@ func NewMySQLLedger(client *mysql.Client) *MySQLLedger {
 //
 // 回傳:
 //
-//	error: 處理錯誤，若成功則為 nil
+//	error: 處理錯誤，若成功則為 nil；斷路器開啟時回傳 circuitbreaker.ErrCircuitOpen
 func (ledger *MySQLLedger) PostTransaction(ctx context.Context, tran *domain.Transaction) error {
-	return ledger.client.DB().Transaction(func(tx *gorm.DB) error {
-		// 1. Idempotency Check 冪等性檢查
-		if exists, err := ledger.checkTransactionExists(tx, tran); err != nil {
-			return err
-		} else if exists {
+	_, err := ledger.PostTransactionWithBalances(ctx, tran)
+	return err
+}
+
+// PostTransactionWithBalances 處理交易請求，並回傳交易異動帳戶在 Commit 時的最新餘額
+// 呼叫端 (如 usecase.CoreUseCase 的餘額快取) 不需要再查詢一次 MySQL
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	tran: 交易請求物件 (Transaction)
+//
+// 回傳:
+//
+//	map[int64]int64: 帳戶 ID 對應的最新餘額 (交易已處理過或為建立帳戶時為 nil)
+//	error: 處理錯誤，若成功則為 nil；斷路器開啟時回傳 circuitbreaker.ErrCircuitOpen
+func (ledger *MySQLLedger) PostTransactionWithBalances(ctx context.Context, tran *domain.Transaction) (map[int64]int64, error) {
+	var balances map[int64]int64
+	err := ledger.breaker.Execute(func() error {
+		var err error
+		balances, err = ledger.postTransaction(ctx, tran)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	ledger.evictBalances(tran)
+	return balances, nil
+}
+
+// evictBalances 移除交易涉及帳戶的餘額快取 (寫入成功後呼叫)
+func (ledger *MySQLLedger) evictBalances(tran *domain.Transaction) {
+	if ledger.balanceCache != nil {
+		ledger.balanceCache.evict(tran.GetLockIDs()...)
+	}
+}
+
+// CacheHitRate 回傳餘額快取的命中率 (0 ~ 1)，未啟用 WithBalanceCache 時為 0
+func (ledger *MySQLLedger) CacheHitRate() float64 {
+	if ledger.balanceCache == nil {
+		return 0
+	}
+	return ledger.balanceCache.hitRate()
+}
+
+// postTransaction 在單一 MySQL Transaction 中處理交易
+// tx 繼承 ctx，ctx 取消時進行中的查詢會中斷並 Rollback，連線歸還連線池
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	tran: 交易請求物件 (Transaction)
+//
+// 回傳:
+//
+//	map[int64]int64: 異動帳戶的最新餘額 (見 applyTransaction)
+//	error: 處理錯誤，若成功則為 nil
+func (ledger *MySQLLedger) postTransaction(ctx context.Context, tran *domain.Transaction) (map[int64]int64, error) {
+	var balances map[int64]int64
+	var timings phaseTimings
+	start := time.Now()
+	err := ledger.client.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		balances, err = ledger.applyTransaction(tx, tran, &timings)
+		return err
+	})
+	ledger.observeTransaction(tran, time.Since(start), &timings, err)
+	if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+		// 並行的相同交易已先寫入 (整個事務已 Rollback)，與冪等性檢查查到時相同視為成功
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// PostTransactionWithSavepoint 在呼叫端的 MySQL Transaction 中以 SAVEPOINT 處理單筆交易 (批次交易使用)
+// 失敗時只 ROLLBACK TO SAVEPOINT，先前已處理的交易不受影響，呼叫端可繼續處理下一筆；
+// 整批的 Commit / Rollback 由呼叫端決定
+//
+// 參數:
+//
+//	tx: 呼叫端開啟的 GORM 資料庫事務
+//	tran: 交易請求物件
+//
+// 回傳:
+//
+//	error: 該筆交易的處理錯誤 (已回滾到 SAVEPOINT)；SAVEPOINT 本身操作失敗時外層事務已無法繼續使用
+//
+// 啟用 WithBalanceCache 時，成功後會移除相關帳戶的快取；外層事務 Commit 前讀到的餘額可能被快取到 ttl 到期
+func (ledger *MySQLLedger) PostTransactionWithSavepoint(tx *gorm.DB, tran *domain.Transaction) error {
+	name := fmt.Sprintf("sp_%d", ledger.savepointSeq.Add(1))
+	if err := tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+	var timings phaseTimings
+	start := time.Now()
+	_, err := ledger.applyTransaction(tx, tran, &timings)
+	ledger.observeTransaction(tran, time.Since(start), &timings, err)
+	if err != nil {
+		if rollbackErr := tx.RollbackTo(name).Error; rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
 			return nil
 		}
+		return err
+	}
+	if err := tx.Exec("RELEASE SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	ledger.evictBalances(tran)
+	return nil
+}
 
-		// 2. Lock & Load Accounts 悲觀鎖載入
-		users, userMap, err := ledger.lockAccounts(tx, tran)
+// applyTransaction 在 tx 中執行交易 (冪等性檢查、鎖定帳戶、業務邏輯、寫入)
+//
+// 參數:
+//
+//	tx: GORM 資料庫事務
+//	tran: 交易請求物件
+//	timings: 累加各階段耗時 (可為 nil)
+//
+// 回傳:
+//
+//	map[int64]int64: 異動帳戶 (已鎖定) 的最新餘額；交易已處理過或為建立帳戶時為 nil
+//	error: 處理錯誤，若成功則為 nil
+func (ledger *MySQLLedger) applyTransaction(tx *gorm.DB, tran *domain.Transaction, timings *phaseTimings) (map[int64]int64, error) {
+	var phases phaseTimings
+	if timings == nil {
+		timings = &phases
+	}
+	mark := time.Now()
+
+	// 1. Idempotency Check 冪等性檢查
+	exists, err := ledger.checkTransactionExists(tx, tran)
+	mark = timings.record(&timings.idempotency, mark)
+	if err != nil {
+		return nil, err
+	} else if exists {
+		return nil, nil
+	}
+
+	// 建立帳戶: 新增帳戶與交易紀錄在同一個 MySQL Transaction 中
+	if tran.Type == domain.TransactionTypeAccountCreation {
+		err := ledger.createAccount(tx, tran)
+		mark = timings.record(&timings.save, mark)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		err = ledger.createTransactionLog(tx, tran)
+		timings.record(&timings.log, mark)
+		return nil, err
+	}
 
-		// 3. Business Logic
-		if err := ledger.processTransactionLogic(tran, userMap); err != nil {
-			return err
-		}
+	// 2. Lock & Load Accounts 悲觀鎖載入
+	users, userMap, err := ledger.lockAccounts(tx, tran)
+	mark = timings.record(&timings.lock, mark)
+	if err != nil {
+		return nil, err
+	}
 
-		// 4. Update Accounts 更新帳戶
-		if err := ledger.saveUsers(tx, users); err != nil {
-			return err
-		}
+	// 3. Business Logic
+	err = ledger.processTransactionLogic(tran, userMap)
+	mark = timings.record(&timings.logic, mark)
+	if err != nil {
+		return nil, err
+	}
 
-		// 5. Create Transaction Record 建立交易記錄
-		return ledger.createTransactionLog(tx, tran)
-	})
+	// 4. Update Accounts 更新帳戶
+	err = ledger.saveUsers(tx, users)
+	mark = timings.record(&timings.save, mark)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Create Transaction Record 建立交易記錄
+	err = ledger.createTransactionLog(tx, tran)
+	timings.record(&timings.log, mark)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[int64]int64, len(users))
+	for _, user := range users {
+		balances[user.ID] = user.Balance
+	}
+	return balances, nil
 }
 
 // checkTransactionExists 檢查交易是否已經存在 (冪等性檢查)
+// 不加鎖讀取：查無資料時 SELECT ... FOR SHARE 會鎖住 ref_id 唯一索引的間隙，並行的插入會互相等待而死結 (1213)；
+// 同一個 ref_id 的並行請求可能同時通過這裡，由 ref_id 唯一索引在寫入交易紀錄時擋下 (見 createTransactionLog)
 //
 // 參數:
 //
@@ -130,15 +487,21 @@ func (ledger *MySQLLedger) PostTransaction(ctx context.Context, tran *domain.Tra
 //	bool: 是否已存在
 //	error: 查詢錯誤
 func (ledger *MySQLLedger) checkTransactionExists(tx *gorm.DB, tran *domain.Transaction) (bool, error) {
-	var count int64
-	err := tx.Model(&sqlTransaction{}).Where("ref_id = ?", tran.TransactionID[:]).Count(&count).Error
+	var ids []int64
+	err := tx.Model(&sqlTransaction{}).
+		Where("ref_id = ?", tran.TransactionID.String()).
+		Limit(1).
+		Pluck("id", &ids).Error
 	if err != nil {
-		return false, domain.ErrSelectTransactionFailed
+		// 保留原始錯誤 (如 context.Canceled)，呼叫端才能分辨是逾時還是資料庫錯誤
+		return false, fmt.Errorf("%w: %w", domain.ErrSelectTransactionFailed, err)
 	}
-	return count > 0, nil
+	return len(ids) > 0, nil
 }
 
 // lockAccounts 鎖定並載入涉及的帳戶 (悲觀鎖 FOR UPDATE)
+// 存款 / 提款只鎖定單一帳戶，轉帳才鎖定兩個帳戶；系統帳戶 (domain.SystemAccountID) 不會被載入或鎖定
+// WithDecimalStorage 時在 SELECT 中將 DECIMAL 轉回放大 CurrencyScale 倍的整數 (見 userColumns)
 //
 // 參數:
 //
@@ -151,21 +514,81 @@ func (ledger *MySQLLedger) checkTransactionExists(tx *gorm.DB, tran *domain.Tran
 //	map[int64]*sqlUser: 使用者 ID 對應的指標 Map
 //	error: 查詢或鎖定錯誤
 func (ledger *MySQLLedger) lockAccounts(tx *gorm.DB, tran *domain.Transaction) ([]sqlUser, map[int64]*sqlUser, error) {
-	lockIDs := tran.GetLockIDs()
 	var users []sqlUser
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("id IN ?", lockIDs).
-		Find(&users).Error; err != nil {
+	var err error
+	lockIDs := make([]int64, 0, 2)
+	for _, id := range tran.GetLockIDs() {
+		if id != domain.SystemAccountID {
+			lockIDs = append(lockIDs, id)
+		}
+	}
+	switch len(lockIDs) {
+	case 0:
+	case 1:
+		users, err = ledger.lockSingleAccount(tx, lockIDs[0])
+	default:
+		users, err = ledger.lockTwoAccounts(tx, lockIDs[0], lockIDs[1])
+	}
+	if err != nil {
 		return nil, nil, err
 	}
 
-	userMap := make(map[int64]*sqlUser)
+	userMap := make(map[int64]*sqlUser, len(users))
 	for i := range users {
 		userMap[users[i].ID] = &users[i]
 	}
 	return users, userMap, nil
 }
 
+// lockSingleAccount 以主鍵鎖定單一帳戶 (存款 / 提款)
+// 帳戶不存在時回傳空列表，由業務邏輯回傳 domain.ErrAccountNotFound
+//
+// 參數:
+//
+//	tx: GORM 資料庫事務
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	[]sqlUser: 鎖定的使用者列表 (0 或 1 筆)
+//	error: 查詢或鎖定錯誤
+func (ledger *MySQLLedger) lockSingleAccount(tx *gorm.DB, accountID int64) ([]sqlUser, error) {
+	var users []sqlUser
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select(ledger.userColumns()).
+		Where("id = ?", accountID).
+		Limit(1).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// lockTwoAccounts 鎖定轉帳雙方的帳戶
+// 呼叫端需依 ID 由小到大傳入 (GetLockIDs 已排序)，並以 ORDER BY id 固定加鎖順序，避免死鎖
+//
+// 參數:
+//
+//	tx: GORM 資料庫事務
+//	firstID: 較小的帳戶 ID
+//	secondID: 較大的帳戶 ID
+//
+// 回傳:
+//
+//	[]sqlUser: 鎖定的使用者列表
+//	error: 查詢或鎖定錯誤
+func (ledger *MySQLLedger) lockTwoAccounts(tx *gorm.DB, firstID, secondID int64) ([]sqlUser, error) {
+	var users []sqlUser
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select(ledger.userColumns()).
+		Where("id IN ?", []int64{firstID, secondID}).
+		Order("id").
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // processTransactionLogic 執行核心交易業務邏輯
 //
 // 參數:
@@ -184,6 +607,8 @@ func (ledger *MySQLLedger) processTransactionLogic(tran *domain.Transaction, use
 		return ledger.handleWithdraw(tran, userMap)
 	case domain.TransactionTypeTransfer:
 		return ledger.handleTransfer(tran, userMap)
+	case domain.TransactionTypeFXTransfer:
+		return ledger.handleFXTransfer(tran, userMap)
 	default:
 		return nil
 	}
@@ -244,6 +669,9 @@ func (ledger *MySQLLedger) handleTransfer(tran *domain.Transaction, userMap map[
 	if !ok {
 		return domain.ErrAccountNotFound
 	}
+	if fromUser.Currency != toUser.Currency {
+		return domain.ErrCurrencyMismatch
+	}
 	// 先扣再加款
 	if err := fromUser.Withdraw(tran.Amount); err != nil {
 		return err
@@ -254,7 +682,66 @@ func (ledger *MySQLLedger) handleTransfer(tran *domain.Transaction, userMap map[
 	return nil
 }
 
-// saveUsers 將更新後的帳戶資料寫回資料庫
+// handleFXTransfer 處理跨幣別轉帳邏輯 (扣 Amount，入帳 CreditAmount)
+//
+// 參數:
+//
+//	tran: 交易請求物件
+//	userMap: 已鎖定的使用者 Map
+//
+// 回傳:
+//
+//	error: 處理錯誤 (如餘額不足、匯率錯誤)
+func (ledger *MySQLLedger) handleFXTransfer(tran *domain.Transaction, userMap map[int64]*sqlUser) error {
+	fromUser, ok := userMap[tran.From]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	toUser, ok := userMap[tran.To]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	credit, err := tran.CreditAmount()
+	if err != nil {
+		return err
+	}
+	if err := fromUser.Withdraw(tran.Amount); err != nil {
+		return err
+	}
+	if err := toUser.Deposit(credit); err != nil {
+		return err
+	}
+	return nil
+}
+
+// createAccount 新增帳戶並設定初始餘額 (單一 INSERT)
+//
+// 參數:
+//
+//	tx: GORM 資料庫事務
+//	tran: domain.TransactionTypeAccountCreation 交易
+//
+// 回傳:
+//
+//	error: 帳戶已存在 (domain.ErrAccountAlreadyExists) 或資料庫寫入錯誤
+func (ledger *MySQLLedger) createAccount(tx *gorm.DB, tran *domain.Transaction) error {
+	users := []sqlUser{{
+		ID:       tran.To,
+		Balance:  tran.Amount,
+		Currency: tran.Currency,
+	}}
+	result := ledger.insertUsers(tx.Clauses(clause.OnConflict{DoNothing: true}), users, 1)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAccountAlreadyExists
+	}
+	return nil
+}
+
+// saveUsers 將更新後的餘額寫回資料庫
+// 以主鍵只更新 balance 欄位 (updated_at 由 GORM 自動帶入)，不覆寫整筆紀錄；WithDecimalStorage 時轉為十進位字串寫入
 //
 // 參數:
 //
@@ -265,15 +752,18 @@ func (ledger *MySQLLedger) handleTransfer(tran *domain.Transaction, userMap map[
 //
 //	error: 資料庫寫入錯誤
 func (ledger *MySQLLedger) saveUsers(tx *gorm.DB, users []sqlUser) error {
-	for _, user := range users {
-		if err := tx.Save(&user).Error; err != nil {
+	for i := range users {
+		if err := tx.Model(&sqlUser{ID: users[i].ID}).
+			Update("balance", ledger.balanceValue(users[i].Balance)).Error; err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// createTransactionLog 建立交易流水紀錄
+// createTransactionLog 建立交易流水紀錄，並將 tran.Sequence 設為紀錄的自增 ID
+// ref_id 唯一索引衝突 (並行的相同交易已先寫入) 時回傳 domain.ErrTransactionAlreadyProcessed，
+// 呼叫端 Rollback 後視為已處理 (見 postTransaction)
 //
 // 參數:
 //
@@ -282,17 +772,57 @@ func (ledger *MySQLLedger) saveUsers(tx *gorm.DB, users []sqlUser) error {
 //
 // 回傳:
 //
-//	error: 資料庫寫入錯誤
+//	error: domain.ErrTransactionAlreadyProcessed 或資料庫寫入錯誤
 func (ledger *MySQLLedger) createTransactionLog(tx *gorm.DB, tran *domain.Transaction) error {
 	transaction := sqlTransaction{
-		RefID:         tran.TransactionID[:],
+		RefID:         tran.TransactionID.String(),
 		Sequence:      tran.Sequence,
 		FromAccountID: tran.From,
 		ToAccountID:   tran.To,
 		Amount:        tran.Amount,
+		ExchangeRate:  tran.ExchangeRate,
 		Type:          uint8(tran.Type),
+		CreatedAt:     tran.CreatedAt, // 與 WAL 使用相同的交易時間 (為 0 時由 GORM 填入 DB 寫入時間)
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		if isDuplicateEntry(err) {
+			return domain.ErrTransactionAlreadyProcessed
+		}
+		return err
+	}
+	// MySQL 帳本以自增 ID 作為交易的順序號 (與 GetTransactionHistory 的游標一致)，回寫給呼叫端 (如 TransferResponse.sequence)
+	tran.Sequence = uint64(transaction.ID)
+	return nil
+}
+
+// mysqlErrDuplicateEntry MySQL 唯一索引衝突的錯誤碼 (ER_DUP_ENTRY)
+const mysqlErrDuplicateEntry = 1062
+
+// isDuplicateEntry 判斷錯誤是否為唯一索引衝突
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// CreateAccountWithInitialBalance 建立帳戶並設定初始餘額
+// 新增帳戶與交易紀錄 (ref_id 冪等性) 在同一個 MySQL Transaction 中完成
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	account: 新帳戶 (使用 ID 與 CurrencyCode)
+//	initialAmount: 初始餘額 (可為 0)
+//	refID: 外部追蹤號 (冪等性)
+//
+// 回傳:
+//
+//	error: 帳戶已存在 (domain.ErrAccountAlreadyExists)、參數錯誤或資料庫錯誤
+func (ledger *MySQLLedger) CreateAccountWithInitialBalance(ctx context.Context, account *domain.Account, initialAmount int64, refID uuid.UUID) error {
+	tran := domain.NewAccountCreation(account, initialAmount, refID, time.Now().UnixMilli())
+	if err := tran.Validate(); err != nil {
+		return err
 	}
-	return tx.Create(&transaction).Error
+	return ledger.PostTransaction(ctx, tran)
 }
 
 // GetAccountBalance 取得指定帳戶的當前餘額
@@ -307,15 +837,172 @@ func (ledger *MySQLLedger) createTransactionLog(tx *gorm.DB, tran *domain.Transa
 //	int64: 帳戶餘額
 //	error: 查詢錯誤
 func (ledger *MySQLLedger) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
+	var generation uint64
+	if ledger.balanceCache != nil {
+		balance, gen, ok := ledger.balanceCache.get(accountID)
+		if ok {
+			return balance, nil
+		}
+		generation = gen
+	}
 	var user sqlUser
-	err := ledger.client.DB().Where("id = ?", accountID).First(&user).Error
+	err := ledger.breaker.Execute(func() error {
+		return ledger.client.DB().WithContext(ctx).Select(ledger.userColumns()).Where("id = ?", accountID).First(&user).Error
+	})
 	if err != nil {
 		return 0, err
 	}
+	if ledger.balanceCache != nil {
+		ledger.balanceCache.put(accountID, user.Balance, generation)
+	}
 	return user.Balance, nil
 }
 
+// GetMultipleAccountBalances 以單一查詢 (WHERE id IN (...)) 取得多個帳戶的餘額
+// 單一 SELECT 在 InnoDB 中讀取同一個一致性快照，結果為同一時間點的餘額
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	accountIDs: 帳戶 ID
+//
+// 回傳:
+//
+//	map[int64]int64: 帳戶 ID 對應的餘額 (不存在的帳戶不會出現在結果中)
+//	error: 查詢錯誤
+func (ledger *MySQLLedger) GetMultipleAccountBalances(ctx context.Context, accountIDs []int64) (map[int64]int64, error) {
+	balances := make(map[int64]int64, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return balances, nil
+	}
+	var users []sqlUser
+	err := ledger.breaker.Execute(func() error {
+		return ledger.client.DB().WithContext(ctx).
+			Select("id", ledger.balanceColumn()).
+			Where("id IN ?", accountIDs).
+			Find(&users).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		balances[user.ID] = user.Balance
+	}
+	return balances, nil
+}
+
+// GetAccount 取得指定帳戶資料 (含最後一筆異動此帳戶的交易)
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	domain.Account: 帳戶資料
+//	error: 查詢錯誤 (帳戶不存在時為 domain.ErrAccountNotFound)
+func (ledger *MySQLLedger) GetAccount(ctx context.Context, accountID int64) (domain.Account, error) {
+	var user sqlUser
+	err := ledger.breaker.Execute(func() error {
+		return ledger.client.DB().WithContext(ctx).Select(ledger.userColumns()).Where("id = ?", accountID).First(&user).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Account{}, domain.ErrAccountNotFound
+	}
+	if err != nil {
+		return domain.Account{}, err
+	}
+	account := *user.toDomain()
+
+	// 最後一筆異動此帳戶的交易 (Sequence 即自增 ID，與 GetTransactionHistory 相同分兩個方向查詢以使用索引)
+	var last []sqlTransaction
+	err = ledger.breaker.Execute(func() error {
+		return ledger.client.DB().WithContext(ctx).
+			Raw(lastTransactionQuery, accountID, accountID).
+			Scan(&last).Error
+	})
+	if err != nil {
+		return domain.Account{}, err
+	}
+	if len(last) > 0 {
+		account.RecordTransaction(last[0].toDomain())
+	}
+	return account, nil
+}
+
+// GetAvailableBalance 取得指定帳戶的可用餘額
+// MySQL 不保存保留金額 (保留只存在於記憶體帳本)，因此可用餘額等於帳戶餘額
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	accountID: 帳戶 ID
+//
+// 回傳:
+//
+//	int64: 可用餘額
+//	error: 查詢錯誤
+func (ledger *MySQLLedger) GetAvailableBalance(ctx context.Context, accountID int64) (int64, error) {
+	return ledger.GetAccountBalance(ctx, accountID)
+}
+
+// transactionHistoryQuery 查詢帳戶 (轉出或轉入方) 在游標之後的交易
+// 以 UNION 分別查詢兩個方向：每個子查詢都能使用 idx_from_account / idx_to_account (InnoDB 次要索引包含主鍵 id，
+// 因此 account_id = ? AND id > ? ORDER BY id 是索引上的範圍掃描)；寫成 OR 時 MySQL 無法以索引排序，需掃描並排序所有紀錄
+const transactionHistoryQuery = `SELECT * FROM (
+	(SELECT * FROM transactions WHERE from_account_id = ? AND id > ? ORDER BY id LIMIT ?)
+	UNION
+	(SELECT * FROM transactions WHERE to_account_id = ? AND id > ? ORDER BY id LIMIT ?)
+) AS history ORDER BY id LIMIT ?`
+
+// lastTransactionQuery 查詢最後一筆異動帳戶 (轉出或轉入方) 的交易 (索引使用方式同 transactionHistoryQuery)
+const lastTransactionQuery = `SELECT * FROM (
+	(SELECT * FROM transactions WHERE from_account_id = ? ORDER BY id DESC LIMIT 1)
+	UNION
+	(SELECT * FROM transactions WHERE to_account_id = ? ORDER BY id DESC LIMIT 1)
+) AS latest ORDER BY id DESC LIMIT 1`
+
+// GetTransactionHistory 取得帳戶的交易紀錄 (以 Sequence 為游標分頁)
+// MySQL 帳本的 Sequence 即 transactions.id (AUTO_INCREMENT)，游標直接對應主鍵
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	accountID: 帳戶 ID (轉出或轉入方)
+//	afterSequence: 游標 (只回傳 Sequence 大於此值的交易，第一頁帶 0)
+//	limit: 最多回傳筆數
+//
+// 回傳:
+//
+//	[]*domain.Transaction: 依 Sequence 遞增排序的交易
+//	error: 查詢錯誤
+func (ledger *MySQLLedger) GetTransactionHistory(ctx context.Context, accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error) {
+	if limit <= 0 {
+		return []*domain.Transaction{}, nil
+	}
+	var rows []sqlTransaction
+	err := ledger.breaker.Execute(func() error {
+		return ledger.client.DB().WithContext(ctx).
+			Raw(transactionHistoryQuery,
+				accountID, afterSequence, limit,
+				accountID, afterSequence, limit,
+				limit,
+			).
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*domain.Transaction, 0, len(rows))
+	for i := range rows {
+		history = append(history, rows[i].toDomain())
+	}
+	return history, nil
+}
+
 // LoadAllAccounts 載入系統所有帳戶資料 (用於初始化 Memory Ledger)
+// 以 Rows() 逐列讀取並直接放入 Map，不先載入完整的 []sqlUser (千萬筆帳戶時可省下數百 MB 的尖峰記憶體)
 //
 // 參數:
 //
@@ -326,19 +1013,197 @@ func (ledger *MySQLLedger) GetAccountBalance(ctx context.Context, accountID int6
 //	map[int64]*domain.Account: 帳戶 ID 對應的 Domain Account 物件
 //	error: 查詢錯誤
 func (ledger *MySQLLedger) LoadAllAccounts(ctx context.Context) (map[int64]*domain.Account, error) {
-	var users []sqlUser
-	if err := ledger.client.DB().Find(&users).Error; err != nil {
+	db := ledger.client.DB().WithContext(ctx)
+
+	// 預估筆數只用來預先配置 Map，避免逐步擴容時新舊 Bucket 同時存在
+	var count int64
+	if err := db.Model(&sqlUser{}).Count(&count).Error; err != nil {
 		return nil, err
 	}
 
-	accountMap := make(map[int64]*domain.Account, len(users))
-	for _, u := range users {
-		accountMap[u.ID] = &domain.Account{
-			ID:      u.ID,
-			Balance: u.Balance,
+	rows, err := db.Model(&sqlUser{}).Select("id", ledger.balanceColumn(), "currency").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accountMap := make(map[int64]*domain.Account, count)
+	var user sqlUser
+	for rows.Next() {
+		if err := rows.Scan(&user.ID, &user.Balance, &user.Currency); err != nil {
+			return nil, err
 		}
+		accountMap[user.ID] = user.toDomain()
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	return accountMap, nil
 }
 
-var _ usecase.Ledger = (*MySQLLedger)(nil)
+// LoadAccountsBatch 分頁載入帳戶資料 (依 ID 排序)
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	offset: 起始位置
+//	limit: 每頁筆數
+//
+// 回傳:
+//
+//	[]*domain.Account: 該頁的帳戶列表，筆數小於 limit 代表已是最後一頁
+//	error: 查詢錯誤
+func (ledger *MySQLLedger) LoadAccountsBatch(ctx context.Context, offset, limit int64) ([]*domain.Account, error) {
+	var users []sqlUser
+	if err := ledger.client.DB().WithContext(ctx).
+		Select(ledger.userColumns()).
+		Order("id").
+		Offset(int(offset)).
+		Limit(int(limit)).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*domain.Account, 0, len(users))
+	for i := range users {
+		accounts = append(accounts, users[i].toDomain())
+	}
+	return accounts, nil
+}
+
+// UpsertAccount 新增帳戶，若帳戶已存在則覆寫餘額
+// 以 INSERT ... ON DUPLICATE KEY UPDATE 執行，重複呼叫結果相同 (可安全用於帳戶初始化與還原)
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	account: 帳戶資料
+//
+// 回傳:
+//
+//	error: 資料庫寫入錯誤
+func (ledger *MySQLLedger) UpsertAccount(ctx context.Context, account *domain.Account) error {
+	users := []sqlUser{{
+		ID:       account.ID,
+		Balance:  account.Balance,
+		Currency: account.CurrencyCode,
+	}}
+	err := ledger.breaker.Execute(func() error {
+		db := ledger.client.DB().WithContext(ctx).
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"balance", "updated_at"}),
+			})
+		return ledger.insertUsers(db, users, 1).Error
+	})
+	if err == nil && ledger.balanceCache != nil {
+		ledger.balanceCache.evict(account.ID)
+	}
+	return err
+}
+
+// SaveCheckpoint 寫入記憶體帳本的檢查點
+// 帳戶餘額與順序號在同一個資料庫交易中寫入，中途失敗不會留下與順序號不一致的餘額
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	accounts: 帳戶快照
+//	sequence: 快照對應的最後一筆 WAL 順序號
+//
+// 回傳:
+//
+//	error: 資料庫寫入錯誤
+func (ledger *MySQLLedger) SaveCheckpoint(ctx context.Context, accounts map[int64]*domain.Account, sequence uint64) error {
+	users := make([]sqlUser, 0, len(accounts))
+	for _, account := range accounts {
+		users = append(users, sqlUser{
+			ID:       account.ID,
+			Balance:  account.Balance,
+			Currency: account.CurrencyCode,
+		})
+	}
+	err := ledger.client.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(users) > 0 {
+			err := ledger.insertUsers(tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"balance", "updated_at"}),
+			}), users, checkpointBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		checkpoint := sqlCheckpoint{
+			ID:       checkpointRowID,
+			Sequence: sequence,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"sequence", "updated_at"}),
+		}).Create(&checkpoint).Error
+	})
+	if err == nil && ledger.balanceCache != nil {
+		ledger.balanceCache.evictAll()
+	}
+	return err
+}
+
+// LoadCheckpointSequence 取得最後一次檢查點的順序號
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//
+// 回傳:
+//
+//	uint64: 檢查點順序號 (沒有檢查點時為 0)
+//	error: 查詢錯誤
+func (ledger *MySQLLedger) LoadCheckpointSequence(ctx context.Context) (uint64, error) {
+	var checkpoint sqlCheckpoint
+	err := ledger.client.DB().WithContext(ctx).Where("id = ?", checkpointRowID).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return checkpoint.Sequence, nil
+}
+
+// PurgeTransactionsBefore 刪除建立時間早於 before 的交易紀錄
+// 以每批 purgeBatchSize 筆分批刪除 (DELETE ... LIMIT)，避免長時間鎖住 transactions 表
+// 依賴 transactions.idx_created_at 索引 (既有資料庫需先執行 scripts/mysql/migrations/004_transactions_created_at_index.sql)，否則每一批都會全表掃描
+//
+// 參數:
+//
+//	ctx: 上下文 (Context)
+//	before: 刪除此時間之前的紀錄
+//
+// 回傳:
+//
+//	int64: 刪除的筆數
+//	error: 資料庫錯誤
+func (ledger *MySQLLedger) PurgeTransactionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var rowsDeleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return rowsDeleted, err
+		}
+		result := ledger.client.DB().WithContext(ctx).
+			Exec("DELETE FROM transactions WHERE created_at < ? LIMIT ?", before.UnixMilli(), purgeBatchSize)
+		if result.Error != nil {
+			return rowsDeleted, result.Error
+		}
+		rowsDeleted += result.RowsAffected
+		if result.RowsAffected < purgeBatchSize {
+			return rowsDeleted, nil
+		}
+	}
+}
+
+var (
+	_ usecase.Ledger          = (*MySQLLedger)(nil)
+	_ usecase.AccountLoader   = (*MySQLLedger)(nil)
+	_ usecase.AccountSyncer   = (*MySQLLedger)(nil)
+	_ usecase.CheckpointStore = (*MySQLLedger)(nil)
+)
@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/pkg/mysql"
+)
+
+func TestIsDuplicateEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "唯一索引衝突", err: &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry"}, want: true},
+		{name: "包裝後的唯一索引衝突", err: fmt.Errorf("insert: %w", &mysqldriver.MySQLError{Number: 1062}), want: true},
+		{name: "死結", err: &mysqldriver.MySQLError{Number: 1213}, want: false},
+		{name: "其他錯誤", err: errors.New("connection refused"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateEntry(tt.err); got != tt.want {
+				t.Fatalf("isDuplicateEntry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// newMockLedger 建立以 sqlmock 為連線的 MySQLLedger
+func newMockLedger(t *testing.T) (*MySQLLedger, *mysql.Client, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mysql.NewClientFromDB(gormDB)
+	return NewMySQLLedger(client), client, mock
+}
+
+// 交易進行中 ctx 被取消：查詢中斷並 Rollback，錯誤傳回呼叫端，連線歸還連線池
+func TestPostTransactionContextCanceledMidTransaction(t *testing.T) {
+	ledger, client, mock := newMockLedger(t)
+	tran := &domain.Transaction{
+		TransactionID: uuid.New(),
+		Type:          domain.TransactionTypeDeposit,
+		To:            1,
+		Amount:        100,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT `id` FROM `transactions`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// 鎖定帳戶的查詢執行到一半時取消 ctx
+	mock.ExpectQuery("SELECT .* FROM `users`").
+		WillDelayFor(time.Minute).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := ledger.PostTransaction(ctx, tran)
+	if err == nil {
+		t.Fatal("PostTransaction succeeded after ctx was canceled")
+	}
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, sqlmock.ErrCancelled) {
+		t.Fatalf("PostTransaction error = %v, want the cancellation error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("PostTransaction returned after %v, want the query aborted by ctx", elapsed)
+	}
+	// database/sql 在背景的 goroutine 中 Rollback 並歸還連線，稍待片刻
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := mock.ExpectationsWereMet()
+		inUse := client.Stats().InUse
+		if err == nil && inUse == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after cancel: expectations %v, %d connections still in use", err, inUse)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// phaseTimings PostTransaction 各階段耗時 (見 applyTransaction)
+// commit 為總耗時扣掉其他階段，包含 BEGIN / COMMIT (或 SAVEPOINT) 與連線池等待
+type phaseTimings struct {
+	idempotency time.Duration
+	lock        time.Duration
+	logic       time.Duration
+	save        time.Duration
+	log         time.Duration
+}
+
+// record 將 since 到現在的時間累加到 phase，回傳現在時間作為下一階段的起點 (timings 為 nil 時不記錄)
+func (t *phaseTimings) record(phase *time.Duration, since time.Time) time.Time {
+	now := time.Now()
+	if t != nil {
+		*phase += now.Sub(since)
+	}
+	return now
+}
+
+// sum 已記錄階段的總耗時
+func (t *phaseTimings) sum() time.Duration {
+	return t.idempotency + t.lock + t.logic + t.save + t.log
+}
+
+// milliseconds 轉為毫秒 (保留小數，次毫秒的階段才看得出差異)
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// observeTransaction 總耗時超過 slowTransactionThreshold 時記錄各階段耗時並累加 SlowTransactions
+//
+// 參數:
+//
+//	tran: 交易請求物件
+//	total: 交易總耗時
+//	timings: 各階段耗時
+//	err: 處理結果
+func (ledger *MySQLLedger) observeTransaction(tran *domain.Transaction, total time.Duration, timings *phaseTimings, err error) {
+	if ledger.slowTransactionThreshold <= 0 || total < ledger.slowTransactionThreshold {
+		return
+	}
+	ledger.slowTransactions.Add(1)
+	slog.Warn("slow mysql transaction",
+		slog.String("transaction_id", tran.TransactionID.String()),
+		slog.String("type", tran.Type.String()),
+		slog.Float64("total_ms", milliseconds(total)),
+		slog.Group("phase_breakdown",
+			slog.Float64("idempotency_ms", milliseconds(timings.idempotency)),
+			slog.Float64("lock_ms", milliseconds(timings.lock)),
+			slog.Float64("logic_ms", milliseconds(timings.logic)),
+			slog.Float64("save_ms", milliseconds(timings.save)),
+			slog.Float64("log_ms", milliseconds(timings.log)),
+			slog.Float64("commit_ms", milliseconds(max(total-timings.sum(), 0))),
+		),
+		slog.Any("error", err),
+	)
+}
+
+// SlowTransactions 回傳啟動以來超過 slowTransactionThreshold 的交易筆數 (供監控使用)
+func (ledger *MySQLLedger) SlowTransactions() uint64 {
+	return ledger.slowTransactions.Load()
+}
@@ -1,8 +1,29 @@
 package domain
 
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Account 帳戶 注意欄位排序以避免 Padding (1 byte 的欄位放在最後，見 layout_test.go)
 type Account struct {
-	ID      int64
+	ID int64
+	// Balance: 由 Deposit / Withdraw 以 atomic.StoreInt64 寫入，不持有帳本鎖的讀取者應使用 AtomicBalance
+	// (位於 offset 8，在 32 位元平台也符合 64 位元原子操作的對齊要求)
 	Balance int64
+	// CurrencyCode: 幣別 (如 "USD", "TWD", "JPY")，空字串代表單一幣別模式
+	CurrencyCode string
+	// Reserved: 已保留 (Hold) 但尚未扣款的金額，不可再被使用
+	Reserved int64
+	// LastTransactionID / LastTransactionAt / LastTransactionType: 最後一筆異動餘額的交易 (供風控、稽核查詢)
+	// (與 Balance 不同，這三個欄位不是 atomic 寫入，讀取者必須與 RecordTransaction 的呼叫者同步，見 RecordTransaction)
+	LastTransactionID   uuid.UUID
+	LastTransactionAt   int64
+	LastTransactionType TransactionType
+	// Frozen: 凍結的帳戶不能存款或提款
+	Frozen bool
 }
 
 func NewAccount(id int64, balance int64) *Account {
@@ -12,26 +33,58 @@ func NewAccount(id int64, balance int64) *Account {
 	}
 }
 
-// Deposit 存款
+// AtomicBalance 以 atomic.LoadInt64 讀取餘額
+// 可在不持有帳本鎖的情況下與 Deposit / Withdraw 同時呼叫，不會有 Data Race (讀到的是其中一次寫入前或後的值)
+func (a *Account) AtomicBalance() int64 {
+	return atomic.LoadInt64(&a.Balance)
+}
+
+// AvailableBalance 可用餘額 (Balance - Reserved)
+func (a *Account) AvailableBalance() int64 {
+	return a.Balance - a.Reserved
+}
+
+// RecordTransaction 記錄最後一筆異動餘額的交易
+// 三個欄位是分開寫入的 (UUID 為 16 bytes)，沒有同步的讀取者可能讀到更新一半的值；
+// 呼叫端需持有帳本對此帳戶的寫鎖 (MutexLedger) 或位於事件迴圈中 (LMAXLedger)，讀取者則持有讀鎖或透過事件迴圈複製
+func (a *Account) RecordTransaction(tran *Transaction) {
+	a.LastTransactionID = tran.TransactionID
+	a.LastTransactionAt = tran.CreatedAt
+	a.LastTransactionType = tran.Type
+}
+
+// Deposit 存款 (金額必須為正數且符合記帳精度，見 ValidateAmount)
 func (a *Account) Deposit(amount int64) error {
-	if amount < 0 {
-		return ErrAmountMustBePositive
+	if a.Frozen {
+		return ErrAccountFrozen
+	}
+	if err := ValidateAmount(amount); err != nil {
+		return err
 	}
 
-	a.Balance = a.Balance + amount
+	if a.Balance > math.MaxInt64-amount {
+		return ErrBalanceOverflow
+	}
+
+	// 寫入者由帳本的鎖互斥，因此先讀後寫不會遺失更新；寫入使用 atomic 讓 AtomicBalance 的讀取者不需持有鎖
+	atomic.StoreInt64(&a.Balance, a.Balance+amount)
 	return nil
 }
 
-// Withdraw 提款
+// Withdraw 提款 (金額必須為正數且符合記帳精度，見 ValidateAmount)
 func (a *Account) Withdraw(amount int64) error {
-	if amount < 0 {
-		return ErrAmountMustBePositive
+	if a.Frozen {
+		return ErrAccountFrozen
+	}
+	if err := ValidateAmount(amount); err != nil {
+		return err
 	}
 
+	// 金額為正且不大於餘額，相減的結果不小於零，不需要額外的下溢檢查
 	if a.Balance < amount {
 		return ErrInsufficientBalance
 	}
 
-	a.Balance = a.Balance - amount
+	atomic.StoreInt64(&a.Balance, a.Balance-amount)
 	return nil
 }
@@ -0,0 +1,27 @@
+package domain
+
+// AccountEventType 帳戶事件類型
+type AccountEventType uint8
+
+const (
+	// 建立帳戶
+	AccountEventTypeCreated AccountEventType = 1
+	// 凍結帳戶
+	AccountEventTypeFrozen AccountEventType = 2
+	// 刪除帳戶
+	AccountEventTypeDeleted AccountEventType = 3
+)
+
+// AccountEvent 帳戶事件 (寫入獨立的帳戶 WAL，與交易 WAL 分開)
+type AccountEvent struct {
+	// AccountID: 帳戶 ID
+	AccountID int64 `json:"aid"`
+	// Balance: 建立帳戶時的初始餘額
+	Balance int64 `json:"bal"`
+	// Currency: 建立帳戶時的幣別 (空字串代表單一幣別模式)
+	Currency string `json:"cur,omitempty"`
+	// CreatedAt: 事件時間
+	CreatedAt int64 `json:"cat"`
+	// Type: 事件類型
+	Type AccountEventType `json:"tp"`
+}
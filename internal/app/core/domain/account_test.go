@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestAccountDepositOverflowBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance int64
+		amount  int64
+		want    int64
+		wantErr error
+	}{
+		{name: "剛好到達 MaxInt64", balance: math.MaxInt64 - 100, amount: 100, want: math.MaxInt64},
+		{name: "超過 MaxInt64 一個單位", balance: math.MaxInt64 - 100, amount: 101, want: math.MaxInt64 - 100, wantErr: ErrBalanceOverflow},
+		{name: "餘額已是 MaxInt64", balance: math.MaxInt64, amount: 1, want: math.MaxInt64, wantErr: ErrBalanceOverflow},
+		{name: "存入 MaxInt64 到空帳戶", balance: 0, amount: math.MaxInt64, want: math.MaxInt64},
+		{name: "存入 0", balance: 100, amount: 0, want: 100, wantErr: ErrAmountMustBePositive},
+		{name: "存入負數", balance: 100, amount: -100, want: 100, wantErr: ErrAmountMustBePositive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := NewAccount(1, tt.balance)
+			err := account.Deposit(tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Deposit(%d) error = %v, want %v", tt.amount, err, tt.wantErr)
+			}
+			if account.Balance != tt.want {
+				t.Fatalf("Balance = %d, want %d", account.Balance, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountWithdrawBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance int64
+		amount  int64
+		want    int64
+		wantErr error
+	}{
+		{name: "提領全部餘額", balance: 100, amount: 100, want: 0},
+		{name: "超過餘額一個單位", balance: 100, amount: 101, want: 100, wantErr: ErrInsufficientBalance},
+		{name: "提領 MaxInt64", balance: math.MaxInt64, amount: math.MaxInt64, want: 0},
+		{name: "提領 0", balance: 100, amount: 0, want: 100, wantErr: ErrAmountMustBePositive},
+		{name: "空帳戶提領 0 (先檢查金額再檢查餘額)", balance: 0, amount: 0, want: 0, wantErr: ErrAmountMustBePositive},
+		{name: "提領負數", balance: 100, amount: -100, want: 100, wantErr: ErrAmountMustBePositive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := NewAccount(1, tt.balance)
+			err := account.Withdraw(tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Withdraw(%d) error = %v, want %v", tt.amount, err, tt.wantErr)
+			}
+			if account.Balance != tt.want {
+				t.Fatalf("Balance = %d, want %d", account.Balance, tt.want)
+			}
+		})
+	}
+}
+
+// 在 -race 下執行：單一寫入者 (帳本鎖保證) 存提款的同時，其他 goroutine 不持有鎖以 AtomicBalance 讀取
+func TestAccountAtomicBalanceConcurrentWithDeposit(t *testing.T) {
+	const (
+		initial   = 1_000_000
+		amount    = 100
+		rounds    = 1_000
+		readers   = 4
+		readLoops = 1_000
+	)
+	account := NewAccount(1, initial)
+
+	var wg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < readLoops; n++ {
+				// 存款與提款交替進行，任何時間點的餘額都在 [initial, initial+amount] 之間
+				if balance := account.AtomicBalance(); balance < initial || balance > initial+amount {
+					t.Errorf("AtomicBalance() = %d, want between %d and %d", balance, initial, initial+amount)
+					return
+				}
+			}
+		}()
+	}
+	for n := 0; n < rounds; n++ {
+		if err := account.Deposit(amount); err != nil {
+			t.Fatal(err)
+		}
+		if err := account.Withdraw(amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if got := account.AtomicBalance(); got != initial {
+		t.Fatalf("AtomicBalance() = %d, want %d", got, initial)
+	}
+}
@@ -3,24 +3,57 @@ package domain
 import "errors"
 
 var (
-	// ErrAmountMustBePositive 金額必須為正數
-	ErrAmountMustBePositive = errors.New("amount must be positive")
+	// ErrAmountMustBePositive 金額必須大於零 (零金額的交易也會被拒絕)
+	ErrAmountMustBePositive = errors.New("amount must be greater than zero")
+
+	// ErrAmountPrecisionExceeded 金額精度超過最小記帳單位 (或換算後不足一個最小單位)
+	ErrAmountPrecisionExceeded = errors.New("amount precision exceeded")
+
+	// ErrInvalidTransactionType 未知的交易類型
+	ErrInvalidTransactionType = errors.New("invalid transaction type")
+
+	// ErrInvalidAccountID 帳戶 ID 不可為負數
+	ErrInvalidAccountID = errors.New("invalid account id")
+
+	// ErrSystemAccountNotAllowed 系統帳戶 (SystemAccountID) 不可作為被異動餘額的帳戶
+	ErrSystemAccountNotAllowed = errors.New("system account is not allowed here")
+
+	// ErrSameAccountTransfer 轉出與轉入帳戶相同
+	ErrSameAccountTransfer = errors.New("cannot transfer to the same account")
+
+	// ErrMissingTransactionID 缺少交易 ID
+	ErrMissingTransactionID = errors.New("missing transaction id")
 
 	// ErrInsufficientBalance 餘額不足
 	ErrInsufficientBalance = errors.New("insufficient balance")
 
+	// ErrBalanceOverflow 餘額溢位
+	ErrBalanceOverflow = errors.New("balance overflow")
+
 	// ErrAccountNotFound 找不到帳戶
 	ErrAccountNotFound = errors.New("account not found")
 
 	// ErrAccountAlreadyExists 帳戶已存在
 	ErrAccountAlreadyExists = errors.New("account already exists")
 
+	// ErrAccountFrozen 帳戶已凍結
+	ErrAccountFrozen = errors.New("account frozen")
+
+	// ErrCurrencyMismatch 非跨幣別轉帳的雙方幣別不同
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	// ErrInvalidExchangeRate 匯率必須為正數
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
 	// ErrTransactionAlreadyProcessed 交易已處理
 	ErrTransactionAlreadyProcessed = errors.New("transaction already processed")
 
 	// ErrSelectTransactionFailed 查詢交易失敗
 	ErrSelectTransactionFailed = errors.New("select transaction failed")
 
+	// ErrBalanceMismatch 餘額核對不一致
+	ErrBalanceMismatch = errors.New("balance mismatch")
+
 	// ErrWALWriteFailed WAL寫入失敗
 	ErrWALWriteFailed = errors.New("WAL write failed")
 )
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// 記憶體帳本同時保存數百萬筆 Transaction / Account，多出的 Padding 會直接放大記憶體用量
+// 結構大小必須等於欄位大小總和 (只允許為了結構對齊而補在結尾的 Padding)
+
+func TestTransactionStructSize(t *testing.T) {
+	assertNoPadding(t, unsafe.Sizeof(Transaction{}), reflect.TypeOf(Transaction{}))
+}
+
+func TestAccountStructSize(t *testing.T) {
+	assertNoPadding(t, unsafe.Sizeof(Account{}), reflect.TypeOf(Account{}))
+}
+
+// assertNoPadding 檢查每個欄位緊接在前一個欄位之後，且結構大小等於欄位大小總和向上對齊到結構的對齊值
+func assertNoPadding(t *testing.T, size uintptr, typ reflect.Type) {
+	t.Helper()
+	var fieldsSize uintptr
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Offset != fieldsSize {
+			t.Errorf("%s.%s at offset %d, want %d (%d bytes of padding before it)",
+				typ.Name(), field.Name, field.Offset, fieldsSize, field.Offset-fieldsSize)
+		}
+		fieldsSize = field.Offset + field.Type.Size()
+	}
+	align := uintptr(typ.Align())
+	if want := (fieldsSize + align - 1) &^ (align - 1); size != want {
+		t.Errorf("unsafe.Sizeof(%s) = %d, want %d (fields %d bytes, align %d)", typ.Name(), size, want, fieldsSize, align)
+	}
+}
@@ -1,12 +1,48 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"log/slog"
+	"math"
+
+	"github.com/google/uuid"
+)
 
 // amount 使用int64，並定義精度：小數點後 4 位
 const (
 	CurrencyScale = 10000
+	// MinAmountUnit 最小記帳單位 (以放大 CurrencyScale 倍後的整數表示)
+	// 目前為 1 (即 0.0001)；若要限制為 2 位小數，改為 100
+	MinAmountUnit = 1
 )
 
+// ValidateAmount 檢查金額是否為正數且符合記帳精度 (Domain 層的不變量，與 Adapter 的欄位檢查無關)
+//
+// 參數:
+//
+//	amount: 金額 (放大 CurrencyScale 倍)
+//
+// 回傳:
+//
+//	error: ErrAmountMustBePositive / ErrAmountPrecisionExceeded
+func ValidateAmount(amount int64) error {
+	if amount <= 0 {
+		return ErrAmountMustBePositive
+	}
+	return ValidateAmountPrecision(amount)
+}
+
+// ValidateAmountPrecision 檢查金額是否為最小記帳單位 (MinAmountUnit) 的整數倍
+func ValidateAmountPrecision(amount int64) error {
+	if amount%MinAmountUnit != 0 {
+		return ErrAmountPrecisionExceeded
+	}
+	return nil
+}
+
+// SystemAccountID 系統 (外部) 帳戶，代表帳本以外的資金來源 / 去向
+// 存款的 From、提款的 To 即為此帳戶；它不是真實帳戶，不會被載入或鎖定
+const SystemAccountID int64 = 0
+
 // TransactionType 交易類型
 // 為了極致節省記憶體，使用 uint8
 type TransactionType uint8
@@ -18,24 +54,56 @@ const (
 	TransactionTypeWithdraw TransactionType = 2
 	// 轉帳
 	TransactionTypeTransfer TransactionType = 3
+	// 建立帳戶並設定初始餘額 (To 為新帳戶，Amount 為初始餘額，可為 0)
+	TransactionTypeAccountCreation TransactionType = 4
+	// 跨幣別轉帳 (依 ExchangeRate 換算入帳金額)
+	TransactionTypeFXTransfer TransactionType = 6
 )
 
-// Transaction 交易 注意欄位排序以避免 Padding
+// Transaction 交易 注意欄位排序以避免 Padding (見 layout_test.go)
+// JSON Tag 使用縮寫以縮小 WAL 檔案大小
 type Transaction struct {
 	// Sequence: 全局唯一的順序號 (由核心引擎分配，1, 2, 3...)
 	// 用於 WAL 重放確保順序一致
-	Sequence uint64
+	Sequence uint64 `json:"seq"`
 	// From, To: 帳戶 ID
-	From int64
-	To   int64
-	// Amount: 金額
-	Amount int64
+	From int64 `json:"fr"`
+	To   int64 `json:"to"`
+	// Amount: 金額 (跨幣別轉帳時為轉出帳戶幣別的扣款金額)
+	Amount int64 `json:"amt"`
+	// ExchangeRate: 匯率 (放大 CurrencyScale 倍)，僅跨幣別轉帳使用
+	ExchangeRate int64 `json:"fx,omitempty"`
+	// Currency: 新帳戶的幣別，僅建立帳戶使用 (空字串代表單一幣別模式)
+	Currency string `json:"cur,omitempty"`
 	// CreatedAt: 交易時間
-	CreatedAt int64
+	CreatedAt int64 `json:"cat"`
 	// TransactionID: 外部追蹤號 (UUID)
-	TransactionID uuid.UUID
+	TransactionID uuid.UUID `json:"tid"`
 	// Type: 放到最後面，利用 Padding 空間
-	Type TransactionType
+	Type TransactionType `json:"tp"`
+}
+
+// NewAccountCreation 建立「建立帳戶並設定初始餘額」的交易 (帳戶與初始存款在同一筆紀錄中，不會只完成一半)
+//
+// 參數:
+//
+//	account: 新帳戶 (使用 ID 與 CurrencyCode)
+//	initialAmount: 初始餘額 (可為 0)
+//	refID: 外部追蹤號 (冪等性)
+//	createdAt: 交易時間 (Unix 毫秒)
+//
+// 回傳:
+//
+//	*Transaction: 交易
+func NewAccountCreation(account *Account, initialAmount int64, refID uuid.UUID, createdAt int64) *Transaction {
+	return &Transaction{
+		To:            account.ID,
+		Amount:        initialAmount,
+		Currency:      account.CurrencyCode,
+		CreatedAt:     createdAt,
+		TransactionID: refID,
+		Type:          TransactionTypeAccountCreation,
+	}
 }
 
 // GetLockIDs 回傳需要鎖定的帳號 ID，並確保順序以避免死鎖
@@ -44,16 +112,113 @@ func (t *Transaction) GetLockIDs() (ids []int64) {
 	// make([]Type, len, cap)
 	ids = make([]int64, 0, 2)
 	switch t.Type {
-	case TransactionTypeTransfer:
+	case TransactionTypeTransfer, TransactionTypeFXTransfer:
 		if t.From < t.To {
 			ids = append(ids, t.From, t.To)
 		} else {
 			ids = append(ids, t.To, t.From)
 		}
-	case TransactionTypeDeposit:
+	case TransactionTypeDeposit, TransactionTypeAccountCreation:
 		ids = append(ids, t.To)
 	case TransactionTypeWithdraw:
 		ids = append(ids, t.From)
+	default:
+		// 新增交易類型卻忘了更新此處時，保守地鎖定雙方帳戶，避免鎖不到帳戶而回傳誤導的 ErrAccountNotFound
+		slog.Warn("GetLockIDs: unrecognised transaction type, locking both accounts",
+			"type", uint8(t.Type),
+			"transaction_id", t.TransactionID.String(),
+		)
+		switch {
+		case t.From == t.To:
+			ids = append(ids, t.From)
+		case t.From < t.To:
+			ids = append(ids, t.From, t.To)
+		default:
+			ids = append(ids, t.To, t.From)
+		}
 	}
 	return ids
 }
+
+// Clone 回傳交易的深拷貝
+// 目前所有欄位皆為值型別 (uuid.UUID 為陣列)，新增 Slice / Map / 指標欄位時需一併複製
+func (t *Transaction) Clone() *Transaction {
+	clone := *t
+	return &clone
+}
+
+// Validate 檢查交易本身的業務規則 (不涉及帳戶狀態)
+// 金額必須為正數 (建立帳戶的初始餘額可為 0)、交易類型必須已知、需有交易 ID，且依類型檢查帳戶 ID (系統帳戶只能出現在存款的 From / 提款的 To)
+func (t *Transaction) Validate() error {
+	if t.TransactionID == uuid.Nil {
+		return ErrMissingTransactionID
+	}
+	if t.Type == TransactionTypeAccountCreation {
+		if t.Amount < 0 {
+			return ErrAmountMustBePositive
+		}
+		if err := ValidateAmountPrecision(t.Amount); err != nil {
+			return err
+		}
+	} else if err := ValidateAmount(t.Amount); err != nil {
+		return err
+	}
+	switch t.Type {
+	case TransactionTypeDeposit, TransactionTypeAccountCreation:
+		if err := validateAccountID(t.To); err != nil {
+			return err
+		}
+	case TransactionTypeWithdraw:
+		if err := validateAccountID(t.From); err != nil {
+			return err
+		}
+	case TransactionTypeTransfer, TransactionTypeFXTransfer:
+		if err := validateAccountID(t.From); err != nil {
+			return err
+		}
+		if err := validateAccountID(t.To); err != nil {
+			return err
+		}
+		if t.From == t.To {
+			return ErrSameAccountTransfer
+		}
+		if t.Type == TransactionTypeFXTransfer && t.ExchangeRate <= 0 {
+			return ErrInvalidExchangeRate
+		}
+	default:
+		return ErrInvalidTransactionType
+	}
+	return nil
+}
+
+// validateAccountID 檢查會被異動餘額的帳戶 ID (不可為負數，也不可為系統帳戶)
+func validateAccountID(id int64) error {
+	if id < 0 {
+		return ErrInvalidAccountID
+	}
+	if id == SystemAccountID {
+		return ErrSystemAccountNotAllowed
+	}
+	return nil
+}
+
+// CreditAmount 計算入帳金額
+// 跨幣別轉帳為 Amount * ExchangeRate / CurrencyScale (無條件捨去至 MinAmountUnit)，其他交易為 Amount
+// 換算後不足一個最小記帳單位時回傳 ErrAmountPrecisionExceeded
+func (t *Transaction) CreditAmount() (int64, error) {
+	if t.Type != TransactionTypeFXTransfer {
+		return t.Amount, nil
+	}
+	if t.ExchangeRate <= 0 {
+		return 0, ErrInvalidExchangeRate
+	}
+	if t.Amount > math.MaxInt64/t.ExchangeRate {
+		return 0, ErrBalanceOverflow
+	}
+	credit := t.Amount * t.ExchangeRate / CurrencyScale
+	credit -= credit % MinAmountUnit
+	if credit <= 0 {
+		return 0, ErrAmountPrecisionExceeded
+	}
+	return credit, nil
+}
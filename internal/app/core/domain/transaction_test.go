@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionClone(t *testing.T) {
+	original := &Transaction{
+		Sequence:      7,
+		From:          1,
+		To:            2,
+		Amount:        100,
+		Currency:      "USD",
+		CreatedAt:     1_700_000_000_000,
+		TransactionID: uuid.New(),
+		Type:          TransactionTypeTransfer,
+	}
+	clone := original.Clone()
+	if clone == original || *clone != *original {
+		t.Fatalf("Clone() = %p %+v, want a distinct copy of %p %+v", clone, *clone, original, *original)
+	}
+
+	want := *clone
+	original.Amount = 1
+	original.TransactionID = uuid.New()
+	if *clone != want {
+		t.Fatalf("clone changed to %+v after modifying the original, want %+v", *clone, want)
+	}
+}
+
+// 金額為 0 的交易在寫入 WAL 前就被拒絕 (建立帳戶的初始餘額例外)
+func TestTransactionValidateZeroAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		tx      Transaction
+		wantErr error
+	}{
+		{name: "存款 0", tx: Transaction{Type: TransactionTypeDeposit, To: 1}, wantErr: ErrAmountMustBePositive},
+		{name: "提款 0", tx: Transaction{Type: TransactionTypeWithdraw, From: 1}, wantErr: ErrAmountMustBePositive},
+		{name: "轉帳 0", tx: Transaction{Type: TransactionTypeTransfer, From: 1, To: 2}, wantErr: ErrAmountMustBePositive},
+		{name: "最小單位的存款", tx: Transaction{Type: TransactionTypeDeposit, To: 1, Amount: MinAmountUnit}},
+		{name: "初始餘額 0 的建立帳戶", tx: Transaction{Type: TransactionTypeAccountCreation, To: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tx.TransactionID = uuid.New()
+			if err := tt.tx.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if got, want := ErrAmountMustBePositive.Error(), "amount must be greater than zero"; got != want {
+		t.Fatalf("ErrAmountMustBePositive = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// transactionTypeNames 交易類型的字串表示 (Log 與 WAL 使用)
+var transactionTypeNames = map[TransactionType]string{
+	TransactionTypeDeposit:         "deposit",
+	TransactionTypeWithdraw:        "withdraw",
+	TransactionTypeTransfer:        "transfer",
+	TransactionTypeAccountCreation: "account_creation",
+	TransactionTypeFXTransfer:      "fx_transfer",
+}
+
+// String 回傳交易類型名稱 (如 "deposit")，未知類型回傳 "TransactionType(n)"
+func (t TransactionType) String() string {
+	if name, ok := transactionTypeNames[t]; ok {
+		return name
+	}
+	return "TransactionType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ParseTransactionType 將名稱 (如 "transfer") 轉換為交易類型
+//
+// 參數:
+//
+//	s: 交易類型名稱 (見 String)
+//
+// 回傳:
+//
+//	TransactionType: 交易類型
+//	error: ErrInvalidTransactionType (未知名稱)
+func ParseTransactionType(s string) (TransactionType, error) {
+	for t, name := range transactionTypeNames {
+		if name == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidTransactionType, s)
+}
+
+// MarshalJSON 以名稱寫入 JSON (WAL 可直接閱讀)
+// 未知類型仍以數字寫入，確保讀回後不會遺失
+func (t TransactionType) MarshalJSON() ([]byte, error) {
+	name, ok := transactionTypeNames[t]
+	if !ok {
+		return []byte(strconv.Itoa(int(t))), nil
+	}
+	return json.Marshal(name)
+}
+
+// UnmarshalJSON 讀取名稱或數字 (舊版 WAL 以數字寫入交易類型，讀取時不需轉換)
+func (t *TransactionType) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		parsed, err := ParseTransactionType(name)
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var n uint8
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*t = TransactionType(n)
+	return nil
+}
@@ -2,29 +2,159 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
 )
 
 // CoreUseCase 是核心業務邏輯層
+// 除了 Ledger 之外的依賴皆為選用 (見 UseCaseOption)，未設定時略過
 type CoreUseCase struct {
-	ledger Ledger
+	ledger           Ledger
+	eventBus         EventBus
+	validator        Validator
+	idempotencyStore IdempotencyStore
+	tracer           Tracer
+	metrics          MetricsCollector
+	balanceCache     BalanceCache
+	balanceCacheTTL  time.Duration
 }
 
-func NewCoreUseCase(ledger Ledger) *CoreUseCase {
-	return &CoreUseCase{
+// NewCoreUseCase 建立 CoreUseCase
+//
+// 參數:
+//
+//	ledger: 帳本實作
+//	opts: 可選的配置選項
+//
+// 回傳:
+//
+//	*CoreUseCase: CoreUseCase 實例
+func NewCoreUseCase(ledger Ledger, opts ...UseCaseOption) *CoreUseCase {
+	c := &CoreUseCase{
 		ledger: ledger,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// PostTransaction 處理交易
-func (c *CoreUseCase) PostTransaction(ctx context.Context, tran *domain.Transaction) error {
-	return c.ledger.PostTransaction(ctx, tran)
+// PostTransaction 處理交易 (先檢查交易本身的業務規則再交給 Ledger)
+func (c *CoreUseCase) PostTransaction(ctx context.Context, tran *domain.Transaction) (err error) {
+	if c.tracer != nil {
+		var end func(error)
+		ctx, end = c.tracer.Start(ctx, "CoreUseCase.PostTransaction")
+		defer func() { end(err) }()
+	}
+	if c.metrics != nil {
+		start := time.Now()
+		defer func() { c.metrics.ObserveTransaction(tran.Type, time.Since(start), err) }()
+	}
+
+	if tran.CreatedAt == 0 {
+		// 統一以應用程式時間作為交易時間，WAL 與資料庫的紀錄才會一致
+		tran.CreatedAt = time.Now().UnixMilli()
+	}
+	if err := tran.Validate(); err != nil {
+		return err
+	}
+	if c.validator != nil {
+		if err := c.validator.Validate(ctx, tran); err != nil {
+			return err
+		}
+	}
+	if c.idempotencyStore != nil {
+		if result, found := c.idempotencyStore.Lookup(ctx, tran.TransactionID); found {
+			return result
+		}
+	}
+
+	var balances map[int64]int64
+	if reporter, ok := c.ledger.(BalanceReportingLedger); ok && c.balanceCache != nil {
+		balances, err = reporter.PostTransactionWithBalances(ctx, tran)
+	} else {
+		err = c.ledger.PostTransaction(ctx, tran)
+	}
+
+	if c.idempotencyStore != nil && !errors.Is(err, domain.ErrWALWriteFailed) {
+		// WAL 寫入失敗代表交易未被處理，允許重試
+		c.idempotencyStore.Save(ctx, tran.TransactionID, err)
+	}
+	if err == nil && c.balanceCache != nil {
+		c.refreshBalanceCache(ctx, tran, balances)
+	}
+	if err == nil && c.eventBus != nil {
+		// 交易已完成，發布失敗不影響交易結果
+		_ = c.eventBus.PublishTransaction(ctx, tran)
+	}
+	return err
 }
 
-// GetAccountBalance 取得帳戶餘額
+// refreshBalanceCache 交易成功後更新異動帳戶的餘額快取 (以 tran.Sequence 作為版本)
+// balances 沒有的帳戶 (Ledger 未回報) 改向 Ledger 查詢，查到的餘額不會比此交易舊；查詢失敗時移除快取
+func (c *CoreUseCase) refreshBalanceCache(ctx context.Context, tran *domain.Transaction, balances map[int64]int64) {
+	for _, accountID := range tran.GetLockIDs() {
+		if accountID == domain.SystemAccountID {
+			continue
+		}
+		balance, ok := balances[accountID]
+		if !ok {
+			var err error
+			if balance, err = c.ledger.GetAccountBalance(ctx, accountID); err != nil {
+				c.balanceCache.Evict(accountID)
+				continue
+			}
+		}
+		c.balanceCache.Set(accountID, balance, tran.Sequence, c.balanceCacheTTL)
+	}
+}
+
+// GetAccountBalance 取得帳戶餘額 (設定 BalanceCache 時先查快取)
 func (c *CoreUseCase) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
-	return c.ledger.GetAccountBalance(ctx, accountID)
+	if c.balanceCache == nil {
+		return c.ledger.GetAccountBalance(ctx, accountID)
+	}
+	balance, generation, ok := c.balanceCache.Get(accountID)
+	if ok {
+		return balance, nil
+	}
+	balance, err := c.ledger.GetAccountBalance(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	// 查詢期間有交易完成時 Fill 不會寫入，避免以查詢到的舊餘額覆蓋
+	c.balanceCache.Fill(accountID, balance, generation, c.balanceCacheTTL)
+	return balance, nil
+}
+
+// GetMultipleAccountBalances 一次取得多個帳戶的餘額 (同一時間點的一致快照)
+func (c *CoreUseCase) GetMultipleAccountBalances(ctx context.Context, accountIDs []int64) (map[int64]int64, error) {
+	return c.ledger.GetMultipleAccountBalances(ctx, accountIDs)
+}
+
+// GetAccount 取得帳戶資料 (餘額、幣別等)
+func (c *CoreUseCase) GetAccount(ctx context.Context, accountID int64) (domain.Account, error) {
+	return c.ledger.GetAccount(ctx, accountID)
+}
+
+// GetAvailableBalance 取得帳戶可用餘額 (Balance - Reserved)
+// 判斷「能不能付款」應使用此方法，呼叫端不需要知道保留金額的機制
+func (c *CoreUseCase) GetAvailableBalance(ctx context.Context, accountID int64) (int64, error) {
+	return c.ledger.GetAvailableBalance(ctx, accountID)
+}
+
+// GetTransactionHistory 取得帳戶的交易紀錄 (以 Sequence 為游標分頁)
+func (c *CoreUseCase) GetTransactionHistory(ctx context.Context, accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error) {
+	return c.ledger.GetTransactionHistory(ctx, accountID, afterSequence, limit)
+}
+
+// CreateAccountWithInitialBalance 建立帳戶並設定初始餘額 (原子操作，不會只建立帳戶而沒有初始餘額)
+func (c *CoreUseCase) CreateAccountWithInitialBalance(ctx context.Context, account *domain.Account, initialAmount int64, refID uuid.UUID) error {
+	return c.ledger.CreateAccountWithInitialBalance(ctx, account, initialAmount, refID)
 }
 
 // LoadAllAccounts 載入所有帳戶
@@ -0,0 +1,184 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/adapter/out/cache"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/mocks"
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/usecase"
+)
+
+func TestPostTransactionDelegatesToLedger(t *testing.T) {
+	ctx := context.Background()
+	ledger := mocks.NewLedger(t)
+	core := usecase.NewCoreUseCase(ledger)
+
+	tx := &domain.Transaction{
+		TransactionID: uuid.New(),
+		Type:          domain.TransactionTypeDeposit,
+		From:          domain.SystemAccountID,
+		To:            1,
+		Amount:        10_000,
+	}
+	ledger.On("PostTransaction", mock.Anything, tx).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Transaction).Sequence = 42
+		}).
+		Return(nil).
+		Once()
+
+	require.NoError(t, core.PostTransaction(ctx, tx))
+	assert.Equal(t, uint64(42), tx.Sequence)
+	// 未指定交易時間時由 CoreUseCase 補上後才交給 Ledger
+	assert.NotZero(t, tx.CreatedAt)
+}
+
+func TestPostTransactionInvalidNotDelegated(t *testing.T) {
+	ledger := mocks.NewLedger(t)
+	core := usecase.NewCoreUseCase(ledger)
+
+	err := core.PostTransaction(context.Background(), &domain.Transaction{
+		TransactionID: uuid.New(),
+		Type:          domain.TransactionTypeDeposit,
+		To:            1,
+		Amount:        0,
+	})
+	assert.ErrorIs(t, err, domain.ErrAmountMustBePositive)
+	ledger.AssertNotCalled(t, "PostTransaction", mock.Anything, mock.Anything)
+}
+
+func TestGetAccountBalancePropagatesError(t *testing.T) {
+	ledger := mocks.NewLedger(t)
+	core := usecase.NewCoreUseCase(ledger)
+	ledger.On("GetAccountBalance", mock.Anything, int64(7)).Return(int64(0), domain.ErrAccountNotFound).Once()
+
+	balance, err := core.GetAccountBalance(context.Background(), 7)
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+	assert.Zero(t, balance)
+}
+
+// reportingLedger 在 mocks.Ledger 之上實作 usecase.BalanceReportingLedger
+type reportingLedger struct {
+	*mocks.Ledger
+}
+
+func (l reportingLedger) PostTransactionWithBalances(ctx context.Context, tran *domain.Transaction) (map[int64]int64, error) {
+	args := l.MethodCalled("PostTransactionWithBalances", ctx, tran)
+	balances, _ := args.Get(0).(map[int64]int64)
+	return balances, args.Error(1)
+}
+
+// expectReportedTransaction 預期一筆交易，處理時分配 sequence 並回報 balances
+func expectReportedTransaction(ledger reportingLedger, tran *domain.Transaction, sequence uint64, balances map[int64]int64) {
+	ledger.On("PostTransactionWithBalances", mock.Anything, tran).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Transaction).Sequence = sequence
+		}).
+		Return(balances, nil).
+		Once()
+}
+
+func newTransfer(from, to, amount int64) *domain.Transaction {
+	return &domain.Transaction{
+		TransactionID: uuid.New(),
+		Type:          domain.TransactionTypeTransfer,
+		From:          from,
+		To:            to,
+		Amount:        amount,
+	}
+}
+
+func TestBalanceCacheRefreshedFromReportedBalances(t *testing.T) {
+	ctx := context.Background()
+	ledger := reportingLedger{mocks.NewLedger(t)}
+	core := usecase.NewCoreUseCase(ledger, usecase.WithBalanceCache(cache.NewTTLCache()), usecase.WithBalanceCacheTTL(time.Minute))
+
+	tran := newTransfer(1, 2, 100)
+	expectReportedTransaction(ledger, tran, 7, map[int64]int64{1: 900, 2: 1100})
+	require.NoError(t, core.PostTransaction(ctx, tran))
+
+	// 兩個帳戶都由回報的餘額寫入快取，不再查詢 Ledger (mock 沒有預期 GetAccountBalance)
+	for id, want := range map[int64]int64{1: 900, 2: 1100} {
+		balance, err := core.GetAccountBalance(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, balance, "account %d", id)
+	}
+}
+
+// Ledger 未實作 BalanceReportingLedger 時，交易後向 Ledger 查詢一次餘額寫入快取
+func TestBalanceCacheFallbackQueriesLedger(t *testing.T) {
+	ctx := context.Background()
+	ledger := mocks.NewLedger(t)
+	core := usecase.NewCoreUseCase(ledger, usecase.WithBalanceCache(cache.NewTTLCache()), usecase.WithBalanceCacheTTL(time.Minute))
+
+	tran := newTransfer(1, 2, 100)
+	ledger.On("PostTransaction", mock.Anything, tran).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Transaction).Sequence = 3
+		}).
+		Return(nil).
+		Once()
+	ledger.On("GetAccountBalance", mock.Anything, int64(1)).Return(int64(900), nil).Once()
+	ledger.On("GetAccountBalance", mock.Anything, int64(2)).Return(int64(0), domain.ErrAccountNotFound).Once()
+	require.NoError(t, core.PostTransaction(ctx, tran))
+
+	balance, err := core.GetAccountBalance(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(900), balance)
+
+	// 查詢失敗的帳戶沒有快取，下次讀取再向 Ledger 查詢
+	ledger.On("GetAccountBalance", mock.Anything, int64(2)).Return(int64(1100), nil).Once()
+	balance, err = core.GetAccountBalance(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1100), balance)
+}
+
+// T1 先完成 (餘額 100)、T2 後完成 (餘額 50)，但 T1 較晚寫入快取：快取必須保留 T2 的餘額
+func TestBalanceCacheKeepsNewestOfConcurrentTransactions(t *testing.T) {
+	ctx := context.Background()
+	ledger := reportingLedger{mocks.NewLedger(t)}
+	core := usecase.NewCoreUseCase(ledger, usecase.WithBalanceCache(cache.NewTTLCache()), usecase.WithBalanceCacheTTL(time.Minute))
+
+	t1, t2 := newTransfer(1, 2, 100), newTransfer(1, 2, 50)
+	expectReportedTransaction(ledger, t2, 2, map[int64]int64{1: 50, 2: 1150})
+	expectReportedTransaction(ledger, t1, 1, map[int64]int64{1: 100, 2: 1100})
+	require.NoError(t, core.PostTransaction(ctx, t2))
+	require.NoError(t, core.PostTransaction(ctx, t1))
+
+	balance, err := core.GetAccountBalance(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), balance)
+}
+
+// 讀取未命中時查詢到舊餘額，查詢期間完成的交易已寫入新餘額：舊餘額不能覆蓋
+func TestBalanceCacheMissDoesNotOverwriteNewerTransaction(t *testing.T) {
+	ctx := context.Background()
+	ledger := reportingLedger{mocks.NewLedger(t)}
+	core := usecase.NewCoreUseCase(ledger, usecase.WithBalanceCache(cache.NewTTLCache()), usecase.WithBalanceCacheTTL(time.Minute))
+
+	tran := newTransfer(1, 2, 50)
+	expectReportedTransaction(ledger, tran, 2, map[int64]int64{1: 50, 2: 1150})
+	ledger.On("GetAccountBalance", mock.Anything, int64(1)).
+		Run(func(mock.Arguments) {
+			// Ledger 已讀出舊餘額，回傳前另一筆交易完成
+			require.NoError(t, core.PostTransaction(ctx, tran))
+		}).
+		Return(int64(100), nil).
+		Once()
+
+	balance, err := core.GetAccountBalance(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), balance)
+
+	balance, err = core.GetAccountBalance(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), balance)
+}
@@ -3,15 +3,62 @@ package usecase
 import (
 	"context"
 
+	"github.com/google/uuid"
+
 	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
 )
 
 // Ledger 是帳務系統的介面
+// 測試 CoreUseCase / GrpcServer 時可用 mockery 產生的 mocks.Ledger 取代真正的帳本 (make generate)
+//
+//go:generate mockery --name=Ledger --output=../mocks
 type Ledger interface {
 	// 不再分 Deposit/Withdraw，直接看 tran.Type 決定
+	// 成功後 tran.Sequence 為帳本分配的順序號 (記憶體帳本為 WAL 順序號，MySQL 帳本為 transactions 的自增 ID)
 	PostTransaction(ctx context.Context, tran *domain.Transaction) error
 	// GetAccountBalance 取得帳戶餘額
 	GetAccountBalance(ctx context.Context, accountID int64) (int64, error)
+	// GetMultipleAccountBalances 一次取得多個帳戶的餘額 (同一時間點的一致快照)，不存在的帳戶不會出現在結果中
+	GetMultipleAccountBalances(ctx context.Context, accountIDs []int64) (map[int64]int64, error)
+	// GetAccount 取得帳戶資料的複本 (餘額、幣別等)
+	GetAccount(ctx context.Context, accountID int64) (domain.Account, error)
+	// GetAvailableBalance 取得帳戶可用餘額 (扣除已保留的金額)
+	GetAvailableBalance(ctx context.Context, accountID int64) (int64, error)
+	// GetTransactionHistory 取得帳戶在 afterSequence 之後的交易紀錄 (依 Sequence 遞增)，最多 limit 筆
+	GetTransactionHistory(ctx context.Context, accountID int64, afterSequence uint64, limit int) ([]*domain.Transaction, error)
+	// CreateAccountWithInitialBalance 以單一操作建立帳戶並設定初始餘額 (refID 用於冪等性)
+	// 帳戶已存在時回傳 domain.ErrAccountAlreadyExists
+	CreateAccountWithInitialBalance(ctx context.Context, account *domain.Account, initialAmount int64, refID uuid.UUID) error
 	// LoadAllAccounts載入所有帳戶
 	LoadAllAccounts(ctx context.Context) (map[int64]*domain.Account, error)
 }
+
+// AccountLoader 分頁載入帳戶資料，用於初始化記憶體帳本時限制啟動的記憶體用量
+type AccountLoader interface {
+	// LoadAccountsBatch 依帳戶 ID 排序，載入從 offset 起最多 limit 筆帳戶
+	LoadAccountsBatch(ctx context.Context, offset, limit int64) ([]*domain.Account, error)
+}
+
+// CheckpointStore 保存記憶體帳本的檢查點 (帳戶餘額與對應的 WAL 順序號)
+// 重啟時只需重放檢查點之後的 WAL
+type CheckpointStore interface {
+	// SaveCheckpoint 在同一個資料庫交易中寫入所有帳戶餘額與順序號
+	SaveCheckpoint(ctx context.Context, accounts map[int64]*domain.Account, sequence uint64) error
+	// LoadCheckpointSequence 取得最後一次檢查點的順序號 (沒有檢查點時回傳 0)
+	LoadCheckpointSequence(ctx context.Context) (uint64, error)
+}
+
+// AccountSyncer 可寫入帳戶資料的持久化儲存 (如 MySQL)，用於將記憶體帳本的狀態同步回去
+type AccountSyncer interface {
+	// UpsertAccount 新增或覆寫帳戶餘額
+	UpsertAccount(ctx context.Context, account *domain.Account) error
+	// GetAccountBalance 取得帳戶餘額 (用於同步後核對)
+	GetAccountBalance(ctx context.Context, accountID int64) (int64, error)
+}
+
+// BalanceReportingLedger 交易完成時一併回傳異動帳戶最新餘額的 Ledger (選用)
+// CoreUseCase 設定 BalanceCache 時以回傳的餘額更新快取，不需要再查詢一次
+type BalanceReportingLedger interface {
+	// PostTransactionWithBalances 與 PostTransaction 相同，成功時回傳異動帳戶的最新餘額 (可能不包含所有帳戶，例如重複的交易)
+	PostTransactionWithBalances(ctx context.Context, tran *domain.Transaction) (map[int64]int64, error)
+}
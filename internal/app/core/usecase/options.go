@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JoeShih716/go-mem-ledger/internal/app/core/domain"
+)
+
+// EventBus 發布已成功處理的交易 (如推送至 Kafka)
+type EventBus interface {
+	// PublishTransaction 發布交易 (tran 在回傳後可能被重複使用，需保留時請複製)
+	PublishTransaction(ctx context.Context, tran *domain.Transaction) error
+}
+
+// Validator 在 domain.Transaction.Validate 之外的額外檢查 (如單筆金額上限)
+type Validator interface {
+	Validate(ctx context.Context, tran *domain.Transaction) error
+}
+
+// IdempotencyStore 跨實例共享的冪等性紀錄 (Ledger 內部的紀錄只在單一實例有效)
+type IdempotencyStore interface {
+	// Lookup 取得交易的原始處理結果，found 為 false 代表尚未處理
+	Lookup(ctx context.Context, transactionID uuid.UUID) (result error, found bool)
+	// Save 記錄交易的處理結果
+	Save(ctx context.Context, transactionID uuid.UUID, result error)
+}
+
+// Tracer 追蹤 UseCase 的處理過程
+type Tracer interface {
+	// Start 開始一個 Span，回傳的 end 需在處理結束時呼叫
+	Start(ctx context.Context, name string) (spanCtx context.Context, end func(err error))
+}
+
+// MetricsCollector 收集交易處理的指標
+type MetricsCollector interface {
+	ObserveTransaction(tranType domain.TransactionType, duration time.Duration, err error)
+}
+
+// BalanceCache 帳戶餘額快取 (如 cache.TTLCache)，減少 GetAccountBalance 對 Ledger 的查詢
+// 並行的交易與查詢寫入快取的順序不一定與 Ledger 處理的順序相同，實作必須依版本判斷新舊，不能讓舊餘額覆蓋新的餘額
+type BalanceCache interface {
+	// Get 取得未過期的餘額，ok 為 false 代表沒有快取或已過期；generation 供未命中後的 Fill 使用
+	Get(accountID int64) (balance int64, generation uint64, ok bool)
+	// Fill 寫入未命中時向 Ledger 查詢到的餘額，Get 之後有 Set 或 Evict 時放棄寫入
+	Fill(accountID int64, balance int64, generation uint64, ttl time.Duration)
+	// Set 寫入交易完成後的餘額，只在 sequence 比已寫入的交易新時才寫入 (sequence 為 0 時移除)
+	Set(accountID int64, balance int64, sequence uint64, ttl time.Duration)
+	// Evict 移除帳戶的快取
+	Evict(accountID int64)
+}
+
+// DefaultBalanceCacheTTL BalanceCache 項目的預設存活時間
+const DefaultBalanceCacheTTL = time.Second
+
+// UseCaseOption 定義了 CoreUseCase 的配置選項函數
+type UseCaseOption func(*CoreUseCase)
+
+// WithEventBus 設定交易成功後發布事件的 EventBus
+func WithEventBus(bus EventBus) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.eventBus = bus
+	}
+}
+
+// WithValidator 設定額外的交易檢查
+func WithValidator(validator Validator) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.validator = validator
+	}
+}
+
+// WithIdempotencyStore 設定跨實例共享的冪等性紀錄
+func WithIdempotencyStore(store IdempotencyStore) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.idempotencyStore = store
+	}
+}
+
+// WithTracer 設定追蹤器
+func WithTracer(tracer Tracer) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.tracer = tracer
+	}
+}
+
+// WithMetricsCollector 設定指標收集器
+func WithMetricsCollector(collector MetricsCollector) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.metrics = collector
+	}
+}
+
+// WithBalanceCache 設定餘額快取：GetAccountBalance 先查快取，交易成功後以最新餘額更新異動帳戶的快取
+// Ledger 實作 BalanceReportingLedger 時直接使用交易回傳的餘額，否則交易後再向 Ledger 查詢一次；
+// 快取以交易的 Sequence 判斷新舊，Ledger 對同一帳戶的交易必須依處理順序分配遞增的 Sequence
+// 不經過 CoreUseCase 的餘額異動 (如檢查點、同步) 最多在 ttl 內讀到舊值
+func WithBalanceCache(cache BalanceCache) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.balanceCache = cache
+		if c.balanceCacheTTL == 0 {
+			c.balanceCacheTTL = DefaultBalanceCacheTTL
+		}
+	}
+}
+
+// WithBalanceCacheTTL 設定餘額快取項目的存活時間 (預設 DefaultBalanceCacheTTL)
+func WithBalanceCacheTTL(ttl time.Duration) UseCaseOption {
+	return func(c *CoreUseCase) {
+		c.balanceCacheTTL = ttl
+	}
+}
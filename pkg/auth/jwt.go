@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationHeader 攜帶 Token 的 Metadata Key，值為 "Bearer <token>"
+const AuthorizationHeader = "authorization"
+
+const bearerPrefix = "Bearer "
+
+var (
+	// ErrMissingToken Metadata 中沒有 Bearer Token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken Token 格式錯誤、演算法不支援或簽章不符
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired Token 已過期
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims JWT Payload 中使用的欄位
+//
+// 結構:
+//
+//	Subject: 呼叫端身分 (sub)
+//	ExpiresAt: 過期時間 (exp, Unix 秒，0 表示不過期)
+//	IssuedAt: 簽發時間 (iat, Unix 秒)
+type Claims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// jwtHeader JWT Header (只支援 HS256)
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// claimsKey Context 中存放 Claims 的 Key
+type claimsKey struct{}
+
+// JWTInterceptor 驗證 Unary RPC 的 HS256 Bearer Token，通過後 Claims 存入 Context (見 ClaimsFromContext)
+// 驗證失敗回傳 codes.Unauthenticated
+func JWTInterceptor(secretKey []byte) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		claims, err := validateToken(md, secretKey)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// JWTStreamInterceptor 與 JWTInterceptor 相同，驗證串流 RPC 建立時的 Token
+func JWTStreamInterceptor(secretKey []byte) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		claims, err := validateToken(md, secretKey)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, &authenticatedStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), claimsKey{}, claims),
+		})
+	}
+}
+
+// authenticatedStream 以帶有 Claims 的 Context 取代原本串流的 Context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// ClaimsFromContext 取得 JWTInterceptor / JWTStreamInterceptor 驗證通過的 Claims
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// validateToken 從 Metadata 取出 Bearer Token 並驗證簽章與過期時間 (兩個 Interceptor 共用)
+//
+// 參數:
+//
+//	md: 請求的 Metadata (可為 nil)
+//	secretKey: HS256 簽章金鑰
+//
+// 回傳:
+//
+//	*Claims: Token 中的 Claims
+//	error: ErrMissingToken, ErrInvalidToken 或 ErrTokenExpired
+func validateToken(md metadata.MD, secretKey []byte) (*Claims, error) {
+	values := md.Get(AuthorizationHeader)
+	if len(values) == 0 || !strings.HasPrefix(values[0], bearerPrefix) {
+		return nil, ErrMissingToken
+	}
+	token := strings.TrimPrefix(values[0], bearerPrefix)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.ExpiresAt != 0 && time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// decodeSegment 解析 Base64URL (無 Padding) 編碼的 JSON 區段
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// SignToken 以 HS256 簽發 Token (供 Client 與測試工具使用)
+//
+// 參數:
+//
+//	claims: Token 中的 Claims
+//	secretKey: HS256 簽章金鑰
+//
+// 回傳:
+//
+//	string: JWT (不含 "Bearer " 前綴)
+//	error: 編碼錯誤
+func SignToken(claims Claims, secretKey []byte) (string, error) {
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
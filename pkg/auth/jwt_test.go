@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testSecret = []byte("test-secret")
+
+// fakeServerStream 只提供 Context 的 grpc.ServerStream
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestJWTInterceptors(t *testing.T) {
+	sign := func(claims Claims, secret []byte) string {
+		token, err := SignToken(claims, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bearerPrefix + token
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      codes.Code
+		wantSubject   string
+	}{
+		{name: "valid token", authorization: sign(Claims{Subject: "svc", ExpiresAt: now.Add(time.Hour).Unix()}, testSecret), wantCode: codes.OK, wantSubject: "svc"},
+		{name: "expired token", authorization: sign(Claims{Subject: "svc", ExpiresAt: now.Add(-time.Minute).Unix()}, testSecret), wantCode: codes.Unauthenticated},
+		{name: "missing token", wantCode: codes.Unauthenticated},
+		{name: "missing bearer prefix", authorization: sign(Claims{Subject: "svc"}, testSecret)[len(bearerPrefix):], wantCode: codes.Unauthenticated},
+		{name: "wrong secret", authorization: sign(Claims{Subject: "svc", ExpiresAt: now.Add(time.Hour).Unix()}, []byte("other-secret")), wantCode: codes.Unauthenticated},
+		{name: "malformed token", authorization: bearerPrefix + "not.a.jwt", wantCode: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationHeader, tt.authorization))
+		}
+
+		t.Run("unary/"+tt.name, func(t *testing.T) {
+			var subject string
+			_, err := JWTInterceptor(testSecret)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				claims, ok := ClaimsFromContext(ctx)
+				if !ok {
+					t.Fatal("handler called without claims in context")
+				}
+				subject = claims.Subject
+				return nil, nil
+			})
+			assertAuthResult(t, err, subject, tt.wantCode, tt.wantSubject)
+		})
+
+		t.Run("stream/"+tt.name, func(t *testing.T) {
+			var subject string
+			err := JWTStreamInterceptor(testSecret)(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv any, ss grpc.ServerStream) error {
+				claims, ok := ClaimsFromContext(ss.Context())
+				if !ok {
+					t.Fatal("handler called without claims in stream context")
+				}
+				subject = claims.Subject
+				return nil
+			})
+			assertAuthResult(t, err, subject, tt.wantCode, tt.wantSubject)
+		})
+	}
+}
+
+// assertAuthResult 檢查 Interceptor 回傳的狀態碼，以及通過時 handler 收到的 Subject
+func assertAuthResult(t *testing.T, err error, subject string, wantCode codes.Code, wantSubject string) {
+	t.Helper()
+	if got := status.Code(err); got != wantCode {
+		t.Fatalf("code = %v, want %v (err: %v)", got, wantCode, err)
+	}
+	if subject != wantSubject {
+		t.Fatalf("handler subject = %q, want %q", subject, wantSubject)
+	}
+}
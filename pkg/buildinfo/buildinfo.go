@@ -0,0 +1,19 @@
+// Package buildinfo 保存建置時注入的版本資訊
+//
+// 透過 go build -ldflags 設定，例如:
+//
+//	go build -ldflags "-X github.com/JoeShih716/go-mem-ledger/pkg/buildinfo.Version=v1.2.0 \
+//	  -X github.com/JoeShih716/go-mem-ledger/pkg/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/JoeShih716/go-mem-ledger/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// 未注入時 (如 go run) 為預設值
+package buildinfo
+
+var (
+	// Version 版本號
+	Version = "dev"
+	// Commit Git Commit Hash
+	Commit = "unknown"
+	// BuildTime 建置時間 (UTC, RFC 3339)
+	BuildTime = "unknown"
+)
@@ -0,0 +1,175 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 斷路器開啟中，呼叫被直接拒絕
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State 斷路器狀態
+type State int32
+
+const (
+	// StateClosed 正常放行，累計連續失敗次數
+	StateClosed State = iota
+	// StateOpen 直接拒絕所有呼叫，直到 OpenTimeout 過後進入 HalfOpen
+	StateOpen
+	// StateHalfOpen 放行少量試探呼叫，成功則關閉，失敗則重新開啟
+	StateHalfOpen
+)
+
+// String 回傳狀態名稱
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// 預設值
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 10 * time.Second
+	DefaultHalfOpenMaxCalls = 1
+)
+
+// Config 斷路器設定
+type Config struct {
+	FailureThreshold int           // 連續失敗幾次後開啟 (預設 5)
+	OpenTimeout      time.Duration // 開啟後多久進入 HalfOpen (預設 10 秒)
+	HalfOpenMaxCalls int           // HalfOpen 狀態下同時放行的試探呼叫數 (預設 1)
+	// IsFailure 判斷錯誤是否計入失敗 (例如業務錯誤不應觸發斷路)，nil 表示所有錯誤都計入
+	IsFailure func(err error) bool
+}
+
+// CircuitBreaker 三態斷路器 (Closed, Open, HalfOpen)
+// 它是執行緒安全的 (Thread-safe)
+type CircuitBreaker struct {
+	cfg Config
+
+	mu               sync.Mutex
+	state            State
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+	// generation 每次狀態轉換加一；呼叫結束時若已不是放行時的 generation，結果屬於先前的狀態而被忽略
+	generation uint64
+}
+
+// New 建立斷路器，未設定的欄位使用預設值
+func New(cfg Config) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultOpenTimeout
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = DefaultHalfOpenMaxCalls
+	}
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// Execute 透過斷路器執行 fn
+// 斷路器開啟時不執行 fn，直接回傳 ErrCircuitOpen；否則回傳 fn 的結果
+// fn panic 時同樣以 defer 呼叫 after 並視為失敗，不會一直佔用 HalfOpen 的試探名額
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	generation, err := cb.before()
+	if err != nil {
+		return err
+	}
+	failed := true
+	defer func() { cb.after(generation, failed) }()
+	err = fn()
+	failed = err != nil && (cb.cfg.IsFailure == nil || cb.cfg.IsFailure(err))
+	return err
+}
+
+// State 取得目前狀態
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.refreshLocked(time.Now())
+	return cb.state
+}
+
+// before 判斷是否放行本次呼叫，放行時回傳當下的 generation (交給 after)
+func (cb *CircuitBreaker) before() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshLocked(time.Now())
+	switch cb.state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.halfOpenInFlight >= cb.cfg.HalfOpenMaxCalls {
+			return 0, ErrCircuitOpen
+		}
+		cb.halfOpenInFlight++
+	}
+	return cb.generation, nil
+}
+
+// after 依呼叫結果更新狀態
+// 放行後斷路器已轉換過狀態 (generation 不同) 時忽略結果：
+// 例如 Closed 時放行、HalfOpen 時才結束的呼叫，不能佔用或釋放 HalfOpen 的試探名額
+func (cb *CircuitBreaker) after(generation uint64, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+	if cb.state == StateHalfOpen {
+		cb.halfOpenInFlight--
+		if failed {
+			cb.openLocked(time.Now())
+		} else {
+			cb.closeLocked()
+		}
+		return
+	}
+
+	if !failed {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.cfg.FailureThreshold {
+		cb.openLocked(time.Now())
+	}
+}
+
+// refreshLocked Open 超過 OpenTimeout 後轉為 HalfOpen (需持有 mu)
+func (cb *CircuitBreaker) refreshLocked(now time.Time) {
+	if cb.state == StateOpen && now.Sub(cb.openedAt) >= cb.cfg.OpenTimeout {
+		cb.state = StateHalfOpen
+		cb.halfOpenInFlight = 0
+		cb.generation++
+	}
+}
+
+// openLocked 開啟斷路器 (需持有 mu)
+func (cb *CircuitBreaker) openLocked(now time.Time) {
+	cb.state = StateOpen
+	cb.openedAt = now
+	cb.failures = 0
+	cb.generation++
+}
+
+// closeLocked 關閉斷路器 (需持有 mu)
+func (cb *CircuitBreaker) closeLocked() {
+	cb.state = StateClosed
+	cb.failures = 0
+	cb.generation++
+}
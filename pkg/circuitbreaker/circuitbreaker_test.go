@@ -0,0 +1,130 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBackend = errors.New("backend unavailable")
+
+// openTimeout 狀態轉換測試使用的 OpenTimeout (檢查 Open 狀態前不能先過期)
+const openTimeout = 100 * time.Millisecond
+
+// openBreaker 建立斷路器並以連續失敗將它開啟
+func openBreaker(t *testing.T, cfg Config) *CircuitBreaker {
+	t.Helper()
+	cb := New(cfg)
+	for i := 0; i < cb.cfg.FailureThreshold; i++ {
+		_ = cb.Execute(func() error { return errBackend })
+	}
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+	return cb
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cb := openBreaker(t, Config{FailureThreshold: 2, OpenTimeout: 10 * time.Millisecond})
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Execute while open = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if got := cb.State(); got != StateHalfOpen {
+		t.Fatalf("state after OpenTimeout = %v, want half-open", got)
+	}
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("probe call: %v", err)
+	}
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("state after successful probe = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerIgnoresNonFailures(t *testing.T) {
+	errBusiness := errors.New("insufficient balance")
+	cb := New(Config{FailureThreshold: 1, IsFailure: func(err error) bool { return !errors.Is(err, errBusiness) }})
+	_ = cb.Execute(func() error { return errBusiness })
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("state after business error = %v, want closed", got)
+	}
+}
+
+// Closed 時放行、斷路器轉為 HalfOpen 後才結束的呼叫，不能釋放試探名額或關閉斷路器
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	cb := New(Config{FailureThreshold: 1, OpenTimeout: openTimeout})
+
+	slowStarted := make(chan struct{})
+	releaseSlow := make(chan struct{})
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		_ = cb.Execute(func() error {
+			close(slowStarted)
+			<-releaseSlow
+			return nil
+		})
+	}()
+	<-slowStarted
+
+	// 其他呼叫失敗讓斷路器開啟，OpenTimeout 後進入 HalfOpen
+	_ = cb.Execute(func() error { return errBackend })
+	time.Sleep(2 * openTimeout)
+
+	// 佔用唯一的試探名額
+	probeStarted := make(chan struct{})
+	releaseProbe := make(chan struct{})
+	probeDone := make(chan error, 1)
+	go func() {
+		probeDone <- cb.Execute(func() error {
+			close(probeStarted)
+			<-releaseProbe
+			return errBackend
+		})
+	}()
+	<-probeStarted
+
+	// 舊的呼叫成功結束：不能把斷路器關閉，也不能讓出試探名額
+	close(releaseSlow)
+	<-slowDone
+	if got := cb.State(); got != StateHalfOpen {
+		t.Fatalf("state after stale success = %v, want half-open", got)
+	}
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second probe = %v, want ErrCircuitOpen (slot still held)", err)
+	}
+
+	close(releaseProbe)
+	<-probeDone
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("state after failed probe = %v, want open", got)
+	}
+}
+
+// 試探呼叫 panic 時要釋放名額，之後的試探不會一直被拒絕
+func TestCircuitBreakerPanicReleasesHalfOpenSlot(t *testing.T) {
+	cb := openBreaker(t, Config{FailureThreshold: 1, OpenTimeout: openTimeout})
+	time.Sleep(2 * openTimeout)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic was swallowed by Execute")
+			}
+		}()
+		_ = cb.Execute(func() error { panic("boom") })
+	}()
+
+	// panic 視為失敗：重新開啟，OpenTimeout 後可以再試探
+	if got := cb.State(); got != StateOpen {
+		t.Fatalf("state after panicking probe = %v, want open", got)
+	}
+	time.Sleep(2 * openTimeout)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("probe after panic: %v", err)
+	}
+	if got := cb.State(); got != StateClosed {
+		t.Fatalf("state = %v, want closed", got)
+	}
+}
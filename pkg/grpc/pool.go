@@ -1,8 +1,12 @@
 package grpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -15,13 +19,76 @@ import (
 // 它是執行緒安全的 (Thread-safe)，並確保每個目標地址只會維護一個連線實例。
 type Pool struct {
 	conns       sync.Map // map[string]*grpc.ClientConn
+	stats       sync.Map // map[string]*connStats (與 conns 同時更新)
 	mu          sync.Mutex
-	interceptor grpc.UnaryClientInterceptor // 全局的單一請求攔截器 (Optional)
+	interceptor grpc.UnaryClientInterceptor    // 全局的單一請求攔截器 (Optional)
+	streams     []grpc.StreamClientInterceptor // 全局的串流攔截器 (Optional)
+	stateHook   StateChangeHook                // 連線狀態變化時的回呼 (預設以 slog 記錄)
+	eagerDial   time.Duration                  // 大於 0 時 GetConnection 等待連線 READY 才回傳 (見 WithEagerDial)
+}
+
+// ConnStats 單一連線的使用狀況 (見 Pool.Stats)
+type ConnStats struct {
+	State      connectivity.State // 目前的連線狀態
+	CreatedAt  time.Time          // 連線建立時間
+	LastUsedAt time.Time          // 最後一次 GetConnection 或 RPC 的時間
+	RPCCount   int64              // 透過此連線發出的 RPC 數 (Unary 與 Stream)
+}
+
+// connStats 連線的使用紀錄 (以 atomic 更新，不需加鎖)
+type connStats struct {
+	createdAt  time.Time
+	lastUsedAt atomic.Int64 // UnixNano
+	rpcCount   atomic.Int64
+}
+
+// touch 更新最後使用時間
+func (s *connStats) touch() {
+	s.lastUsedAt.Store(time.Now().UnixNano())
+}
+
+// unaryInterceptor 計算 Unary RPC 次數
+func (s *connStats) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	s.rpcCount.Add(1)
+	s.touch()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// streamInterceptor 計算 Stream RPC 次數 (每個 Stream 計一次)
+func (s *connStats) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	s.rpcCount.Add(1)
+	s.touch()
+	return streamer(ctx, desc, cc, method, opts...)
 }
 
 // PoolOption 定義了 Pool 的配置選項函數
 type PoolOption func(*Pool)
 
+// StateChangeHook 連線狀態變化時的回呼函數
+type StateChangeHook func(target string, oldState, newState connectivity.State)
+
+// WithStateChangeHook 設定連線狀態變化時的回呼，取代預設的 slog 記錄
+// 傳入 nil 則不監看連線狀態。
+func WithStateChangeHook(hook StateChangeHook) PoolOption {
+	return func(p *Pool) {
+		p.stateHook = hook
+	}
+}
+
+// LogStateChange 預設的 StateChangeHook，以 slog 記錄連線狀態變化
+// 讓維運人員可以觀察到反覆斷線 (Flapping) 的連線。
+func LogStateChange(target string, oldState, newState connectivity.State) {
+	level := slog.LevelInfo
+	if newState == connectivity.TransientFailure {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "grpc connection state changed",
+		"target", target,
+		"from", oldState.String(),
+		"to", newState.String(),
+	)
+}
+
 // WithInterceptor 設定 Pool 的全局 UnaryClientInterceptor
 // 用於統一處理 Logging, Metrics, 或 Auth Token 注入。
 func WithInterceptor(interceptor grpc.UnaryClientInterceptor) PoolOption {
@@ -30,10 +97,30 @@ func WithInterceptor(interceptor grpc.UnaryClientInterceptor) PoolOption {
 	}
 }
 
+// WithStreamInterceptors 設定 Pool 的全局 StreamClientInterceptor (依傳入順序串接)
+// 用於串流 RPC (如 WatchBalance) 的 Tracing 或 Auth；可與 WithInterceptor 同時使用。
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) PoolOption {
+	return func(p *Pool) {
+		p.streams = append(p.streams, interceptors...)
+	}
+}
+
+// WithEagerDial 讓 GetConnection 立即建立連線並等待進入 READY 才回傳 (預設為 Lazy Connect)
+// timeout 限制每次 GetConnection 的等待時間：到期前未 READY 則回傳錯誤；
+// 連線是這次呼叫建立的才會關閉並移除 (不會留下半連線的狀態)，已被其他呼叫端取得的共用連線則保留由 gRPC 自行重連。
+// 與 WarmUp 不同，WarmUp 一次處理多個目標，WithEagerDial 作用於每次 GetConnection。
+func WithEagerDial(timeout time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.eagerDial = timeout
+	}
+}
+
 // NewPool 建立並回傳一個新的 gRPC 連線池。
 // 可以傳入多個 PoolOption 來配置連線池。
 func NewPool(opts ...PoolOption) *Pool {
-	p := &Pool{}
+	p := &Pool{
+		stateHook: LogStateChange,
+	}
 	for _, opt := range opts {
 		opt(p)
 	}
@@ -53,13 +140,43 @@ func NewPool(opts ...PoolOption) *Pool {
 //	*grpc.ClientConn: gRPC 客戶端連線物件
 //	error: 若建立連線失敗則回傳錯誤
 func (p *Pool) GetConnection(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, created, err := p.getOrCreate(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	stats, _ := p.stats.Load(target)
+	if stats != nil {
+		stats.(*connStats).touch()
+	}
+	if p.eagerDial <= 0 {
+		return conn, nil
+	}
+	// Eager Dial: 等待連線 READY (已 READY 的連線會立即回傳)
+	ctx, cancel := context.WithTimeout(context.Background(), p.eagerDial)
+	defer cancel()
+	if err := waitForReady(ctx, conn); err != nil {
+		if created {
+			// 只有這次呼叫建立的連線可以關閉，尚未交給其他呼叫端
+			if p.conns.CompareAndDelete(target, conn) {
+				p.stats.CompareAndDelete(target, stats)
+			}
+			_ = conn.Close()
+		}
+		return nil, fmt.Errorf("grpc eager dial %s: %w", target, err)
+	}
+	return conn, nil
+}
+
+// getOrCreate 取得現有連線或建立新連線 (Lazy，不等待連線建立)
+// created 為 true 代表連線是這次呼叫建立的
+func (p *Pool) getOrCreate(target string, opts ...grpc.DialOption) (conn *grpc.ClientConn, created bool, err error) {
 	// 1. 嘗試讀取現有連線 (Fast path)
 	if v, ok := p.conns.Load(target); ok {
 		conn := v.(*grpc.ClientConn)
 		// 檢查連線是否處於健康狀態 (或正在連線中)
 		// 如果連線已處於 Shutdown (已關閉) 狀態，我們需要建立新的連線。
 		if conn.GetState() != connectivity.Shutdown {
-			return conn, nil
+			return conn, false, nil
 		}
 		// 如果已關閉，從 map 中移除並繼續建立流程
 		p.conns.Delete(target)
@@ -73,7 +190,7 @@ func (p *Pool) GetConnection(target string, opts ...grpc.DialOption) (*grpc.Clie
 	if v, ok := p.conns.Load(target); ok {
 		conn := v.(*grpc.ClientConn)
 		if conn.GetState() != connectivity.Shutdown {
-			return conn, nil
+			return conn, false, nil
 		}
 		p.conns.Delete(target)
 	}
@@ -91,21 +208,122 @@ func (p *Pool) GetConnection(target string, opts ...grpc.DialOption) (*grpc.Clie
 		}),
 	}
 
-	// 如果有設定攔截器，則加入選項
+	// 使用統計 (最外層) 與全局攔截器
+	stats := &connStats{createdAt: time.Now()}
+	stats.touch()
+	unary := []grpc.UnaryClientInterceptor{stats.unaryInterceptor}
 	if p.interceptor != nil {
-		defaultOpts = append(defaultOpts, grpc.WithUnaryInterceptor(p.interceptor))
+		unary = append(unary, p.interceptor)
 	}
+	streams := append([]grpc.StreamClientInterceptor{stats.streamInterceptor}, p.streams...)
+	defaultOpts = append(defaultOpts,
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(streams...),
+	)
 
 	finalOpts := append(defaultOpts, opts...)
 	// 這裡建立的是一個「虛擬連線」，真正的網路連線會在第一次呼叫時才建立 (Lazy connection)
-	conn, err := grpc.NewClient(target, finalOpts...)
+	conn, err = grpc.NewClient(target, finalOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create grpc client for target %s: %w", target, err)
+		return nil, false, fmt.Errorf("failed to create grpc client for target %s: %w", target, err)
 	}
 
 	// 將新連線存入 map
+	p.stats.Store(target, stats)
 	p.conns.Store(target, conn)
-	return conn, nil
+
+	if p.stateHook != nil {
+		go p.watchState(target, conn)
+	}
+	return conn, true, nil
+}
+
+// WarmUp 預先建立連線並完成握手，避免第一個 RPC 承擔 TCP (+ TLS) 握手延遲。
+// 會等待所有連線進入 READY；ctx 到期前仍有連線未就緒則回傳錯誤。
+//
+// 參數:
+//
+//	ctx: context.Context - 控制等待期限 (建議帶 Timeout)
+//	targets: []string - 目標伺服器地址
+//
+// 回傳值:
+//
+//	error: 未能在期限內就緒的連線錯誤 (多個目標失敗時合併回傳)
+func (p *Pool) WarmUp(ctx context.Context, targets []string) error {
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		conn, err := p.GetConnection(target)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func(i int, target string, conn *grpc.ClientConn) {
+			defer wg.Done()
+			if err := waitForReady(ctx, conn); err != nil {
+				errs[i] = fmt.Errorf("grpc warm up %s: %w", target, err)
+			}
+		}(i, target, conn)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// waitForReady 觸發連線並等待進入 READY 狀態
+func waitForReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.New("connection closed")
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("last state %s: %w", state, ctx.Err())
+		}
+	}
+}
+
+// watchState 監看連線狀態，每次變化時呼叫 stateHook，直到連線關閉 (Shutdown) 為止
+func (p *Pool) watchState(target string, conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for state != connectivity.Shutdown {
+		if !conn.WaitForStateChange(context.Background(), state) {
+			return
+		}
+		newState := conn.GetState()
+		p.stateHook(target, state, newState)
+		state = newState
+	}
+}
+
+// Stats 回傳每個目標連線的狀態與使用狀況 (供 Metrics 與維運儀表板使用)
+// 長時間沒有更新 LastUsedAt 的連線代表該下游服務已不再使用
+//
+// 回傳值:
+//
+//	map[string]ConnStats: 以目標地址為 Key 的連線統計
+func (p *Pool) Stats() map[string]ConnStats {
+	result := make(map[string]ConnStats)
+	p.conns.Range(func(key, value any) bool {
+		target := key.(string)
+		conn := value.(*grpc.ClientConn)
+		stats := ConnStats{State: conn.GetState()}
+		if v, ok := p.stats.Load(target); ok {
+			s := v.(*connStats)
+			stats.CreatedAt = s.createdAt
+			stats.LastUsedAt = time.Unix(0, s.lastUsedAt.Load())
+			stats.RPCCount = s.rpcCount.Load()
+		}
+		result[target] = stats
+		return true
+	})
+	return result
 }
 
 // Close 關閉連線池中的所有連線。
@@ -119,6 +337,7 @@ func (p *Pool) Close() error {
 			firstErr = err // 記錄第一個發生的錯誤
 		}
 		p.conns.Delete(key)
+		p.stats.Delete(key)
 		return true
 	})
 	return firstErr
@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newBufconnServer 啟動一個只提供 Health 服務 (Unary Check + Stream Watch) 的記憶體 gRPC Server
+func newBufconnServer(t *testing.T) grpc.DialOption {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+func TestPoolUnaryAndStreamInterceptors(t *testing.T) {
+	dialer := newBufconnServer(t)
+
+	var unaryCalls, streamCalls atomic.Int32
+	pool := NewPool(
+		WithStateChangeHook(nil),
+		WithInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			unaryCalls.Add(1)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		WithStreamInterceptors(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			streamCalls.Add(1)
+			return streamer(ctx, desc, cc, method, opts...)
+		}),
+	)
+	defer pool.Close()
+
+	conn, err := pool.GetConnection("passthrough:///bufnet", dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := healthpb.NewHealthClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Watch Recv: %v", err)
+	}
+
+	if got := unaryCalls.Load(); got != 1 {
+		t.Errorf("unary interceptor called %d times, want 1", got)
+	}
+	if got := streamCalls.Load(); got != 1 {
+		t.Errorf("stream interceptor called %d times, want 1", got)
+	}
+	// 使用統計的攔截器同樣要執行 (不會被全局攔截器取代)
+	if got := pool.Stats()["passthrough:///bufnet"].RPCCount; got != 2 {
+		t.Errorf("RPCCount = %d, want 2", got)
+	}
+}
+
+// switchableDialer 可切換目標 Listener 的 Dialer (nil 代表目標無法連線)
+type switchableDialer struct {
+	lis atomic.Pointer[bufconn.Listener]
+}
+
+// serve 啟動新的 Health Server 並讓之後的連線都連到它
+func (d *switchableDialer) serve(t *testing.T) *grpc.Server {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	d.lis.Store(lis)
+	return s
+}
+
+func (d *switchableDialer) dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			lis := d.lis.Load()
+			if lis == nil {
+				return nil, errors.New("unreachable")
+			}
+			return lis.DialContext(ctx)
+		}),
+		// 縮短重連的退避時間，避免測試等待預設的 1 秒
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond},
+			MinConnectTimeout: 100 * time.Millisecond,
+		}),
+	}
+}
+
+// 目標無法連線時，GetConnection 在 timeout 後回傳錯誤，並移除這次建立的連線
+func TestPoolEagerDialTimeout(t *testing.T) {
+	const target = "passthrough:///unreachable"
+	var dialer switchableDialer
+	pool := NewPool(WithStateChangeHook(nil), WithEagerDial(100*time.Millisecond))
+	defer pool.Close()
+
+	start := time.Now()
+	conn, err := pool.GetConnection(target, dialer.dialOptions()...)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetConnection error = %v, want context.DeadlineExceeded", err)
+	}
+	if conn != nil {
+		t.Fatal("GetConnection returned a connection on timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("GetConnection took %v, want about the 100ms timeout", elapsed)
+	}
+	if _, ok := pool.Stats()[target]; ok {
+		t.Fatal("timed out connection still in the pool")
+	}
+}
+
+// 共用連線重連中逾時，只回傳錯誤，不能關閉其他呼叫端正在使用的連線
+func TestPoolEagerDialTimeoutKeepsSharedConnection(t *testing.T) {
+	const target = "passthrough:///bufnet"
+	var dialer switchableDialer
+	server := dialer.serve(t)
+	pool := NewPool(WithStateChangeHook(nil), WithEagerDial(500*time.Millisecond))
+	defer pool.Close()
+
+	shared, err := pool.GetConnection(target, dialer.dialOptions()...)
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+
+	// 下游中斷：之後的連線嘗試都失敗
+	dialer.lis.Store(nil)
+	server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !shared.WaitForStateChange(ctx, connectivity.Ready) {
+		t.Fatal("connection still READY after the server stopped")
+	}
+	if _, err := pool.GetConnection(target, dialer.dialOptions()...); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetConnection while unreachable error = %v, want context.DeadlineExceeded", err)
+	}
+	if state := shared.GetState(); state == connectivity.Shutdown {
+		t.Fatal("shared connection was closed by a timed out GetConnection")
+	}
+
+	// 下游恢復後，同一個連線重新 READY
+	dialer.serve(t)
+	conn, err := pool.GetConnection(target, dialer.dialOptions()...)
+	if err != nil {
+		t.Fatalf("GetConnection after recovery: %v", err)
+	}
+	if conn != shared {
+		t.Fatal("GetConnection after recovery returned a new connection")
+	}
+}
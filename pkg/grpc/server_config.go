@@ -0,0 +1,41 @@
+package grpc
+
+import "google.golang.org/grpc"
+
+// Server 預設限制
+const (
+	DefaultMaxConcurrentStreams uint32 = 1000
+	DefaultMaxMsgSize                  = 4 * 1024 * 1024 // 4MB
+)
+
+// ServerConfig 定義 gRPC Server 的資源限制與功能開關
+type ServerConfig struct {
+	MaxConcurrentStreams uint32 `yaml:"max_concurrent_streams"` // 每條連線最多同時處理的 Stream 數
+	MaxRecvMsgSize       int    `yaml:"max_recv_msg_size"`      // 單一請求最大位元組數 (避免超大批次請求造成 OOM)
+	MaxSendMsgSize       int    `yaml:"max_send_msg_size"`      // 單一回應最大位元組數
+	// EnableReflection 是否註冊 gRPC Reflection (預設關閉)
+	// Reflection 會讓任何連得到 Port 的人取得服務定義，只應在本機開發時開啟
+	EnableReflection bool `yaml:"enable_reflection"`
+}
+
+// SetDefaults 補全未設定 (為 0) 的欄位
+func (c *ServerConfig) SetDefaults() {
+	if c.MaxConcurrentStreams == 0 {
+		c.MaxConcurrentStreams = DefaultMaxConcurrentStreams
+	}
+	if c.MaxRecvMsgSize == 0 {
+		c.MaxRecvMsgSize = DefaultMaxMsgSize
+	}
+	if c.MaxSendMsgSize == 0 {
+		c.MaxSendMsgSize = DefaultMaxMsgSize
+	}
+}
+
+// ServerOptions 轉換為 grpc.NewServer 使用的選項
+func (c *ServerConfig) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxConcurrentStreams(c.MaxConcurrentStreams),
+		grpc.MaxRecvMsgSize(c.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.MaxSendMsgSize),
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LMAXStats LMAX 事件迴圈的處理統計
+// 欄位與 memory.LMAXStats 相同，可直接轉型：metrics.LMAXStats(ledger.Stats())
+type LMAXStats struct {
+	EventsProcessed uint64
+	EventsPerSecond float64
+	LastEventAt     time.Time
+	AvgProcessingNs int64
+}
+
+// LMAXCollector 將 LMAX 事件迴圈的統計以 Prometheus Gauge 匯出 (prometheus.Collector 實作)
+// 每次被抓取 (Scrape) 時呼叫 stats 讀取最新值，不需要另外的更新 goroutine
+type LMAXCollector struct {
+	stats           func() LMAXStats
+	eventsProcessed *prometheus.Desc
+	eventsPerSecond *prometheus.Desc
+	lastEventAt     *prometheus.Desc
+	avgProcessing   *prometheus.Desc
+}
+
+// NewLMAXCollector 建立 LMAXCollector
+//
+// 參數:
+//
+//	stats: 讀取事件迴圈統計的函數 (可由任意 goroutine 呼叫，如 LMAXLedger.Stats)
+//
+// 回傳:
+//
+//	*LMAXCollector: LMAXCollector 實例
+func NewLMAXCollector(stats func() LMAXStats) *LMAXCollector {
+	return &LMAXCollector{
+		stats: stats,
+		eventsProcessed: prometheus.NewDesc("ledger_lmax_events_processed",
+			"Number of transactions applied by the LMAX event loop (including business errors).", nil, nil),
+		eventsPerSecond: prometheus.NewDesc("ledger_lmax_events_per_second",
+			"Transactions applied per second, exponentially weighted over about 5 seconds.", nil, nil),
+		lastEventAt: prometheus.NewDesc("ledger_lmax_last_event_timestamp_seconds",
+			"Unix time of the last applied transaction (0 before the first one).", nil, nil),
+		avgProcessing: prometheus.NewDesc("ledger_lmax_avg_processing_seconds",
+			"Average time the event loop spends applying one transaction, excluding WAL writes.", nil, nil),
+	}
+}
+
+// Describe 實作 prometheus.Collector
+func (c *LMAXCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.eventsProcessed
+	ch <- c.eventsPerSecond
+	ch <- c.lastEventAt
+	ch <- c.avgProcessing
+}
+
+// Collect 實作 prometheus.Collector
+func (c *LMAXCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	var lastEventAt float64
+	if !stats.LastEventAt.IsZero() {
+		lastEventAt = float64(stats.LastEventAt.UnixNano()) / float64(time.Second)
+	}
+	ch <- prometheus.MustNewConstMetric(c.eventsProcessed, prometheus.GaugeValue, float64(stats.EventsProcessed))
+	ch <- prometheus.MustNewConstMetric(c.eventsPerSecond, prometheus.GaugeValue, stats.EventsPerSecond)
+	ch <- prometheus.MustNewConstMetric(c.lastEventAt, prometheus.GaugeValue, lastEventAt)
+	ch <- prometheus.MustNewConstMetric(c.avgProcessing, prometheus.GaugeValue, time.Duration(stats.AvgProcessingNs).Seconds())
+}
+
+var _ prometheus.Collector = (*LMAXCollector)(nil)
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLMAXCollector(t *testing.T) {
+	stats := LMAXStats{
+		EventsProcessed: 1500,
+		EventsPerSecond: 250.5,
+		LastEventAt:     time.Unix(1_700_000_000, 500_000_000),
+		AvgProcessingNs: 2_000,
+	}
+	collector := NewLMAXCollector(func() LMAXStats { return stats })
+
+	want := `
+# HELP ledger_lmax_avg_processing_seconds Average time the event loop spends applying one transaction, excluding WAL writes.
+# TYPE ledger_lmax_avg_processing_seconds gauge
+ledger_lmax_avg_processing_seconds 2e-06
+# HELP ledger_lmax_events_per_second Transactions applied per second, exponentially weighted over about 5 seconds.
+# TYPE ledger_lmax_events_per_second gauge
+ledger_lmax_events_per_second 250.5
+# HELP ledger_lmax_events_processed Number of transactions applied by the LMAX event loop (including business errors).
+# TYPE ledger_lmax_events_processed gauge
+ledger_lmax_events_processed 1500
+# HELP ledger_lmax_last_event_timestamp_seconds Unix time of the last applied transaction (0 before the first one).
+# TYPE ledger_lmax_last_event_timestamp_seconds gauge
+ledger_lmax_last_event_timestamp_seconds 1.7000000005e+09
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次抓取都讀取最新值；尚未處理交易時最後事件時間為 0
+	stats = LMAXStats{}
+	want = `
+# HELP ledger_lmax_last_event_timestamp_seconds Unix time of the last applied transaction (0 before the first one).
+# TYPE ledger_lmax_last_event_timestamp_seconds gauge
+ledger_lmax_last_event_timestamp_seconds 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "ledger_lmax_last_event_timestamp_seconds"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,9 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,9 +12,17 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// ErrConnectionValidationFailed Ping 成功但 ValidationQuery 執行失敗 (如代理可連線但後端資料庫不可用)
+var ErrConnectionValidationFailed = errors.New("mysql connection validation failed")
+
+// validationTimeout 執行 ValidationQuery 的超時時間 (ctx 的 Deadline 更短時以 ctx 為準)
+const validationTimeout = 2 * time.Second
+
 // Client 封裝 GORM DB 實例
 type Client struct {
 	db *gorm.DB
+	// 驗證連線的查詢 (見 Config.ValidationQuery)
+	validationQuery string
 }
 
 // NewClient 建立並回傳一個新的 MySQL 客戶端實例 (GORM)
@@ -27,9 +38,9 @@ type Client struct {
 func NewClient(cfg Config) (*Client, error) {
 	gormConfig := &gorm.Config{
 		// 預設跳過事務模式，顯著提升寫入效能 (除非業務邏輯明確需要 Transaction)
-		// 對於遊戲 Log 或狀態更新這類高頻操作很有幫助
-		SkipDefaultTransaction: true,
-		Logger:                 newLogger(cfg.LogLevel),
+		// 對於遊戲 Log 或狀態更新這類高頻操作很有幫助；可透過 Config.SkipDefaultTransaction 關閉
+		SkipDefaultTransaction: cfg.skipDefaultTransaction(),
+		Logger:                 newLogger(cfg),
 	}
 
 	var db *gorm.DB
@@ -62,6 +73,12 @@ func NewClient(cfg Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect to mysql after %d attempts: %w", maxRetries, err)
 	}
 
+	if cfg.TracerProvider != nil {
+		if err := db.Use(NewTracePlugin(cfg.TracerProvider)); err != nil {
+			return nil, fmt.Errorf("failed to register trace plugin: %w", err)
+		}
+	}
+
 	// 取得底層 sql.DB 物件以設定連線池
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -74,12 +91,63 @@ func NewClient(cfg Config) (*Client, error) {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
+	validationQuery := cfg.ValidationQuery
+	if validationQuery == "" {
+		validationQuery = DefaultValidationQuery
+	}
+	client := &Client{db: db, validationQuery: validationQuery}
+
 	// 測試連線
-	if err := sqlDB.Ping(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
 		return nil, fmt.Errorf("mysql ping failed: %w", err)
 	}
 
-	return &Client{db: db}, nil
+	return client, nil
+}
+
+// NewClientFromDB 以已建立的 GORM DB 建立 Client (不會重試連線或 Ping，主要供測試注入 sqlmock 使用)
+//
+// 參數:
+//
+//	db: *gorm.DB - 已開啟的 GORM DB
+//
+// 回傳值:
+//
+//	*Client: 封裝後的 MySQL 客戶端
+func NewClientFromDB(db *gorm.DB) *Client {
+	return &Client{db: db, validationQuery: DefaultValidationQuery}
+}
+
+// pingTimeout NewClient 測試連線的超時時間
+const pingTimeout = 5 * time.Second
+
+// Ping 檢查資料庫連線是否正常，供健康檢查 (Readiness Probe) 使用
+// Ping 成功後再執行 ValidationQuery，失敗時回傳 ErrConnectionValidationFailed
+func (c *Client) Ping(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+	if _, err := sqlDB.ExecContext(ctx, c.validationQuery); err != nil {
+		return fmt.Errorf("%w: %w", ErrConnectionValidationFailed, err)
+	}
+	return nil
+}
+
+// Stats 回傳連線池統計資訊
+func (c *Client) Stats() sql.DBStats {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
 }
 
 // DB 回傳底層的 *gorm.DB 實例，供業務邏輯層使用
@@ -97,9 +165,10 @@ func (c *Client) Close() error {
 }
 
 // newLogger 根據配置建立 GORM Logger
-func newLogger(level string) logger.Interface {
+// 設定 cfg.Logger 時使用 slog 結構化輸出，否則使用 GORM 預設的純文字 Logger
+func newLogger(cfg Config) logger.Interface {
 	var logLevel logger.LogLevel
-	switch level {
+	switch cfg.LogLevel {
 	case "info":
 		logLevel = logger.Info
 	case "warn":
@@ -112,5 +181,12 @@ func newLogger(level string) logger.Interface {
 		logLevel = logger.Error // 預設只記錄錯誤
 	}
 
+	if cfg.Logger != nil {
+		slowThreshold := cfg.SlowQueryThreshold
+		if slowThreshold == 0 {
+			slowThreshold = DefaultSlowQueryThreshold
+		}
+		return newStructuredLogger(logLevel, cfg.Logger, slowThreshold)
+	}
 	return logger.Default.LogMode(logLevel)
 }
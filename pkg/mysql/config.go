@@ -2,7 +2,10 @@ package mysql
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config 定義 MySQL 連線與連線池的配置
@@ -18,9 +21,32 @@ type Config struct {
 	MaxOpenConns    int           // 最大開啟連線數
 	MaxIdleConns    int           // 最大閒置連線數
 	ConnMaxLifetime time.Duration // 連線最大存活時間
+	// ValidationQuery 驗證連線的查詢 (預設 "SELECT 1")，NewClient 與 Ping 在 Ping 成功後執行
+	// 透過 ProxySQL 等代理連線時，Ping 只會到達代理，需實際執行查詢才能確認後端可用
+	ValidationQuery string `yaml:"validation_query"`
 
 	// GORM 設定
-	LogLevel string // Log 等級: "silent", "error", "warn", "info"
+	LogLevel           string               // Log 等級: "silent", "error", "warn", "info"
+	Logger             *slog.Logger         `yaml:"-"`                    // 設定後以 slog 輸出結構化 Log (nil 則使用 GORM 預設的純文字 Log)
+	SlowQueryThreshold time.Duration        `yaml:"slow_query_threshold"` // 慢查詢門檻 (預設 200ms)，搭配 Logger 使用
+	TracerProvider     trace.TracerProvider `yaml:"-"`                    // 設定後為每個查詢建立 OpenTelemetry Span (見 TracePlugin)
+	// SkipDefaultTransaction 是否跳過 GORM 對單一寫入自動包裝的 Transaction (nil 表示使用預設值 true)
+	// 需要 GORM 自動 Transaction (如關聯寫入) 時設為 false
+	SkipDefaultTransaction *bool `yaml:"skip_default_transaction"`
+
+	// 資料保留設定
+	TransactionRetentionDays int `yaml:"transaction_retention_days"` // 交易紀錄保留天數 (0 表示不清理)
+}
+
+// DefaultValidationQuery 未設定 ValidationQuery 時使用的查詢
+const DefaultValidationQuery = "SELECT 1"
+
+// skipDefaultTransaction 回傳 SkipDefaultTransaction 的實際值 (未設定時為 true)
+func (c *Config) skipDefaultTransaction() bool {
+	if c.SkipDefaultTransaction == nil {
+		return true
+	}
+	return *c.SkipDefaultTransaction
 }
 
 // DSN (Data Source Name) 產生連線字串
@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultSlowQueryThreshold 預設的慢查詢門檻
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// structuredLogger 以 slog 輸出結構化 Log 的 GORM Logger
+type structuredLogger struct {
+	level         logger.LogLevel
+	sl            *slog.Logger
+	slowThreshold time.Duration
+}
+
+// NewStructuredLogger 建立以 slog 輸出的 GORM Logger (搭配 slog.JSONHandler 即可輸出 JSON)
+// 慢查詢門檻為 DefaultSlowQueryThreshold
+//
+// 參數:
+//
+//	level: logger.LogLevel - GORM Log 等級
+//	sl: *slog.Logger - 輸出目標 (nil 時使用 slog.Default())
+//
+// 回傳值:
+//
+//	logger.Interface: GORM Logger
+func NewStructuredLogger(level logger.LogLevel, sl *slog.Logger) logger.Interface {
+	return newStructuredLogger(level, sl, DefaultSlowQueryThreshold)
+}
+
+// newStructuredLogger 建立以 slog 輸出的 GORM Logger (slowThreshold 為 0 時不記錄慢查詢)
+func newStructuredLogger(level logger.LogLevel, sl *slog.Logger, slowThreshold time.Duration) *structuredLogger {
+	if sl == nil {
+		sl = slog.Default()
+	}
+	return &structuredLogger{
+		level:         level,
+		sl:            sl,
+		slowThreshold: slowThreshold,
+	}
+}
+
+// LogMode 回傳指定等級的 Logger 複本
+func (l *structuredLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info 記錄一般訊息
+func (l *structuredLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.sl.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn 記錄警告訊息
+func (l *structuredLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.sl.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error 記錄錯誤訊息
+func (l *structuredLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.sl.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace 記錄每一筆 SQL
+// 錯誤 (不含 ErrRecordNotFound) 以 ERROR、超過慢查詢門檻以 WARN、Info 等級時其餘以 DEBUG 記錄
+func (l *structuredLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		query, rows := fc()
+		return []any{
+			slog.String("query", query),
+			slog.Int64("rows_affected", rows),
+			slog.Float64("duration_ms", float64(elapsed.Nanoseconds())/1e6),
+		}
+	}
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.sl.ErrorContext(ctx, "mysql query failed", append(attrs(), slog.Any("error", err))...)
+	case l.slowThreshold != 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		l.sl.WarnContext(ctx, "mysql slow query", attrs()...)
+	case l.level >= logger.Info:
+		l.sl.DebugContext(ctx, "mysql query", attrs()...)
+	}
+}
+
+var _ logger.Interface = (*structuredLogger)(nil)
@@ -0,0 +1,120 @@
+package mysql
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	// tracerName TracePlugin 建立 Tracer 使用的名稱
+	tracerName = "github.com/JoeShih716/go-mem-ledger/pkg/mysql"
+	// traceSpanKey 在 gorm.DB Instance 中保存 Span 的 Key
+	traceSpanKey = "mysql:trace_span"
+	// traceStartKey 在 gorm.DB Instance 中保存開始時間的 Key
+	traceStartKey = "mysql:trace_start"
+)
+
+// TracePlugin 以 GORM Plugin API 為每個查詢建立 OpenTelemetry Span
+// Span 屬性: db.statement (含 ? 佔位符，不含參數值)、db.rows_affected、db.duration_ms
+// Span 以 Statement.Context 為父 Context，因此呼叫端需使用 DB().WithContext(ctx)
+type TracePlugin struct {
+	tracer trace.Tracer
+}
+
+// NewTracePlugin 建立 TracePlugin
+//
+// 參數:
+//
+//	provider: trace.TracerProvider - OpenTelemetry TracerProvider
+//
+// 回傳值:
+//
+//	*TracePlugin: GORM Plugin (以 db.Use 註冊)
+func NewTracePlugin(provider trace.TracerProvider) *TracePlugin {
+	return &TracePlugin{tracer: provider.Tracer(tracerName)}
+}
+
+// Name 實作 gorm.Plugin
+func (p *TracePlugin) Name() string {
+	return "mysql:trace"
+}
+
+// Initialize 實作 gorm.Plugin，在每種操作的前後註冊 Callback
+func (p *TracePlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	register := []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range register {
+		if err := r.before("mysql:trace_before_"+r.operation, p.before(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("mysql:trace_after_"+r.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before 開始 Span 並放入 Statement.Context，讓之後的查詢成為子 Span
+func (p *TracePlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := p.tracer.Start(db.Statement.Context, "mysql."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "mysql"),
+				attribute.String("db.operation", operation),
+				attribute.String("db.sql.table", db.Statement.Table),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(traceSpanKey, span)
+		db.InstanceSet(traceStartKey, time.Now())
+	}
+}
+
+// after 記錄查詢結果並結束 Span
+func (p *TracePlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(traceSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	}
+	if start, ok := db.InstanceGet(traceStartKey); ok {
+		if t, ok := start.(time.Time); ok {
+			attrs = append(attrs, attribute.Float64("db.duration_ms", float64(time.Since(t).Microseconds())/1000))
+		}
+	}
+	span.SetAttributes(attrs...)
+
+	// 查無資料屬於正常結果，不標記為錯誤
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+var _ gorm.Plugin = (*TracePlugin)(nil)
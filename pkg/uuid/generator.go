@@ -0,0 +1,77 @@
+package uuid
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSize 預設每批預先產生的 UUID 數量
+const DefaultBatchSize = 1024
+
+// uuidSize 單一 UUID 的位元組長度
+const uuidSize = 16
+
+// UUIDGenerator 產生 UUID (Version 4) 的抽象
+type UUIDGenerator interface {
+	New() uuid.UUID
+}
+
+// RandomGenerator 每次呼叫都直接使用 uuid.New() (每個 UUID 各讀取一次 crypto/rand)
+type RandomGenerator struct{}
+
+// New 產生一個新的 UUID
+func (RandomGenerator) New() uuid.UUID {
+	return uuid.New()
+}
+
+// BatchGenerator 一次從 crypto/rand 讀取 batchSize 個 UUID 所需的隨機資料
+// 之後的 New 直接從緩衝區切出，將 syscall 次數降為約 1/batchSize
+// 它是執行緒安全的 (Thread-safe)
+type BatchGenerator struct {
+	mu  sync.Mutex
+	buf []byte
+	pos int
+}
+
+// NewBatchGenerator 建立一個批次 UUID 產生器
+// 帶0 則使用預設值DefaultBatchSize
+func NewBatchGenerator(batchSize int) *BatchGenerator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	buf := make([]byte, batchSize*uuidSize)
+	return &BatchGenerator{
+		buf: buf,
+		pos: len(buf), // 第一次呼叫時才讀取
+	}
+}
+
+// New 產生一個新的 UUID (Version 4, RFC 4122 Variant)
+// 與 uuid.New 相同，讀取 crypto/rand 失敗時會 panic
+func (g *BatchGenerator) New() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pos >= len(g.buf) {
+		if _, err := io.ReadFull(rand.Reader, g.buf); err != nil {
+			panic(err)
+		}
+		g.pos = 0
+	}
+
+	var id uuid.UUID
+	copy(id[:], g.buf[g.pos:g.pos+uuidSize])
+	g.pos += uuidSize
+
+	id[6] = (id[6] & 0x0f) | 0x40 // Version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // Variant 10
+	return id
+}
+
+var (
+	_ UUIDGenerator = RandomGenerator{}
+	_ UUIDGenerator = (*BatchGenerator)(nil)
+)
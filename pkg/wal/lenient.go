@@ -0,0 +1,125 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// SkippedEntry 寬鬆讀取時略過的損毀紀錄
+//
+// 結構:
+//
+//	Offset: 損毀紀錄在檔案中的位元組位置
+//	Length: 損毀紀錄的位元組數 (含換行)
+type SkippedEntry struct {
+	Offset int64
+	Length int64
+}
+
+// OpenReader 以唯讀模式開啟 WAL 檔案 (不需要先建立 WAL，供離線工具使用)
+//
+// 參數:
+//
+//	path: WAL 檔案路徑
+//
+// 回傳:
+//
+//	*WALReader: 讀取器 (使用完畢需呼叫 Close)
+//	error: 開啟錯誤
+func OpenReader(path string) (*WALReader, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &WALReader{file: file}, nil
+}
+
+// ReadAllLenient 寬鬆模式讀取：以換行切分紀錄，無法解析的紀錄交給 onSkip 後繼續讀取下一筆
+// ReadAll 遇到損毀紀錄會直接回傳錯誤；此方法用於修復工具盡可能救回資料
+// 檔案開頭的格式版本 Header 不會交給 callback
+//
+// 參數:
+//
+//	ctx: 上下文
+//	callback: 處理每筆可解析紀錄的函式
+//	onSkip: 每略過一筆損毀紀錄時呼叫；可為 nil
+//
+// 回傳:
+//
+//	error: 讀取或 callback 錯誤 (損毀紀錄本身不算錯誤)
+func (r *WALReader) ReadAllLenient(ctx context.Context, callback func(jsonRaw []byte) error, onSkip func(SkippedEntry)) error {
+	return r.ReadAllLenientWithOffset(ctx,
+		func(jsonRaw []byte, _ int64) error {
+			return callback(jsonRaw)
+		},
+		func(entry SkippedEntry) error {
+			if onSkip != nil {
+				onSkip(entry)
+			}
+			return nil
+		},
+	)
+}
+
+// ReadAllLenientWithOffset 與 ReadAllLenient 相同，並將每筆紀錄所在的位元組位置交給 callback
+// onSkip 回傳錯誤時停止讀取 (例如恢復時遇到第一筆損毀紀錄就停止)，呼叫端可依位置截斷 WAL (見 WAL.TruncateTail)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	callback: 處理每筆可解析紀錄的函式 (offset 為該筆紀錄所在行的開頭)
+//	onSkip: 每略過一筆損毀紀錄時呼叫，回傳錯誤則停止讀取
+//
+// 回傳:
+//
+//	error: 讀取、callback 或 onSkip 錯誤
+func (r *WALReader) ReadAllLenientWithOffset(ctx context.Context, callback func(jsonRaw []byte, offset int64) error, onSkip func(SkippedEntry) error) error {
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(r.file, DefaultBufferSize)
+	var offset int64
+	first := true
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		lineOffset := offset
+		offset += int64(len(line))
+
+		raw := bytes.TrimSpace(line)
+		switch {
+		case len(raw) == 0:
+			// 空行不算紀錄
+		case !json.Valid(raw):
+			if err := onSkip(SkippedEntry{Offset: lineOffset, Length: int64(len(line))}); err != nil {
+				return err
+			}
+		default:
+			isHeader := false
+			if first {
+				_, isHeader = parseHeader(raw)
+			}
+			if !isHeader {
+				if err := callback(raw, lineOffset); err != nil {
+					return err
+				}
+			}
+		}
+		if len(raw) > 0 {
+			first = false
+		}
+		if readErr != nil {
+			return nil
+		}
+	}
+}
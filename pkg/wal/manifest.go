@@ -0,0 +1,195 @@
+package wal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSegmentChecksumMismatch Segment 檔案內容與 Manifest 記錄的 SHA-256 不符
+var ErrSegmentChecksumMismatch = errors.New("wal segment checksum mismatch")
+
+// SegmentMeta 單一 WAL Segment 的描述資料
+type SegmentMeta struct {
+	Filename      string `json:"filename"` // 相對於 Manifest 所在目錄的檔名
+	FirstSequence uint64 `json:"first_sequence"`
+	LastSequence  uint64 `json:"last_sequence"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SHA256        string `json:"sha256"`
+	Timestamp     int64  `json:"timestamp"` // Segment 封存時間 (Unix 毫秒)
+}
+
+// Manifest 記錄所有 WAL Segment 及其順序
+// 恢復時依 Manifest 的順序讀取，而非依賴檔案系統時間 (掛載 Volume 後時間可能不正確)
+type Manifest struct {
+	Segments []SegmentMeta `json:"segments"`
+}
+
+// NewSegmentMeta 計算 Segment 檔案的大小與 SHA-256，建立描述資料
+//
+// 參數:
+//
+//	path: Segment 檔案路徑
+//	firstSequence: Segment 中第一筆交易的順序號
+//	lastSequence: Segment 中最後一筆交易的順序號
+//
+// 回傳:
+//
+//	SegmentMeta: 描述資料 (Filename 只保留檔名)
+//	error: 讀檔錯誤
+func NewSegmentMeta(path string, firstSequence, lastSequence uint64) (SegmentMeta, error) {
+	sum, size, err := fileChecksum(path)
+	if err != nil {
+		return SegmentMeta{}, err
+	}
+	return SegmentMeta{
+		Filename:      filepath.Base(path),
+		FirstSequence: firstSequence,
+		LastSequence:  lastSequence,
+		SizeBytes:     size,
+		SHA256:        sum,
+		Timestamp:     time.Now().UnixMilli(),
+	}, nil
+}
+
+// LoadManifest 讀取 Manifest 檔案，檔案不存在時回傳空的 Manifest
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Manifest{}, nil
+		}
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Save 以原子方式寫入 Manifest (先寫暫存檔並 Sync，再 Rename 取代)
+// 中途崩潰只會留下舊的或新的 Manifest，不會是寫一半的檔案
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, FileModeReadOnly)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Append 新增一個 Segment 並原子寫入 Manifest (Segment 輪替時呼叫)
+func (m *Manifest) Append(path string, segment SegmentMeta) error {
+	m.Segments = append(m.Segments, segment)
+	return m.Save(path)
+}
+
+// RecoverFromManifest 依 Manifest 記錄的順序讀取所有 Segment
+// 讀取前會核對每個 Segment 的 SHA-256，不符時回傳 ErrSegmentChecksumMismatch
+//
+// 參數:
+//
+//	ctx: 上下文 (可中斷恢復)
+//	manifestPath: Manifest 檔案路徑，Segment 檔案需位於同一目錄
+//	callback: 每筆資料的回呼，與 WAL.ReadAll 相同
+//
+// 回傳:
+//
+//	error: 讀取或核對錯誤
+func RecoverFromManifest(ctx context.Context, manifestPath string, callback func(jsonRaw []byte) error) error {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(manifestPath)
+	for _, segment := range manifest.Segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, segment.Filename)
+		sum, _, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		if sum != segment.SHA256 {
+			return fmt.Errorf("%w: %s", ErrSegmentChecksumMismatch, segment.Filename)
+		}
+
+		if err := readSegment(path, callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSegment 以唯讀模式讀取單一 Segment 的所有資料
+func readSegment(path string, callback func(jsonRaw []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	first := true
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if first {
+			first = false
+			if _, ok := parseHeader(raw); ok {
+				continue
+			}
+		}
+		if err := callback(raw); err != nil {
+			return err
+		}
+	}
+}
+
+// fileChecksum 計算檔案的 SHA-256 (hex) 與大小
+func fileChecksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
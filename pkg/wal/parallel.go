@@ -0,0 +1,143 @@
+package wal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// ParallelWAL 將寫入分散到同一個目錄下的 N 個 WAL 檔案 (wal-0.log, wal-1.log, ...)，減少單一 WAL mu 的競爭
+// 適用於多分片 (Shard) 的帳本：各分片以 WriteTo 寫入自己的分區；Write 則輪流分配到各分區
+// 紀錄需帶有順序號 ("seq" 或 "Sequence")，ReadAll 才能依順序號還原全域順序
+type ParallelWAL struct {
+	partitions []*WAL
+	// Write 輪流分配分區用的計數器
+	next atomic.Uint64
+}
+
+// partitionFileName 第 i 個分區的檔案名稱
+func partitionFileName(i int) string {
+	return fmt.Sprintf("wal-%d.log", i)
+}
+
+// NewParallelWAL 在 dir 中開啟或建立 n 個 WAL 分區
+// 重新開啟既有目錄時 n 必須與建立時相同，否則多出的分區不會被讀取
+//
+// 參數:
+//
+//	dir: WAL 目錄 (不存在時建立)
+//	n: 分區數量
+//	bufferSize: 每個分區的 Buffer 大小 (0 使用 DefaultBufferSize)
+//
+// 回傳:
+//
+//	*ParallelWAL: ParallelWAL 實例
+//	error: 建立目錄或開啟檔案的錯誤
+func NewParallelWAL(dir string, n int, bufferSize int) (*ParallelWAL, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("wal: partition count must be positive, got %d", n)
+	}
+	if err := os.MkdirAll(dir, FileModeExecutable); err != nil {
+		return nil, err
+	}
+	p := &ParallelWAL{partitions: make([]*WAL, 0, n)}
+	for i := 0; i < n; i++ {
+		w, err := NewWALFromFile(filepath.Join(dir, partitionFileName(i)), bufferSize)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.partitions = append(p.partitions, w)
+	}
+	return p, nil
+}
+
+// Partitions 回傳分區數量
+func (p *ParallelWAL) Partitions() int {
+	return len(p.partitions)
+}
+
+// Write 寫入一筆資料到下一個分區 (輪流分配，需呼叫 Flush 才會刷入硬碟)
+// Go 沒有可用的 Goroutine ID，因此以計數器輪流分配；需要固定分區時使用 WriteTo
+func (p *ParallelWAL) Write(ctx context.Context, v any) error {
+	idx := (p.next.Add(1) - 1) % uint64(len(p.partitions))
+	return p.partitions[idx].Write(ctx, v)
+}
+
+// WriteTo 寫入一筆資料到指定分區 (分片帳本以分片編號寫入自己的分區)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	partition: 分區編號 (以 Partitions 取餘數)
+//	v: 要寫入的資料
+//
+// 回傳:
+//
+//	error: 寫入錯誤
+func (p *ParallelWAL) WriteTo(ctx context.Context, partition int, v any) error {
+	n := len(p.partitions)
+	return p.partitions[((partition%n)+n)%n].Write(ctx, v)
+}
+
+// Flush 將所有分區的緩衝區刷入硬碟 (回傳所有分區的錯誤)
+func (p *ParallelWAL) Flush() error {
+	var errs []error
+	for _, w := range p.partitions {
+		if err := w.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadAll 讀取所有分區並依順序號遞增交給 callback (沒有順序號的紀錄視為 0，排在最前面)
+// 同一分區內的順序號不保證遞增 (並行寫入時可能交錯)，因此先讀入所有紀錄再排序，記憶體用量與 WAL 大小成正比
+func (p *ParallelWAL) ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error {
+	type entry struct {
+		sequence uint64
+		raw      []byte
+	}
+	var entries []entry
+	for _, w := range p.partitions {
+		err := w.ReadAll(ctx, func(jsonRaw []byte) error {
+			sequence, _ := sequenceOf(jsonRaw)
+			entries = append(entries, entry{sequence: sequence, raw: bytes.Clone(jsonRaw)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// 穩定排序：順序號相同 (或都沒有順序號) 時保留分區與寫入的先後
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].sequence < entries[j].sequence
+	})
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := callback(e.raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 關閉所有分區 (會先 Flush)
+func (p *ParallelWAL) Close() error {
+	var errs []error
+	for _, w := range p.partitions {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var _ Writer = (*ParallelWAL)(nil)
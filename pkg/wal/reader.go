@@ -0,0 +1,66 @@
+package wal
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrNoFilePath WAL 不是由檔案建立 (NewWALFromRWS)，無法開啟獨立的讀取 Handle
+var ErrNoFilePath = errors.New("wal has no file path")
+
+// WALReader 使用獨立 File Descriptor (O_RDONLY) 讀取 WAL 檔案
+// 不會取得 WAL 的 mu，讀取期間不影響 Write / Flush 的吞吐量 (例如讓稽核程式持續 Tail WAL)
+// 只讀得到已 Flush 的資料；WALReader 本身不是執行緒安全的，每個讀取者應使用自己的 WALReader
+type WALReader struct {
+	file *os.File
+}
+
+// NewReader 以唯讀模式另外開啟同一個 WAL 檔案
+//
+// 回傳:
+//
+//	*WALReader: 讀取器 (使用完畢需呼叫 Close)
+//	error: 開啟錯誤；WAL 不是由檔案建立時回傳 ErrNoFilePath
+func (w *WAL) NewReader() (*WALReader, error) {
+	if w.path == "" {
+		return nil, ErrNoFilePath
+	}
+	return OpenReader(w.path)
+}
+
+// ReadAll 從頭讀取所有已寫入硬碟的紀錄 (略過格式版本 Header)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	callback: 處理每筆紀錄的函式
+//
+// 回傳:
+//
+//	error: 讀取或 callback 錯誤
+func (r *WALReader) ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error {
+	_, err := readFrom(ctx, r.file, 0, callback)
+	return err
+}
+
+// ReadFrom 從指定的位元組位置開始讀取，回傳下次讀取的位置 (語意同 WAL.ReadFrom)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	offset: 開始讀取的位元組位置 (第一次為 0)
+//	callback: 處理每筆紀錄的函式
+//
+// 回傳:
+//
+//	int64: 下次讀取的位置
+//	error: 讀取或 callback 錯誤
+func (r *WALReader) ReadFrom(ctx context.Context, offset int64, callback func(jsonRaw []byte) error) (int64, error) {
+	return readFrom(ctx, r.file, offset, callback)
+}
+
+// Close 關閉讀取用的 File Descriptor
+func (r *WALReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,90 @@
+package wal
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ReadAllStats WAL 紀錄統計 (依交易類型)
+//
+// 結構:
+//
+//	Total: 紀錄總數 (不含格式版本 Header)
+//	Deposits: 存款筆數
+//	Withdraws: 提款筆數
+//	Transfers: 轉帳筆數
+//	Unknowns: 其他類型 (如建立帳戶、跨幣別轉帳) 或無法解析的紀錄
+type ReadAllStats struct {
+	Total     int
+	Deposits  int
+	Withdraws int
+	Transfers int
+	Unknowns  int
+}
+
+// entryType 只解析紀錄中的交易類型 (新格式使用縮寫 "tp"，舊格式使用完整名稱 "Type")
+type entryType struct {
+	Compact json.RawMessage `json:"tp"`
+	Legacy  json.RawMessage `json:"Type"`
+}
+
+// 交易類型的 JSON 表示 (對應 domain.TransactionType：舊紀錄為數字，新紀錄為名稱)
+var (
+	depositTypes  = []string{`1`, `"deposit"`}
+	withdrawTypes = []string{`2`, `"withdraw"`}
+	transferTypes = []string{`3`, `"transfer"`}
+)
+
+// Count 掃描所有紀錄並依交易類型計數 (只解析類型欄位，供健康檢查與稽核報表使用)
+// 無法解析的紀錄計入 Unknowns，不會中斷掃描
+//
+// 參數:
+//
+//	ctx: 上下文
+//
+// 回傳:
+//
+//	ReadAllStats: 統計結果
+//	error: 讀取錯誤
+func (w *WAL) Count(ctx context.Context) (ReadAllStats, error) {
+	var stats ReadAllStats
+	err := w.ReadAll(ctx, func(jsonRaw []byte) error {
+		stats.add(jsonRaw)
+		return nil
+	})
+	return stats, err
+}
+
+// add 依紀錄的交易類型累加計數
+func (s *ReadAllStats) add(jsonRaw []byte) {
+	s.Total++
+	var entry entryType
+	if err := json.Unmarshal(jsonRaw, &entry); err != nil {
+		s.Unknowns++
+		return
+	}
+	raw := entry.Compact
+	if raw == nil {
+		raw = entry.Legacy
+	}
+	switch {
+	case matchesType(raw, depositTypes):
+		s.Deposits++
+	case matchesType(raw, withdrawTypes):
+		s.Withdraws++
+	case matchesType(raw, transferTypes):
+		s.Transfers++
+	default:
+		s.Unknowns++
+	}
+}
+
+// matchesType 類型欄位是否為 values 其中之一
+func matchesType(raw json.RawMessage, values []string) bool {
+	for _, v := range values {
+		if string(raw) == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package wal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// entrySequence 只解析紀錄中的順序號 (新格式使用縮寫 "seq"，舊格式使用完整名稱 "Sequence")
+type entrySequence struct {
+	Compact *uint64 `json:"seq"`
+	Legacy  *uint64 `json:"Sequence"`
+}
+
+// sequenceOf 取得紀錄的順序號 (沒有順序號或無法解析時 ok 為 false)
+func sequenceOf(raw []byte) (uint64, bool) {
+	var entry entrySequence
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return 0, false
+	}
+	switch {
+	case entry.Compact != nil:
+		return *entry.Compact, true
+	case entry.Legacy != nil:
+		return *entry.Legacy, true
+	}
+	return 0, false
+}
+
+// TruncateAfter 壓縮 WAL：只保留順序號大於 keepAfterSequence 的紀錄 (檢查點之前的紀錄已不需要重放)
+// 先將保留的紀錄寫入暫存檔並 Sync，再以 os.Rename 原子取代原檔；中途崩潰只會留下舊的或新的檔案
+// 沒有順序號或無法解析的紀錄一律保留；執行期間持有鎖，Write / Flush 會等待
+// 只支援 NewWALFromFile 建立的 WAL
+//
+// 參數:
+//
+//	ctx: 上下文
+//	keepAfterSequence: 保留順序號大於此值的紀錄 (通常為檢查點的順序號)
+//
+// 回傳:
+//
+//	error: ErrNoFilePath，或讀寫、取代檔案的錯誤
+func (w *WAL) TruncateAfter(ctx context.Context, keepAfterSequence uint64) error {
+	if w.path == "" {
+		return ErrNoFilePath
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 先將緩衝區的紀錄寫入，確保它們也會被複製
+	if err := w.flushLocked(); err != nil {
+		w.discardLocked()
+		return err
+	}
+
+	tmpPath := w.path + ".tmp"
+	if err := w.writeKeptEntries(ctx, tmpPath, keepAfterSequence); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(w.path))
+	return w.reopenLocked()
+}
+
+// TruncateTail 捨棄 offset 之後的所有資料，之後的寫入從 offset 開始
+// 用於恢復時移除尾端的損毀紀錄 (位置由 WALReader.ReadAllLenientWithOffset 取得)，應在接受寫入前呼叫；
+// 會先 Flush 緩衝區中的資料，offset 應位於紀錄的邊界
+//
+// 參數:
+//
+//	offset: 保留的資料長度 (位元組)
+//
+// 回傳:
+//
+//	error: Flush 或截斷錯誤 (儲存不支援 Truncate 時也會回傳錯誤)
+func (w *WAL) TruncateTail(offset int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.flushLocked(); err != nil {
+		w.discardLocked()
+		return err
+	}
+	return w.truncateLocked(offset)
+}
+
+// writeKeptEntries 將要保留的紀錄 (含格式版本 Header) 寫入 tmpPath 並 Sync (需持有 mu)
+func (w *WAL) writeKeptEntries(ctx context.Context, tmpPath string, keepAfterSequence uint64) error {
+	if _, err := w.rws.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = w.rws.Seek(0, io.SeekEnd)
+	}()
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, FileModeReadOnly)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriterSize(file, DefaultBufferSize)
+
+	decoder := json.NewDecoder(w.rws)
+	first := true
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				// 尾端寫一半的紀錄不會被複製
+				break
+			}
+			return err
+		}
+		isHeader := false
+		if first {
+			first = false
+			// Header 原樣保留 (舊格式檔案沒有 Header，也不補上)
+			_, isHeader = parseHeader(raw)
+		}
+		if !isHeader {
+			if sequence, ok := sequenceOf(raw); ok && sequence <= keepAfterSequence {
+				continue
+			}
+		}
+		if _, err := out.Write(raw); err != nil {
+			return err
+		}
+		if err := out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// reopenLocked Rename 後關閉舊檔案並重新開啟新檔案 (需持有 mu)
+func (w *WAL) reopenLocked() error {
+	if closer, ok := w.rws.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, FileModeReadOnly)
+	if err != nil {
+		return fmt.Errorf("wal: reopen after truncate: %w", err)
+	}
+	w.rws = file
+	w.writer.Reset(file)
+	w.headerChecked = false
+	return nil
+}
+
+// syncDir 將目錄項目 (Rename 結果) 刷入硬碟，失敗時忽略 (部分檔案系統不支援)
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,95 @@
+package wal
+
+import (
+	"context"
+	"sync"
+)
+
+// TypedReaderOption 定義了 TypedReader 的配置選項函數
+type TypedReaderOption func(*typedReaderConfig)
+
+type typedReaderConfig struct {
+	progressFn    ProgressFunc
+	onDecodeError func(jsonRaw []byte, err error) error
+}
+
+// WithReadProgress 讀取過程中回報進度 (來源需實作 ProgressReader，否則忽略)
+func WithReadProgress(fn ProgressFunc) TypedReaderOption {
+	return func(c *typedReaderConfig) {
+		c.progressFn = fn
+	}
+}
+
+// WithDecodeErrorHandler 設定解析失敗時的處理方式
+// fn 回傳 nil 時略過該筆紀錄繼續讀取，回傳錯誤則停止讀取；未設定時直接回傳解析錯誤
+func WithDecodeErrorHandler(fn func(jsonRaw []byte, err error) error) TypedReaderOption {
+	return func(c *typedReaderConfig) {
+		c.onDecodeError = fn
+	}
+}
+
+// TypedReader 將 WAL 紀錄解析為 T 後交給 callback，呼叫端不需要自行 Unmarshal
+// T 物件以 sync.Pool 重用，減少大型 WAL 恢復時的 GC 壓力：callback 回傳後 *T 會被重用，需保留時請複製
+type TypedReader[T any] struct {
+	source Writer
+	decode func(jsonRaw []byte, v *T) error
+	config typedReaderConfig
+	pool   sync.Pool
+}
+
+// NewTypedReader 建立 TypedReader
+//
+// 參數:
+//
+//	source: WAL (使用 ReadAll，設定 WithReadProgress 且支援時使用 ReadAllWithProgress)
+//	decode: 將一筆紀錄解析至 v (v 已重設為零值)
+//	opts: 可選的配置選項
+//
+// 回傳:
+//
+//	*TypedReader[T]: TypedReader 實例
+func NewTypedReader[T any](source Writer, decode func(jsonRaw []byte, v *T) error, opts ...TypedReaderOption) *TypedReader[T] {
+	r := &TypedReader[T]{
+		source: source,
+		decode: decode,
+		pool: sync.Pool{
+			New: func() any {
+				return new(T)
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(&r.config)
+	}
+	return r
+}
+
+// Read 依序讀取並解析所有紀錄
+//
+// 參數:
+//
+//	ctx: 上下文
+//	callback: 處理每筆解析後紀錄的函式 (回傳後 v 會被重用)
+//
+// 回傳:
+//
+//	error: 讀取、解析或 callback 錯誤
+func (r *TypedReader[T]) Read(ctx context.Context, callback func(v *T) error) error {
+	handle := func(jsonRaw []byte) error {
+		v := r.pool.Get().(*T)
+		defer r.pool.Put(v)
+		var zero T
+		*v = zero
+		if err := r.decode(jsonRaw, v); err != nil {
+			if r.config.onDecodeError != nil {
+				return r.config.onDecodeError(jsonRaw, err)
+			}
+			return err
+		}
+		return callback(v)
+	}
+	if reader, ok := r.source.(ProgressReader); ok && r.config.progressFn != nil {
+		return reader.ReadAllWithProgress(ctx, handle, r.config.progressFn)
+	}
+	return r.source.ReadAll(ctx, handle)
+}
@@ -2,10 +2,17 @@ package wal
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"sync"
+	"time"
 )
 
 // 自己定義常用的權限常量
@@ -22,83 +29,521 @@ const (
 
 const (
 	DefaultBufferSize = 64 * 1024 // 64KB Buffer
+
+	// FlushTimeout Close 等待緩衝區刷入硬碟的最長時間
+	FlushTimeout = 5 * time.Second
+
+	// ReadAllWithProgress 回報進度的頻率 (先到者為準)
+	progressEntryInterval = 10000
+	progressTimeInterval  = time.Second
+)
+
+// WAL 檔案格式版本
+const (
+	// FormatVersionLegacy 沒有 Header 的舊格式 (交易欄位使用完整名稱)
+	FormatVersionLegacy = 1
+	// FormatVersionCompact 檔案開頭有 Header，交易欄位使用縮寫 JSON Tag
+	FormatVersionCompact = 2
+	// CurrentFormatVersion 新建立的 WAL 檔案使用的格式版本
+	CurrentFormatVersion = FormatVersionCompact
 )
 
+// header WAL 檔案的第一筆紀錄，標示格式版本 (ReadAll 不會交給 callback)
+type header struct {
+	Version *int `json:"wal_version"`
+}
+
+// ErrTornEntry WAL 尾端有只寫入一部分的紀錄且無法截斷 (儲存不支援 Truncate，或讀取期間已有新的寫入)
+var ErrTornEntry = errors.New("wal: partially written entry")
+
+// errTruncateNotSupported 儲存不支援 Truncate (如測試用的記憶體實作)
+var errTruncateNotSupported = errors.New("storage does not support truncate")
+
 type WAL struct {
-	file   *os.File
+	// 底層儲存 (正式環境為 *os.File，測試可傳入記憶體實作)
+	rws    io.ReadWriteSeeker
 	writer *bufio.Writer
 	mu     sync.Mutex
+	// 是否已確認過 Header (空檔案第一次寫入前會先寫 Header)
+	headerChecked bool
+	// 最近一次 ReadAll 讀到的格式版本
+	version int
+	// 檔案路徑 (NewWALFromFile 建立時才有，供 NewReader 開啟獨立的讀取 Handle)
+	path string
+	// 最後一次成功 Flush 後的檔案大小 (寫入失敗時截斷回此大小)
+	durableSize int64
 }
 
-// NewWAL 開啟或建立一個 WAL 檔案
+// syncer 可將資料刷入硬碟的儲存 (如 *os.File)
+type syncer interface {
+	Sync() error
+}
+
+// truncater 可截斷的儲存 (如 *os.File)
+type truncater interface {
+	Truncate(size int64) error
+}
+
+// NewWALFromFile 開啟或建立一個 WAL 檔案
 // 帶0 則使用預設值DefaultBufferSize = 64KB
 // O_RDWR讀寫模式
 // O_APPEND 每次寫入時自動跳到文件末尾
 // O_CREATE 如果文件不存在則建立
-func NewWAL(path string, bufferSize int) (*WAL, error) {
+func NewWALFromFile(path string, bufferSize int) (*WAL, error) {
 	// 提示: os.OpenFile with O_APPEND|O_CREATE|O_RDWR
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, FileModeReadOnly)
 	if err != nil {
 		return nil, err
 	}
+	w := newWAL(file, bufferSize)
+	w.path = path
+	return w, nil
+}
+
+// NewWALFromRWS 使用任意 io.ReadWriteSeeker 建立 WAL (使用預設 Buffer 大小)
+// 方便在測試中以記憶體實作取代實體檔案
+// 若 rws 實作 Sync() error 則 Flush 時會呼叫；實作 io.Closer 則 Close 時會呼叫
+func NewWALFromRWS(rws io.ReadWriteSeeker) *WAL {
+	return newWAL(rws, DefaultBufferSize)
+}
+
+// newWAL 建立 WAL 實例
+func newWAL(rws io.ReadWriteSeeker, bufferSize int) *WAL {
 	if bufferSize <= 0 {
 		bufferSize = DefaultBufferSize
 	}
-	return &WAL{file: file,
-		mu:     sync.Mutex{},
-		writer: bufio.NewWriterSize(file, bufferSize),
-	}, nil
+	return &WAL{
+		rws:     rws,
+		mu:      sync.Mutex{},
+		writer:  bufio.NewWriterSize(rws, bufferSize),
+		version: CurrentFormatVersion,
+	}
 }
 
-// Write 寫入一筆資料
-func (w *WAL) Write(v any) error {
+// FormatVersion 回傳 WAL 檔案的格式版本 (於 ReadAll 後有效)
+// 舊格式檔案在升級後會接著寫入新格式的紀錄，因此 FormatVersionLegacy 的檔案可能混有兩種格式
+func (w *WAL) FormatVersion() int {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	if err := json.NewEncoder(w.writer).Encode(v); err != nil {
+	return w.version
+}
+
+// ensureHeaderLocked 空檔案第一次寫入前先寫入格式版本 Header (需持有 mu)
+func (w *WAL) ensureHeaderLocked() error {
+	if w.headerChecked {
+		return nil
+	}
+	size, err := w.rws.Seek(0, io.SeekEnd)
+	if err != nil {
 		return err
 	}
+	w.durableSize = size
+	if size == 0 && w.writer.Buffered() == 0 {
+		version := CurrentFormatVersion
+		if err := json.NewEncoder(w.writer).Encode(header{Version: &version}); err != nil {
+			return err
+		}
+	}
+	w.headerChecked = true
 	return nil
 }
 
-// Flush 將緩衝區的資料刷入硬碟
+// parseHeader 判斷一筆紀錄是否為格式版本 Header
+func parseHeader(raw []byte) (int, bool) {
+	var h header
+	if err := json.Unmarshal(raw, &h); err != nil || h.Version == nil {
+		return 0, false
+	}
+	return *h.Version, true
+}
+
+// Write 寫入一筆資料 (寫入 Buffer，需呼叫 Flush 才會刷入硬碟)
+// Write 不會自動 Flush：批次寫入 (Group Commit) 時整批 Write 完再呼叫一次 Flush
+// ctx 已取消時不寫入
+func (w *WAL) Write(ctx context.Context, v any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ensureHeaderLocked(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(buf.Bytes()); err != nil {
+		// 緩衝區滿時會直接寫入檔案，寫入失敗同樣捨棄未 Flush 的資料
+		w.discardLocked()
+		return err
+	}
+	return nil
+}
+
+// Flush 將緩衝區的資料寫入檔案並呼叫 Sync (fsync) 持久化
+// 回傳 nil 後，先前 Write 的資料才保證在當機後仍存在
+// 失敗時捨棄上次成功 Flush 之後的所有資料並將檔案截斷回當時的大小 (需支援 Truncate，如 *os.File)，
+// WAL 可繼續使用；呼叫端若要重試，需重新 Write 這些資料
 func (w *WAL) Flush() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	if err := w.flushLocked(); err != nil {
+		w.discardLocked()
+		return err
+	}
+	return nil
+}
+
+// flushLocked 寫入檔案並 Sync，成功後記錄新的檔案大小 (需持有 mu)
+func (w *WAL) flushLocked() error {
 	if err := w.writer.Flush(); err != nil {
 		return err
 	}
-	return w.file.Sync()
+	if s, ok := w.rws.(syncer); ok {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+	if !w.headerChecked {
+		// 尚未寫入過任何資料
+		return nil
+	}
+	size, err := w.rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	w.durableSize = size
+	return nil
 }
 
-// Close 關閉檔案
+// discardLocked 寫入失敗後捨棄未持久化的資料 (需持有 mu)
+// 將檔案截斷回最後一次成功 Flush 的大小並重置緩衝區；儲存不支援 Truncate 時無法安全重置，
+// 緩衝區維持錯誤狀態，之後的 Write / Flush 都會失敗
+func (w *WAL) discardLocked() {
+	if !w.headerChecked {
+		w.writer.Reset(w.rws)
+		return
+	}
+	t, ok := w.rws.(truncater)
+	if !ok {
+		return
+	}
+	if err := t.Truncate(w.durableSize); err != nil {
+		return
+	}
+	if _, err := w.rws.Seek(w.durableSize, io.SeekStart); err != nil {
+		return
+	}
+	w.writer.Reset(w.rws)
+	// 截斷後重新確認是否需要寫入 Header
+	w.headerChecked = false
+}
+
+// Close 將緩衝區刷入硬碟後關閉檔案，最多等待 FlushTimeout
 func (w *WAL) Close() error {
-	return w.file.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+	defer cancel()
+	return w.CloseWithContext(ctx)
+}
+
+// CloseWithContext 將緩衝區刷入硬碟後關閉檔案，由呼叫端透過 ctx 控制等待期限
+// 若 ctx 先到期，立即回傳 ctx 的錯誤 (緩衝區中的資料可能遺失)；
+// 檔案由背景的 goroutine 在 Flush 結束後才關閉，不會在寫入或 fsync 途中關閉
+func (w *WAL) CloseWithContext(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		flushErr := w.Flush()
+		// 持有 mu 關閉，避免與其他 Write / Flush 同時存取檔案
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if c, ok := w.rws.(io.Closer); ok {
+			if err := c.Close(); err != nil && flushErr == nil {
+				flushErr = err
+			}
+		}
+		done <- flushErr
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // ReadAll 讀取所有資料
 // callback 是一個函式，接收一個 json.RawMessage
 // 這樣可以避免一次將所有資料載入記憶體
-func (w *WAL) ReadAll(callback func(jsonRaw []byte) error) error {
+// 檔案開頭的格式版本 Header 不會交給 callback，讀取後可透過 FormatVersion 取得版本
+// 尾端只寫入一部分的紀錄 (程序在寫入途中被終止) 會被截斷，之後的寫入從最後一筆完整紀錄之後開始
+func (w *WAL) ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error {
+	return w.ReadAllWithProgress(ctx, callback, nil)
+}
+
+// ReadAllWithProgress 與 ReadAll 相同，並在讀取過程中回報進度
+// progressFn 每 10,000 筆或每秒 (先到者為準) 呼叫一次，讀取完成時再呼叫一次；可為 nil
+// 儲存支援 io.ReaderAt (如 *os.File) 時只在取得檔案大小時持有鎖，之後以 io.SectionReader 讀取當下大小的快照，
+// 讀取期間 Write / Flush 可以繼續附加資料 (不會被讀到)；讀取期間不可呼叫 TruncateAfter 或 Close (會關閉檔案)
+// 不支援 io.ReaderAt 的儲存 (如測試用的記憶體實作) 則與之前相同，整個讀取期間持有鎖
+//
+// 參數:
+//
+//	ctx: 上下文
+//	callback: 處理每筆紀錄的函式
+//	progressFn: 進度回報函式 (bytesRead: 已讀取位元組數, totalBytes: 檔案大小)
+//
+// 回傳:
+//
+//	error: 讀取或 callback 錯誤
+func (w *WAL) ReadAllWithProgress(ctx context.Context, callback func(jsonRaw []byte) error, progressFn ProgressFunc) error {
 	w.mu.Lock()
-	defer w.mu.Unlock()
+	// 取得檔案大小 (快照範圍，也用於回報進度)
+	totalBytes, err := w.rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
 
-	// 確保從頭讀取
-	if _, err := w.file.Seek(0, 0); err != nil {
+	ra, ok := w.rws.(io.ReaderAt)
+	if !ok {
+		defer w.mu.Unlock()
+		// 確保從頭讀取
+		if _, err := w.rws.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		// 讀取結束後移回尾端，避免非 O_APPEND 的儲存覆寫既有資料
+		defer func() {
+			_, _ = w.rws.Seek(0, io.SeekEnd)
+		}()
+		result, err := scanEntries(ctx, w.rws, totalBytes, callback, progressFn)
+		w.version = result.version
+		if err == nil && result.torn {
+			err = w.repairTornTailLocked(totalBytes, result.goodOffset)
+		}
 		return err
 	}
+	w.mu.Unlock()
+
+	// ReadAt 不使用也不移動檔案的讀寫位置，不會影響同時進行的寫入
+	result, err := scanEntries(ctx, io.NewSectionReader(ra, 0, totalBytes), totalBytes, callback, progressFn)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.version = result.version
+	if err == nil && result.torn {
+		err = w.repairTornTailLocked(totalBytes, result.goodOffset)
+	}
+	return err
+}
+
+// scanResult scanEntries 的讀取結果
+//
+// 結構:
+//
+//	version: 檔案的格式版本 (空檔案為 CurrentFormatVersion，沒有 Header 為 FormatVersionLegacy)
+//	goodOffset: 最後一筆完整紀錄 (含 Header) 結束的位元組位置
+//	torn: goodOffset 之後有只寫入一部分的紀錄
+type scanResult struct {
+	version    int
+	goodOffset int64
+	torn       bool
+}
 
-	decoder := json.NewDecoder(w.file)
+// scanEntries 從 r 的開頭依序解析紀錄並交給 callback (略過格式版本 Header)
+//
+// 參數:
+//
+//	ctx: 上下文
+//	r: 從檔案開頭開始的讀取來源
+//	totalBytes: 檔案大小 (回報進度用)
+//	callback: 處理每筆紀錄的函式
+//	progressFn: 進度回報函式 (可為 nil)
+//
+// 回傳:
+//
+//	scanResult: 格式版本與最後一筆完整紀錄的位置
+//	error: 讀取或 callback 錯誤
+func scanEntries(ctx context.Context, r io.Reader, totalBytes int64, callback func(jsonRaw []byte) error, progressFn ProgressFunc) (scanResult, error) {
+	decoder := json.NewDecoder(r)
+	first := true
+	result := scanResult{version: CurrentFormatVersion} // 空檔案會以目前版本寫入 Header
+	entries := 0
+	lastReport := time.Now()
 	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
 		var raw json.RawMessage
 		if err := decoder.Decode(&raw); err != nil {
-			if err.Error() == "EOF" { // io.EOF check
+			if errors.Is(err, io.EOF) {
 				break
 			}
-			return err
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// 最後一筆紀錄只寫入一部分 (程序在寫入途中被終止)，之前的紀錄仍完整
+				// 由呼叫端截斷到 goodOffset，避免之後的寫入接在這段殘缺資料後面
+				result.torn = true
+				break
+			}
+			return result, err
+		}
+		result.goodOffset = decoder.InputOffset()
+		if first {
+			first = false
+			if headerVersion, ok := parseHeader(raw); ok {
+				result.version = headerVersion
+				continue
+			}
+			result.version = FormatVersionLegacy
 		}
 		if err := callback(raw); err != nil {
+			return result, err
+		}
+		if progressFn != nil {
+			entries++
+			if entries%progressEntryInterval == 0 || time.Since(lastReport) >= progressTimeInterval {
+				progressFn(decoder.InputOffset(), totalBytes)
+				lastReport = time.Now()
+			}
+		}
+	}
+	if progressFn != nil {
+		progressFn(decoder.InputOffset(), totalBytes)
+	}
+	return result, nil
+}
+
+// repairTornTailLocked 截斷尾端只寫入一部分的紀錄 (需持有 mu)
+// 檔案以 O_APPEND 開啟，不截斷的話下一筆寫入會接在殘缺資料的同一行，之後的讀取將無法解析而遺失該筆紀錄
+// 讀取期間已有新的寫入 (殘缺資料已不在尾端) 或儲存不支援 Truncate 時無法安全修復，回傳 ErrTornEntry
+//
+// 參數:
+//
+//	snapshotSize: 讀取時的檔案大小
+//	goodOffset: 最後一筆完整紀錄結束的位置
+//
+// 回傳:
+//
+//	error: ErrTornEntry 或截斷錯誤
+func (w *WAL) repairTornTailLocked(snapshotSize, goodOffset int64) error {
+	size, err := w.rws.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size != snapshotSize || w.writer.Buffered() > 0 {
+		return fmt.Errorf("%w at offset %d: wal was written during the read", ErrTornEntry, goodOffset)
+	}
+	if err := w.truncateLocked(goodOffset); err != nil {
+		return fmt.Errorf("%w at offset %d: %w", ErrTornEntry, goodOffset, err)
+	}
+	slog.Warn("wal: truncated partially written last entry",
+		"offset", goodOffset,
+		"bytes_discarded", snapshotSize-goodOffset,
+	)
+	return nil
+}
+
+// truncateLocked 將檔案截斷到 size 並 Sync，之後的寫入從 size 開始 (需持有 mu，且緩衝區為空)
+// size 之前的資料若不是以換行結尾會補上換行，讓下一筆紀錄從新的一行開始 (以換行切分的 ReadAllLenient 才能正確讀取)
+func (w *WAL) truncateLocked(size int64) error {
+	t, ok := w.rws.(truncater)
+	if !ok {
+		return errTruncateNotSupported
+	}
+	if err := t.Truncate(size); err != nil {
+		return err
+	}
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := w.rws.Seek(size-1, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(w.rws, last); err != nil {
 			return err
 		}
+		if last[0] != '\n' {
+			if _, err := w.rws.Write([]byte{'\n'}); err != nil {
+				return err
+			}
+			size++
+		}
+	}
+	if _, err := w.rws.Seek(size, io.SeekStart); err != nil {
+		return err
 	}
+	if s, ok := w.rws.(syncer); ok {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+	w.writer.Reset(w.rws)
+	w.durableSize = size
+	// 截斷後重新確認是否需要寫入 Header
+	w.headerChecked = false
 	return nil
 }
+
+// ReadFrom 從指定的位元組位置開始讀取，回傳最後一筆成功解析紀錄之後的位置
+// 呼叫端保存回傳的 newOffset，下次從該位置繼續讀取，不需重新讀取整個檔案
+// 只讀得到已 Flush 的資料；尾端不完整的紀錄 (寫入中) 不算錯誤，下次會從它的開頭重新讀取
+// offset 為 0 時會略過檔案開頭的格式版本 Header
+//
+// 參數:
+//
+//	ctx: 上下文
+//	offset: 開始讀取的位元組位置 (第一次為 0)
+//	callback: 處理每筆紀錄的函式
+//
+// 回傳:
+//
+//	int64: 下次讀取的位置
+//	error: 讀取或 callback 錯誤
+func (w *WAL) ReadFrom(ctx context.Context, offset int64, callback func(jsonRaw []byte) error) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 讀取結束後移回尾端，避免非 O_APPEND 的儲存覆寫既有資料
+	defer func() {
+		_, _ = w.rws.Seek(0, io.SeekEnd)
+	}()
+	return readFrom(ctx, w.rws, offset, callback)
+}
+
+// readFrom 從 rs 的 offset 位置開始解析紀錄 (WAL.ReadFrom 與 WALReader 共用)
+func readFrom(ctx context.Context, rs io.ReadSeeker, offset int64, callback func(jsonRaw []byte) error) (int64, error) {
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	decoder := json.NewDecoder(rs)
+	newOffset := offset
+	first := offset == 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return newOffset, err
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return newOffset, nil
+			}
+			return newOffset, err
+		}
+		if first {
+			first = false
+			if _, ok := parseHeader(raw); ok {
+				newOffset = offset + decoder.InputOffset()
+				continue
+			}
+		}
+		if err := callback(raw); err != nil {
+			return newOffset, err
+		}
+		newOffset = offset + decoder.InputOffset()
+	}
+}
+
+var (
+	_ Writer         = (*WAL)(nil)
+	_ ProgressReader = (*WAL)(nil)
+)
@@ -0,0 +1,221 @@
+package wal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	Seq uint64 `json:"seq"`
+}
+
+// readSequences 重新開啟 WAL 並讀取所有紀錄的順序號
+func readSequences(t *testing.T, path string) []uint64 {
+	t.Helper()
+	w, err := NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatalf("NewWALFromFile: %v", err)
+	}
+	defer w.Close()
+	return readAllSequences(t, w)
+}
+
+func readAllSequences(t *testing.T, w *WAL) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	err := w.ReadAll(context.Background(), func(jsonRaw []byte) error {
+		var e testEntry
+		if err := json.Unmarshal(jsonRaw, &e); err != nil {
+			return err
+		}
+		seqs = append(seqs, e.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	return seqs
+}
+
+func writeEntries(t *testing.T, w *WAL, seqs ...uint64) {
+	t.Helper()
+	for _, seq := range seqs {
+		if err := w.Write(context.Background(), testEntry{Seq: seq}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+}
+
+// 完整的寫入 → 重新開啟 → 讀取流程，全部在記憶體中進行
+func TestWALRoundTripInMemory(t *testing.T) {
+	rws := &memRWS{}
+	writeEntries(t, NewWALFromRWS(rws), 1, 2, 3)
+
+	// 第一筆紀錄為格式版本 Header
+	firstLine, _, _ := bytes.Cut(rws.data, []byte("\n"))
+	if version, ok := parseHeader(firstLine); !ok || version != CurrentFormatVersion {
+		t.Fatalf("first record %s, want header with version %d", firstLine, CurrentFormatVersion)
+	}
+
+	// 以同一份資料重新開啟 (模擬重新啟動)，Header 不交給 callback，紀錄依寫入順序回傳
+	reopened := &memRWS{data: rws.data}
+	w := NewWALFromRWS(reopened)
+	if got, want := readAllSequences(t, w), []uint64{1, 2, 3}; !slices.Equal(got, want) {
+		t.Fatalf("ReadAll = %v, want %v", got, want)
+	}
+	if got := w.FormatVersion(); got != CurrentFormatVersion {
+		t.Fatalf("FormatVersion = %d, want %d", got, CurrentFormatVersion)
+	}
+
+	// 讀取後繼續寫入，接在既有紀錄之後且不重複寫入 Header
+	writeEntries(t, w, 4)
+	if got, want := readAllSequences(t, NewWALFromRWS(&memRWS{data: reopened.data})), []uint64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Fatalf("ReadAll after append = %v, want %v", got, want)
+	}
+	if headers := bytes.Count(reopened.data, []byte("wal_version")); headers != 1 {
+		t.Fatalf("found %d headers, want 1", headers)
+	}
+}
+
+func TestReadAllTruncatesTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "torn.wal")
+	w, err := NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatalf("NewWALFromFile: %v", err)
+	}
+	writeEntries(t, w, 1, 2)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// 模擬程序在寫入第 3 筆紀錄途中被終止
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"seq":3,"fr`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	w, err = NewWALFromFile(path, 0)
+	if err != nil {
+		t.Fatalf("NewWALFromFile: %v", err)
+	}
+	if got := readAllSequences(t, w); len(got) != 2 {
+		t.Fatalf("recovered %v, want [1 2]", got)
+	}
+	// 截斷後的寫入不能接在殘缺資料後面
+	writeEntries(t, w, 3)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got := readSequences(t, path)
+	want := []uint64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("recovered %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("recovered %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReadAllTornTailWithoutTruncate(t *testing.T) {
+	rws := &memRWS{data: []byte("{\"seq\":1}\n{\"seq\":2,")}
+	w := NewWALFromRWS(rws)
+	err := w.ReadAll(context.Background(), func([]byte) error { return nil })
+	if !errors.Is(err, ErrTornEntry) {
+		t.Fatalf("ReadAll error = %v, want ErrTornEntry", err)
+	}
+}
+
+// 逾時後 CloseWithContext 立即回傳，但檔案要等 fsync 結束後才關閉
+func TestCloseWithContextWaitsForFlushBeforeClosing(t *testing.T) {
+	rws := &slowSyncRWS{memRWS: &memRWS{}, release: make(chan struct{}), closed: make(chan struct{})}
+	w := NewWALFromRWS(rws)
+	if err := w.Write(context.Background(), testEntry{Seq: 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.CloseWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CloseWithContext error = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-rws.closed:
+		t.Fatal("file closed while Sync was still running")
+	default:
+	}
+
+	close(rws.release)
+	select {
+	case <-rws.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("file not closed after Sync returned")
+	}
+}
+
+// slowSyncRWS Sync 會阻塞到 release 被關閉的記憶體儲存
+type slowSyncRWS struct {
+	*memRWS
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func (s *slowSyncRWS) Sync() error {
+	<-s.release
+	return nil
+}
+
+func (s *slowSyncRWS) Close() error {
+	close(s.closed)
+	return nil
+}
+
+// memRWS 不支援 Truncate 的記憶體儲存
+type memRWS struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memRWS) Read(p []byte) (int, error) {
+	if m.pos >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memRWS) Write(p []byte) (int, error) {
+	m.data = append(m.data[:m.pos], p...)
+	m.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (m *memRWS) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		m.pos = offset
+	case io.SeekCurrent:
+		m.pos += offset
+	case io.SeekEnd:
+		m.pos = int64(len(m.data)) + offset
+	}
+	return m.pos, nil
+}
@@ -0,0 +1,108 @@
+package wal
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Writer 是 WAL 的抽象介面，讓帳本可以注入不同的實作
+// (正式環境使用檔案型 *WAL，測試可使用 NopWriter / MemWriter，未來可擴充 Redis Streams、S3 等)
+type Writer interface {
+	// Write 寫入一筆資料 (不保證已持久化)
+	Write(ctx context.Context, v any) error
+	// Flush 將已寫入的資料持久化
+	Flush() error
+	// ReadAll 依寫入順序讀取所有資料
+	ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error
+}
+
+// ProgressFunc 讀取進度回報函式
+type ProgressFunc func(bytesRead, totalBytes int64)
+
+// ProgressReader 可在讀取時回報進度的 WAL (如 *WAL)
+type ProgressReader interface {
+	ReadAllWithProgress(ctx context.Context, callback func(jsonRaw []byte) error, progressFn ProgressFunc) error
+}
+
+// NopWriter 不做任何事的 Writer (純記憶體模式或測試用)
+type NopWriter struct{}
+
+// Write 丟棄資料
+func (NopWriter) Write(ctx context.Context, v any) error {
+	return nil
+}
+
+// Flush 不做任何事
+func (NopWriter) Flush() error {
+	return nil
+}
+
+// ReadAll 沒有任何資料
+func (NopWriter) ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error {
+	return nil
+}
+
+// MemWriter 將資料以 JSON 存放在記憶體中的 Writer (測試用)
+// 它是執行緒安全的 (Thread-safe)
+type MemWriter struct {
+	mu      sync.Mutex
+	entries []json.RawMessage
+}
+
+// NewMemWriter 建立一個空的 MemWriter
+func NewMemWriter() *MemWriter {
+	return &MemWriter{}
+}
+
+// Write 將資料序列化為 JSON 後存入記憶體
+func (m *MemWriter) Write(ctx context.Context, v any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, raw)
+	return nil
+}
+
+// Flush 不做任何事 (資料已在記憶體中)
+func (m *MemWriter) Flush() error {
+	return nil
+}
+
+// ReadAll 依寫入順序讀取所有資料
+func (m *MemWriter) ReadAll(ctx context.Context, callback func(jsonRaw []byte) error) error {
+	m.mu.Lock()
+	entries := make([]json.RawMessage, len(m.entries))
+	copy(entries, m.entries)
+	m.mu.Unlock()
+
+	for _, raw := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := callback(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries 回傳目前所有資料的副本
+func (m *MemWriter) Entries() []json.RawMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]json.RawMessage, len(m.entries))
+	copy(entries, m.entries)
+	return entries
+}
+
+var (
+	_ Writer = NopWriter{}
+	_ Writer = (*MemWriter)(nil)
+)
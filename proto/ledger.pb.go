@@ -75,14 +75,15 @@ func (TransactionType) EnumDescriptor() ([]byte, []int) {
 }
 
 type TransferRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RefId         string                 `protobuf:"bytes,1,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`                            // Client 端的 UUID
-	Type          TransactionType        `protobuf:"varint,2,opt,name=type,proto3,enum=pb.TransactionType" json:"type,omitempty"`                  // 交易類型
-	FromAccountId int64                  `protobuf:"varint,3,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"` // 來源帳號 (DEPOSIT 時可忽略或填空)
-	ToAccountId   int64                  `protobuf:"varint,4,opt,name=to_account_id,json=toAccountId,proto3" json:"to_account_id,omitempty"`       // 目標帳號 (WITHDRAW 時可忽略)
-	Amount        int64                  `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`                                      // 金額 (定點數, 放大 10000 倍)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RefId          string                 `protobuf:"bytes,1,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`                             // Client 端的 UUID
+	Type           TransactionType        `protobuf:"varint,2,opt,name=type,proto3,enum=pb.TransactionType" json:"type,omitempty"`                   // 交易類型
+	FromAccountId  int64                  `protobuf:"varint,3,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`  // 來源帳號 (DEPOSIT 時可忽略或填空)
+	ToAccountId    int64                  `protobuf:"varint,4,opt,name=to_account_id,json=toAccountId,proto3" json:"to_account_id,omitempty"`        // 目標帳號 (WITHDRAW 時可忽略)
+	Amount         int64                  `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`                                       // 金額 (定點數, 放大 10000 倍)
+	IncludeBalance bool                   `protobuf:"varint,6,opt,name=include_balance,json=includeBalance,proto3" json:"include_balance,omitempty"` // 是否在回應中帶交易後餘額 (預設 false，不額外查詢餘額)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *TransferRequest) Reset() {
@@ -150,11 +151,20 @@ func (x *TransferRequest) GetAmount() int64 {
 	return 0
 }
 
+func (x *TransferRequest) GetIncludeBalance() bool {
+	if x != nil {
+		return x.IncludeBalance
+	}
+	return false
+}
+
 type TransferResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                                      // e.g. "insufficient balance"
-	CurrentBalance int64                  `protobuf:"varint,3,opt,name=current_balance,json=currentBalance,proto3" json:"current_balance,omitempty"` // 交易後餘額 (若是轉帳，回傳 from 的餘額)
+	CurrentBalance int64                  `protobuf:"varint,3,opt,name=current_balance,json=currentBalance,proto3" json:"current_balance,omitempty"` // 交易後餘額 (若是轉帳，回傳 from 的餘額)；未要求 include_balance 或查詢失敗時為 -1
+	Sequence       uint64                 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`                                   // 交易的全局順序號 (用於排序多個 Ledger 實例的事件；重複提交或未分配時為 0)
+	RequestId      string                 `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                 // 請求的 Request ID (X-Request-ID)，回報問題時提供以便查詢 Log
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -210,6 +220,20 @@ func (x *TransferResponse) GetCurrentBalance() int64 {
 	return 0
 }
 
+func (x *TransferResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *TransferResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
 type BatchTransferRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Requests      []*TransferRequest     `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
@@ -343,10 +367,16 @@ func (x *GetBalanceRequest) GetAccountId() int64 {
 }
 
 type GetBalanceResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Balance       int64                  `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Balance              int64                  `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	AvailableBalance     int64                  `protobuf:"varint,2,opt,name=available_balance,json=availableBalance,proto3" json:"available_balance,omitempty"`                                    // 可用餘額 (扣除已保留的金額)，判斷能否付款應使用此欄位
+	Currency             string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`                                                                             // 幣別 (如 "USD")，空字串代表單一幣別模式
+	LastTransactionRefId string                 `protobuf:"bytes,4,opt,name=last_transaction_ref_id,json=lastTransactionRefId,proto3" json:"last_transaction_ref_id,omitempty"`                     // 最後一筆異動餘額的交易 ref_id (沒有交易時為空字串)
+	LastTransactionAt    int64                  `protobuf:"varint,5,opt,name=last_transaction_at,json=lastTransactionAt,proto3" json:"last_transaction_at,omitempty"`                               // 最後一筆交易的時間 (Unix 毫秒)
+	LastTransactionType  TransactionType        `protobuf:"varint,6,opt,name=last_transaction_type,json=lastTransactionType,proto3,enum=pb.TransactionType" json:"last_transaction_type,omitempty"` // 最後一筆交易的類型
+	RequestId            string                 `protobuf:"bytes,7,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                                                          // 請求的 Request ID (X-Request-ID)，回報問題時提供以便查詢 Log
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *GetBalanceResponse) Reset() {
@@ -386,40 +416,215 @@ func (x *GetBalanceResponse) GetBalance() int64 {
 	return 0
 }
 
+func (x *GetBalanceResponse) GetAvailableBalance() int64 {
+	if x != nil {
+		return x.AvailableBalance
+	}
+	return 0
+}
+
+func (x *GetBalanceResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *GetBalanceResponse) GetLastTransactionRefId() string {
+	if x != nil {
+		return x.LastTransactionRefId
+	}
+	return ""
+}
+
+func (x *GetBalanceResponse) GetLastTransactionAt() int64 {
+	if x != nil {
+		return x.LastTransactionAt
+	}
+	return 0
+}
+
+func (x *GetBalanceResponse) GetLastTransactionType() TransactionType {
+	if x != nil {
+		return x.LastTransactionType
+	}
+	return TransactionType_UNKNOWN
+}
+
+func (x *GetBalanceResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type CreateAccountRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RefId          string                 `protobuf:"bytes,1,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`                             // Client 端的 UUID (冪等性)
+	AccountId      int64                  `protobuf:"varint,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`                // 帳號 (必須為正數，0 為系統帳戶)
+	Currency       string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`                                    // 幣別 (如 "USD")，空字串代表單一幣別模式
+	InitialBalance int64                  `protobuf:"varint,4,opt,name=initial_balance,json=initialBalance,proto3" json:"initial_balance,omitempty"` // 初始餘額 (定點數, 放大 10000 倍)；預設只允許 0，見 CreateAccount
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateAccountRequest) Reset() {
+	*x = CreateAccountRequest{}
+	mi := &file_proto_ledger_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountRequest) ProtoMessage() {}
+
+func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ledger_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountRequest.ProtoReflect.Descriptor instead.
+func (*CreateAccountRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ledger_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateAccountRequest) GetRefId() string {
+	if x != nil {
+		return x.RefId
+	}
+	return ""
+}
+
+func (x *CreateAccountRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *CreateAccountRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateAccountRequest) GetInitialBalance() int64 {
+	if x != nil {
+		return x.InitialBalance
+	}
+	return 0
+}
+
+type CreateAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // 請求的 Request ID (X-Request-ID)，回報問題時提供以便查詢 Log
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAccountResponse) Reset() {
+	*x = CreateAccountResponse{}
+	mi := &file_proto_ledger_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountResponse) ProtoMessage() {}
+
+func (x *CreateAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ledger_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountResponse.ProtoReflect.Descriptor instead.
+func (*CreateAccountResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ledger_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateAccountResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
 var File_proto_ledger_proto protoreflect.FileDescriptor
 
 const file_proto_ledger_proto_rawDesc = "" +
 	"\n" +
-	"\x12proto/ledger.proto\x12\x02pb\"\xb5\x01\n" +
+	"\x12proto/ledger.proto\x12\x02pb\"\xde\x01\n" +
 	"\x0fTransferRequest\x12\x15\n" +
 	"\x06ref_id\x18\x01 \x01(\tR\x05refId\x12'\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x13.pb.TransactionTypeR\x04type\x12&\n" +
 	"\x0ffrom_account_id\x18\x03 \x01(\x03R\rfromAccountId\x12\"\n" +
 	"\rto_account_id\x18\x04 \x01(\x03R\vtoAccountId\x12\x16\n" +
-	"\x06amount\x18\x05 \x01(\x03R\x06amount\"o\n" +
+	"\x06amount\x18\x05 \x01(\x03R\x06amount\x12'\n" +
+	"\x0finclude_balance\x18\x06 \x01(\bR\x0eincludeBalance\"\xaa\x01\n" +
 	"\x10TransferResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
-	"\x0fcurrent_balance\x18\x03 \x01(\x03R\x0ecurrentBalance\"G\n" +
+	"\x0fcurrent_balance\x18\x03 \x01(\x03R\x0ecurrentBalance\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\x04R\bsequence\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x05 \x01(\tR\trequestId\"G\n" +
 	"\x14BatchTransferRequest\x12/\n" +
 	"\brequests\x18\x01 \x03(\v2\x13.pb.TransferRequestR\brequests\"K\n" +
 	"\x15BatchTransferResponse\x122\n" +
 	"\tresponses\x18\x01 \x03(\v2\x14.pb.TransferResponseR\tresponses\"2\n" +
 	"\x11GetBalanceRequest\x12\x1d\n" +
 	"\n" +
-	"account_id\x18\x01 \x01(\x03R\taccountId\".\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\"\xc6\x02\n" +
 	"\x12GetBalanceResponse\x12\x18\n" +
-	"\abalance\x18\x01 \x01(\x03R\abalance*G\n" +
+	"\abalance\x18\x01 \x01(\x03R\abalance\x12+\n" +
+	"\x11available_balance\x18\x02 \x01(\x03R\x10availableBalance\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x125\n" +
+	"\x17last_transaction_ref_id\x18\x04 \x01(\tR\x14lastTransactionRefId\x12.\n" +
+	"\x13last_transaction_at\x18\x05 \x01(\x03R\x11lastTransactionAt\x12G\n" +
+	"\x15last_transaction_type\x18\x06 \x01(\x0e2\x13.pb.TransactionTypeR\x13lastTransactionType\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\a \x01(\tR\trequestId\"\x91\x01\n" +
+	"\x14CreateAccountRequest\x12\x15\n" +
+	"\x06ref_id\x18\x01 \x01(\tR\x05refId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\x03R\taccountId\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12'\n" +
+	"\x0finitial_balance\x18\x04 \x01(\x03R\x0einitialBalance\"6\n" +
+	"\x15CreateAccountResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId*G\n" +
 	"\x0fTransactionType\x12\v\n" +
 	"\aUNKNOWN\x10\x00\x12\v\n" +
 	"\aDEPOSIT\x10\x01\x12\f\n" +
 	"\bWITHDRAW\x10\x02\x12\f\n" +
-	"\bTRANSFER\x10\x032\xc9\x01\n" +
+	"\bTRANSFER\x10\x032\x8f\x02\n" +
 	"\rLedgerService\x125\n" +
 	"\bTransfer\x12\x13.pb.TransferRequest\x1a\x14.pb.TransferResponse\x12D\n" +
 	"\rBatchTransfer\x12\x18.pb.BatchTransferRequest\x1a\x19.pb.BatchTransferResponse\x12;\n" +
 	"\n" +
-	"GetBalance\x12\x15.pb.GetBalanceRequest\x1a\x16.pb.GetBalanceResponseB(Z&github.com/JoeShih716/go-mem-ledger/pbb\x06proto3"
+	"GetBalance\x12\x15.pb.GetBalanceRequest\x1a\x16.pb.GetBalanceResponse\x12D\n" +
+	"\rCreateAccount\x12\x18.pb.CreateAccountRequest\x1a\x19.pb.CreateAccountResponseB(Z&github.com/JoeShih716/go-mem-ledger/pbb\x06proto3"
 
 var (
 	file_proto_ledger_proto_rawDescOnce sync.Once
@@ -434,7 +639,7 @@ func file_proto_ledger_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_ledger_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_proto_ledger_proto_goTypes = []any{
 	(TransactionType)(0),          // 0: pb.TransactionType
 	(*TransferRequest)(nil),       // 1: pb.TransferRequest
@@ -443,22 +648,27 @@ var file_proto_ledger_proto_goTypes = []any{
 	(*BatchTransferResponse)(nil), // 4: pb.BatchTransferResponse
 	(*GetBalanceRequest)(nil),     // 5: pb.GetBalanceRequest
 	(*GetBalanceResponse)(nil),    // 6: pb.GetBalanceResponse
+	(*CreateAccountRequest)(nil),  // 7: pb.CreateAccountRequest
+	(*CreateAccountResponse)(nil), // 8: pb.CreateAccountResponse
 }
 var file_proto_ledger_proto_depIdxs = []int32{
 	0, // 0: pb.TransferRequest.type:type_name -> pb.TransactionType
 	1, // 1: pb.BatchTransferRequest.requests:type_name -> pb.TransferRequest
 	2, // 2: pb.BatchTransferResponse.responses:type_name -> pb.TransferResponse
-	1, // 3: pb.LedgerService.Transfer:input_type -> pb.TransferRequest
-	3, // 4: pb.LedgerService.BatchTransfer:input_type -> pb.BatchTransferRequest
-	5, // 5: pb.LedgerService.GetBalance:input_type -> pb.GetBalanceRequest
-	2, // 6: pb.LedgerService.Transfer:output_type -> pb.TransferResponse
-	4, // 7: pb.LedgerService.BatchTransfer:output_type -> pb.BatchTransferResponse
-	6, // 8: pb.LedgerService.GetBalance:output_type -> pb.GetBalanceResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	0, // 3: pb.GetBalanceResponse.last_transaction_type:type_name -> pb.TransactionType
+	1, // 4: pb.LedgerService.Transfer:input_type -> pb.TransferRequest
+	3, // 5: pb.LedgerService.BatchTransfer:input_type -> pb.BatchTransferRequest
+	5, // 6: pb.LedgerService.GetBalance:input_type -> pb.GetBalanceRequest
+	7, // 7: pb.LedgerService.CreateAccount:input_type -> pb.CreateAccountRequest
+	2, // 8: pb.LedgerService.Transfer:output_type -> pb.TransferResponse
+	4, // 9: pb.LedgerService.BatchTransfer:output_type -> pb.BatchTransferResponse
+	6, // 10: pb.LedgerService.GetBalance:output_type -> pb.GetBalanceResponse
+	8, // 11: pb.LedgerService.CreateAccount:output_type -> pb.CreateAccountResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_ledger_proto_init() }
@@ -472,7 +682,7 @@ func file_proto_ledger_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_ledger_proto_rawDesc), len(file_proto_ledger_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -22,6 +22,7 @@ const (
 	LedgerService_Transfer_FullMethodName      = "/pb.LedgerService/Transfer"
 	LedgerService_BatchTransfer_FullMethodName = "/pb.LedgerService/BatchTransfer"
 	LedgerService_GetBalance_FullMethodName    = "/pb.LedgerService/GetBalance"
+	LedgerService_CreateAccount_FullMethodName = "/pb.LedgerService/CreateAccount"
 )
 
 // LedgerServiceClient is the client API for LedgerService service.
@@ -36,6 +37,9 @@ type LedgerServiceClient interface {
 	BatchTransfer(ctx context.Context, in *BatchTransferRequest, opts ...grpc.CallOption) (*BatchTransferResponse, error)
 	// GetBalance 查詢餘額
 	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	// CreateAccount 建立帳戶並設定初始餘額 (以 ref_id 冪等)
+	// 初始餘額大於 0 等同憑空增加資金，只有伺服器以 --allow-initial-balance 啟動時允許 (否則回傳 PERMISSION_DENIED)
+	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error)
 }
 
 type ledgerServiceClient struct {
@@ -76,6 +80,16 @@ func (c *ledgerServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequ
 	return out, nil
 }
 
+func (c *ledgerServiceClient) CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*CreateAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAccountResponse)
+	err := c.cc.Invoke(ctx, LedgerService_CreateAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LedgerServiceServer is the server API for LedgerService service.
 // All implementations must embed UnimplementedLedgerServiceServer
 // for forward compatibility.
@@ -88,6 +102,9 @@ type LedgerServiceServer interface {
 	BatchTransfer(context.Context, *BatchTransferRequest) (*BatchTransferResponse, error)
 	// GetBalance 查詢餘額
 	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	// CreateAccount 建立帳戶並設定初始餘額 (以 ref_id 冪等)
+	// 初始餘額大於 0 等同憑空增加資金，只有伺服器以 --allow-initial-balance 啟動時允許 (否則回傳 PERMISSION_DENIED)
+	CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error)
 	mustEmbedUnimplementedLedgerServiceServer()
 }
 
@@ -107,6 +124,9 @@ func (UnimplementedLedgerServiceServer) BatchTransfer(context.Context, *BatchTra
 func (UnimplementedLedgerServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetBalance not implemented")
 }
+func (UnimplementedLedgerServiceServer) CreateAccount(context.Context, *CreateAccountRequest) (*CreateAccountResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateAccount not implemented")
+}
 func (UnimplementedLedgerServiceServer) mustEmbedUnimplementedLedgerServiceServer() {}
 func (UnimplementedLedgerServiceServer) testEmbeddedByValue()                       {}
 
@@ -182,6 +202,24 @@ func _LedgerService_GetBalance_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LedgerService_CreateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CreateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_CreateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CreateAccount(ctx, req.(*CreateAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LedgerService_ServiceDesc is the grpc.ServiceDesc for LedgerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,6 +239,10 @@ var LedgerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBalance",
 			Handler:    _LedgerService_GetBalance_Handler,
 		},
+		{
+			MethodName: "CreateAccount",
+			Handler:    _LedgerService_CreateAccount_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/ledger.proto",
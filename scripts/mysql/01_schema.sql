@@ -6,6 +6,7 @@ USE ledger_db;
 CREATE TABLE IF NOT EXISTS users (
     id BIGINT NOT NULL AUTO_INCREMENT,
     balance BIGINT NOT NULL DEFAULT 0 COMMENT '餘額 (定點數, 放大 10000 倍)',
+    currency VARCHAR(8) NOT NULL DEFAULT '' COMMENT '幣別 (空字串代表單一幣別模式)',
     created_at BIGINT NOT NULL DEFAULT 0,
     updated_at BIGINT NOT NULL DEFAULT 0,
     PRIMARY KEY (id)
@@ -14,21 +15,31 @@ CREATE TABLE IF NOT EXISTS users (
 -- Transactions 表：交易明細
 CREATE TABLE IF NOT EXISTS transactions (
     id BIGINT NOT NULL AUTO_INCREMENT,
-    ref_id BINARY(16) NOT NULL COMMENT '外部冪等金鑰 (UUID)',
+    ref_id VARCHAR(36) NOT NULL COMMENT '外部冪等金鑰 (UUID 字串, 含連字號)',
     sequence BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '全域順序號 (由 Core 分配)',
     from_account_id BIGINT NOT NULL DEFAULT 0,
     to_account_id BIGINT NOT NULL DEFAULT 0,
     amount BIGINT NOT NULL DEFAULT 0,
-    type TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '1:Deposit, 2:Withdraw, 3:Transfer',
+    exchange_rate BIGINT NOT NULL DEFAULT 0 COMMENT '匯率 (放大 10000 倍, 僅跨幣別轉帳)',
+    type TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '1:Deposit, 2:Withdraw, 3:Transfer, 6:FXTransfer',
     created_at BIGINT NOT NULL DEFAULT 0 COMMENT '交易時間戳 (Unix)',
 
     PRIMARY KEY (id),
     UNIQUE KEY uk_ref_id (ref_id), -- 確保冪等性
     KEY idx_from_account (from_account_id),
     KEY idx_to_account (to_account_id),
-    KEY idx_sequence (sequence) -- 用於 WAL 重放檢查
+    KEY idx_sequence (sequence), -- 用於 WAL 重放檢查
+    KEY idx_created_at (created_at) -- 用於依時間分批清除舊交易 (cmd/purge)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='交易明細表';
 
+-- Ledger Checkpoints 表：記憶體帳本最後一次寫入 MySQL 的 WAL 順序號 (只有 id = 1 一筆)
+CREATE TABLE IF NOT EXISTS ledger_checkpoints (
+    id BIGINT NOT NULL,
+    sequence BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '檢查點對應的 WAL 順序號',
+    updated_at BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='記憶體帳本檢查點';
+
 -- 新增 Trigger：自動更新 updated_at (毫秒)
 DELIMITER //
 CREATE TRIGGER update_users_timestamp
@@ -48,11 +59,11 @@ SELECT
     FROM_UNIXTIME(updated_at / 1000) as updated_at
 FROM users;
 
--- View: 開發者可讀的交易表 (UUID 轉換, 金額轉換)
+-- View: 開發者可讀的交易表 (金額轉換)
 CREATE OR REPLACE VIEW human_readable_transactions AS
 SELECT
     id,
-    BIN_TO_UUID(ref_id) as uuid,
+    ref_id as uuid,
     sequence,
     from_account_id,
     to_account_id,
@@ -0,0 +1,19 @@
+-- 既有資料庫升級用 (新建立的資料庫已由 01_schema.sql 建立這些欄位，不需執行)
+-- 放在子目錄中，MySQL 容器初始化時不會自動執行
+USE ledger_db;
+
+-- 帳戶幣別 (空字串代表單一幣別模式)
+ALTER TABLE users
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT '' COMMENT '幣別 (空字串代表單一幣別模式)' AFTER balance;
+
+-- 跨幣別轉帳的匯率
+ALTER TABLE transactions
+    ADD COLUMN exchange_rate BIGINT NOT NULL DEFAULT 0 COMMENT '匯率 (放大 10000 倍, 僅跨幣別轉帳)' AFTER amount;
+
+-- 記憶體帳本檢查點 (checkpoint_mode)
+CREATE TABLE IF NOT EXISTS ledger_checkpoints (
+    id BIGINT NOT NULL,
+    sequence BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '檢查點對應的 WAL 順序號',
+    updated_at BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='記憶體帳本檢查點';
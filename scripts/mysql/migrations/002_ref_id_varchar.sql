@@ -0,0 +1,39 @@
+-- transactions.ref_id 由 BINARY(16) 改為 VARCHAR(36) (標準 UUID 字串)，讓 SQL 查詢可直接閱讀
+-- 新建立的資料庫已由 01_schema.sql 建立 VARCHAR(36) 欄位，不需執行；重複執行不會有影響
+-- 放在子目錄中，MySQL 容器初始化時不會自動執行
+-- 轉換期間會鎖住 transactions 表，請在停機 (Core 已停止) 時執行
+USE ledger_db;
+
+DELIMITER //
+CREATE PROCEDURE migrate_ref_id_varchar()
+BEGIN
+    -- 偵測舊格式：ref_id 仍為 binary 才轉換
+    IF (SELECT DATA_TYPE FROM information_schema.COLUMNS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'transactions' AND COLUMN_NAME = 'ref_id') = 'binary' THEN
+        ALTER TABLE transactions
+            ADD COLUMN ref_id_str VARCHAR(36) NOT NULL DEFAULT '' AFTER ref_id;
+        UPDATE transactions SET ref_id_str = BIN_TO_UUID(ref_id);
+        ALTER TABLE transactions
+            DROP INDEX uk_ref_id,
+            DROP COLUMN ref_id,
+            CHANGE COLUMN ref_id_str ref_id VARCHAR(36) NOT NULL COMMENT '外部冪等金鑰 (UUID 字串, 含連字號)',
+            ADD UNIQUE KEY uk_ref_id (ref_id);
+    END IF;
+END //
+DELIMITER ;
+
+CALL migrate_ref_id_varchar();
+DROP PROCEDURE migrate_ref_id_varchar;
+
+-- View: 開發者可讀的交易表 (ref_id 已是字串，不需 BIN_TO_UUID)
+CREATE OR REPLACE VIEW human_readable_transactions AS
+SELECT
+    id,
+    ref_id as uuid,
+    sequence,
+    from_account_id,
+    to_account_id,
+    amount / 10000.0 as real_amount,
+    type,
+    FROM_UNIXTIME(created_at / 1000) as created_at
+FROM transactions;
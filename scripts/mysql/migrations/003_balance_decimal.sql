@@ -0,0 +1,34 @@
+-- users.balance 由 BIGINT (放大 10000 倍的定點數) 改為 DECIMAL(20,4) (實際金額)，搭配 ledger.decimal_storage: true 使用
+-- 欄位保證最多 4 位小數，SQL 可直接 SUM(balance) 得到正確的金額；讀寫時需要轉換，預估約 15% 額外負擔
+-- 只在要啟用 decimal_storage 時執行；重複執行不會有影響 (已是 decimal 時略過)
+-- 放在子目錄中，MySQL 容器初始化時不會自動執行
+-- 轉換期間會鎖住 users 表，請在停機 (Core 已停止) 時執行；Core 啟動時 (Init) 會確認欄位型別與設定一致
+USE ledger_db;
+
+DELIMITER //
+CREATE PROCEDURE migrate_balance_decimal()
+BEGIN
+    IF (SELECT DATA_TYPE FROM information_schema.COLUMNS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'users' AND COLUMN_NAME = 'balance') = 'bigint' THEN
+        -- 另建欄位再複製：直接 MODIFY 會把放大後的整數當成金額
+        ALTER TABLE users
+            ADD COLUMN balance_decimal DECIMAL(20,4) NOT NULL DEFAULT 0 AFTER balance;
+        UPDATE users SET balance_decimal = balance / 10000;
+        ALTER TABLE users
+            DROP COLUMN balance,
+            CHANGE COLUMN balance_decimal balance DECIMAL(20,4) NOT NULL DEFAULT 0 COMMENT '餘額 (實際金額, 4 位小數)';
+    END IF;
+END //
+DELIMITER ;
+
+CALL migrate_balance_decimal();
+DROP PROCEDURE migrate_balance_decimal;
+
+-- View: 開發者可讀的餘額表 (balance 已是實際金額，不需除以 10000)
+CREATE OR REPLACE VIEW human_readable_users AS
+SELECT
+    id,
+    balance as real_balance,
+    FROM_UNIXTIME(created_at / 1000) as created_at,
+    FROM_UNIXTIME(updated_at / 1000) as updated_at
+FROM users;
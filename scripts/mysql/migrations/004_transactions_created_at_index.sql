@@ -0,0 +1,17 @@
+-- transactions.created_at 索引：cmd/purge 以 created_at 分批刪除舊交易，沒有索引時每一批都會全表掃描
+-- 新建立的資料庫已由 01_schema.sql 建立此索引，不需執行；重複執行不會有影響 (索引已存在時略過)
+-- 放在子目錄中，MySQL 容器初始化時不會自動執行
+USE ledger_db;
+
+DELIMITER //
+CREATE PROCEDURE migrate_transactions_created_at_index()
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.STATISTICS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'transactions' AND INDEX_NAME = 'idx_created_at') THEN
+        ALTER TABLE transactions ADD KEY idx_created_at (created_at);
+    END IF;
+END //
+DELIMITER ;
+
+CALL migrate_transactions_created_at_index();
+DROP PROCEDURE migrate_transactions_created_at_index;